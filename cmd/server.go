@@ -1,9 +1,16 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"time"
 
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/app/repository"
 	"github.com/osamaesmail/go-post-api/internal/db/migration"
+	"github.com/osamaesmail/go-post-api/internal/db/mysql"
+	"github.com/osamaesmail/go-post-api/internal/db/redis"
 	"github.com/osamaesmail/go-post-api/internal/logger"
 	"github.com/osamaesmail/go-post-api/internal/server"
 	cli "github.com/urfave/cli/v2"
@@ -66,6 +73,22 @@ func ExecuteServer() error {
 				return nil
 			},
 		},
+		{
+			Name:        "role",
+			Description: "role assigns a role to the account registered with the given email, use it to promote the first admin",
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "email", Required: true},
+				&cli.StringFlag{Name: "role", Required: true},
+			},
+			Action: func(c *cli.Context) error {
+				err := setRole(c.Context, c.String("email"), c.String("role"))
+				if err != nil {
+					return err
+				}
+				logger.Log().Info().Msgf("role %s assigned to %s", c.String("role"), c.String("email"))
+				return nil
+			},
+		},
 		{
 			Name:        "start",
 			Description: "start the server",
@@ -88,3 +111,40 @@ func ExecuteServer() error {
 
 	return app.Run(os.Args)
 }
+
+func setRole(ctx context.Context, email, role string) error {
+	switch role {
+	case model.RoleUser, model.RoleModerator, model.RoleAdmin:
+	default:
+		return fmt.Errorf("unknown role %q", role)
+	}
+
+	mysqlClient, err := mysql.NewClient()
+	if err != nil {
+		return err
+	}
+	defer mysqlClient.Close()
+
+	redisClient, err := redis.NewClient()
+	if err != nil {
+		return err
+	}
+	defer redisClient.Close()
+
+	accountRepository := repository.NewAccountRepository(mysqlClient, redisClient)
+	tokenRepository := repository.NewTokenRepository(redisClient)
+
+	account, err := accountRepository.GetByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+
+	account.Role = role
+	account.UpdatedAt.Time = time.Now()
+	err = accountRepository.Update(ctx, account)
+	if err != nil {
+		return err
+	}
+
+	return tokenRepository.SetRevokedBefore(ctx, account.ID, time.Now())
+}
@@ -16,11 +16,15 @@ require (
 	github.com/golang-migrate/migrate/v4 v4.14.1
 	github.com/leodido/go-urn v1.2.1 // indirect
 	github.com/rs/zerolog v1.22.0
+	github.com/russross/blackfriday/v2 v2.0.1
 	github.com/spf13/viper v1.7.1
 	github.com/stretchr/testify v1.7.0
 	github.com/swaggo/http-swagger v1.0.0
 	github.com/swaggo/swag v1.7.0
 	github.com/urfave/cli/v2 v2.3.0
 	golang.org/x/crypto v0.0.0-20200709230013-948cd5f35899
+	golang.org/x/image v0.0.0-20210628002857-a66eb6448b8d
+	golang.org/x/net v0.0.0-20201207224615-747e23833adb
+	golang.org/x/text v0.3.6
 	gopkg.in/go-playground/assert.v1 v1.2.1 // indirect
 )
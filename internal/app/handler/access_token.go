@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/app/service"
+	"github.com/osamaesmail/go-post-api/internal/constant"
+	"github.com/osamaesmail/go-post-api/internal/validation"
+	"github.com/osamaesmail/go-post-api/internal/web"
+)
+
+type AccessTokenHandler interface {
+	Create() http.HandlerFunc
+	List() http.HandlerFunc
+	Delete() http.HandlerFunc
+}
+
+func NewAccessTokenHandler(accessTokenService service.AccessTokenService) AccessTokenHandler {
+	return &accessTokenHandler{accessTokenService}
+}
+
+type accessTokenHandler struct {
+	accessTokenService service.AccessTokenService
+}
+
+// @Router /accounts/{account_id}/tokens [post]
+// @Tags access tokens
+// @Summary Create personal access token
+// @Description The token is only returned once, send it in the X-API-Key header like a JWT
+// @Accept json
+// @Produce json
+// @Param account_id path int true "account id" Format(int64)
+// @Param payload body model.AccessTokenCreateRequest true "body request"
+// @Success 201 {object} model.AccessTokenCreateResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Security ApiKeyAuth
+func (h *accessTokenHandler) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accountID, err := web.GetUrlPathInt64(r, "account_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.AccessTokenCreateRequest{AccountID: accountID}
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+			return
+		}
+
+		err = validation.Struct(req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := h.accessTokenService.Create(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrUnauthorized:
+				web.MarshalError(w, http.StatusUnauthorized, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalPayload(w, http.StatusCreated, res)
+	}
+}
+
+// @Router /accounts/{account_id}/tokens [get]
+// @Tags access tokens
+// @Summary List personal access tokens
+// @Description TODO
+// @Produce json
+// @Param account_id path int true "account id" Format(int64)
+// @Success 200 {array} model.AccessTokenResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Security ApiKeyAuth
+func (h *accessTokenHandler) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accountID, err := web.GetUrlPathInt64(r, "account_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.AccessTokenListRequest{AccountID: accountID}
+		res, err := h.accessTokenService.List(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrUnauthorized:
+				web.MarshalError(w, http.StatusUnauthorized, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+// @Router /accounts/{account_id}/tokens/{token_id} [delete]
+// @Tags access tokens
+// @Summary Revoke personal access token
+// @Description TODO
+// @Produce json
+// @Param account_id path int true "account id" Format(int64)
+// @Param token_id path int true "access token id" Format(int64)
+// @Success 204
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Security ApiKeyAuth
+func (h *accessTokenHandler) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accountID, err := web.GetUrlPathInt64(r, "account_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		id, err := web.GetUrlPathInt64(r, "token_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.AccessTokenDeleteRequest{ID: id, AccountID: accountID}
+		err = h.accessTokenService.Delete(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrUnauthorized:
+				web.MarshalError(w, http.StatusUnauthorized, err)
+				return
+			case constant.ErrAccessTokenNotFound:
+				web.MarshalError(w, http.StatusNotFound, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -2,6 +2,8 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/osamaesmail/go-post-api/internal/app/model"
@@ -15,8 +17,15 @@ type AccountHandler interface {
 	Create() http.HandlerFunc
 	List() http.HandlerFunc
 	Get() http.HandlerFunc
+	GetByHandle() http.HandlerFunc
 	Update() http.HandlerFunc
+	UpdateHandle() http.HandlerFunc
 	UpdatePassword() http.HandlerFunc
+	ForgotPassword() http.HandlerFunc
+	ResetPassword() http.HandlerFunc
+	UpdateRole() http.HandlerFunc
+	Verify() http.HandlerFunc
+	ResendVerification() http.HandlerFunc
 	Delete() http.HandlerFunc
 }
 
@@ -56,8 +65,12 @@ func (h *accountHandler) Create() http.HandlerFunc {
 
 		res, err := h.accountService.Create(r.Context(), req)
 		if err != nil {
+			if errors.Is(err, constant.ErrPasswordPolicy) {
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
+			}
 			switch err {
-			case constant.ErrEmailRegistered:
+			case constant.ErrEmailRegistered, constant.ErrHandleTaken:
 				web.MarshalError(w, http.StatusConflict, err)
 				return
 			default:
@@ -73,14 +86,16 @@ func (h *accountHandler) Create() http.HandlerFunc {
 // @Router /accounts [get]
 // @Tags accounts
 // @Summary List accounts
-// @Description TODO
+// @Description Email and the other private fields are only included for the caller's own account, or for every account when the caller is an admin
 // @Produce json
 // @Param limit query int false "pagination limit"
 // @Param offset query int false "pagination offset"
 // @Param name query string false "account name"
 // @Success 200 {array} model.AccountResponse
 // @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
+// @Security ApiKeyAuth
 func (h *accountHandler) List() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		limit, offset, err := web.GetPagination(r)
@@ -108,14 +123,16 @@ func (h *accountHandler) List() http.HandlerFunc {
 // @Router /accounts/{account_id} [get]
 // @Tags accounts
 // @Summary Get account
-// @Description TODO
+// @Description Email and the other private fields are only included for the caller's own account, or when the caller is an admin. `/accounts/@{handle}` gets the account by its case insensitive handle the same way, a handle it used before redirects to the current one
 // @Accept json
 // @Produce json
 // @Param account_id path int true "account id" Format(int64)
 // @Success 200 {object} model.AccountResponse
 // @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
 // @Failure 404 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
+// @Security ApiKeyAuth
 func (h *accountHandler) Get() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id, err := web.GetUrlPathInt64(r, "account_id")
@@ -141,6 +158,39 @@ func (h *accountHandler) Get() http.HandlerFunc {
 	}
 }
 
+// GetByHandle serves /accounts/@{handle}, which swag cannot describe, it is
+// documented along with Get. A handle the account used before redirects to
+// its current one.
+func (h *accountHandler) GetByHandle() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := model.AccountHandleGetRequest{Handle: web.GetUrlPathString(r, "handle")}
+		err := validation.Struct(req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := h.accountService.GetByHandle(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrAccountNotFound:
+				web.MarshalError(w, http.StatusNotFound, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		if res.Handle != nil && *res.Handle != req.Handle {
+			http.Redirect(w, r, fmt.Sprintf("/v1/accounts/@%s", *res.Handle), http.StatusMovedPermanently)
+			return
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
 // @Router /accounts/{account_id} [put]
 // @Tags accounts
 // @Summary Update account
@@ -183,6 +233,9 @@ func (h *accountHandler) Update() http.HandlerFunc {
 			case constant.ErrUnauthorized:
 				web.MarshalError(w, http.StatusUnauthorized, err)
 				return
+			case constant.ErrAvatarURLNotOwn:
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
 			case constant.ErrEmailRegistered:
 				web.MarshalError(w, http.StatusConflict, err)
 				return
@@ -199,6 +252,64 @@ func (h *accountHandler) Update() http.HandlerFunc {
 	}
 }
 
+// @Router /accounts/{account_id}/handle [put]
+// @Tags accounts
+// @Summary Update account handle
+// @Description The previous handle keeps redirecting to the account and stays reserved for it
+// @Accept json
+// @Produce json
+// @Param account_id path int true "account id" Format(int64)
+// @Param payload body model.AccountHandleUpdateRequest true "body request"
+// @Success 200 {object} model.AccountResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Security ApiKeyAuth
+func (h *accountHandler) UpdateHandle() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := web.GetUrlPathInt64(r, "account_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.AccountHandleUpdateRequest{ID: id}
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+			return
+		}
+
+		err = validation.Struct(req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := h.accountService.UpdateHandle(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrUnauthorized:
+				web.MarshalError(w, http.StatusUnauthorized, err)
+				return
+			case constant.ErrHandleTaken:
+				web.MarshalError(w, http.StatusConflict, err)
+				return
+			case constant.ErrAccountNotFound:
+				web.MarshalError(w, http.StatusNotFound, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
 // @Router /accounts/{account_id}/password [put]
 // @Tags accounts
 // @Summary Update account password
@@ -236,6 +347,10 @@ func (h *accountHandler) UpdatePassword() http.HandlerFunc {
 
 		res, err := h.accountService.UpdatePassword(r.Context(), req)
 		if err != nil {
+			if errors.Is(err, constant.ErrPasswordPolicy) {
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
+			}
 			switch err {
 			case constant.ErrUnauthorized, constant.ErrWrongPassword:
 				web.MarshalError(w, http.StatusUnauthorized, err)
@@ -253,10 +368,232 @@ func (h *accountHandler) UpdatePassword() http.HandlerFunc {
 	}
 }
 
+// @Router /accounts/password/forgot [post]
+// @Tags accounts
+// @Summary Request password reset
+// @Description Emails a single use reset token when the email is registered, the response is the same either way
+// @Accept json
+// @Produce json
+// @Param payload body model.AccountPasswordForgotRequest true "body request"
+// @Success 202
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+func (h *accountHandler) ForgotPassword() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req model.AccountPasswordForgotRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+			return
+		}
+
+		err = validation.Struct(req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		err = h.accountService.ForgotPassword(r.Context(), req)
+		if err != nil {
+			web.MarshalError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// @Router /accounts/password/reset [post]
+// @Tags accounts
+// @Summary Reset password
+// @Description Redeems a reset token and logs the account out everywhere
+// @Accept json
+// @Produce json
+// @Param payload body model.AccountPasswordResetRequest true "body request"
+// @Success 204
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+func (h *accountHandler) ResetPassword() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req model.AccountPasswordResetRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+			return
+		}
+
+		err = validation.Struct(req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		err = h.accountService.ResetPassword(r.Context(), req)
+		if err != nil {
+			if errors.Is(err, constant.ErrPasswordPolicy) {
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
+			}
+			switch err {
+			case constant.ErrInvalidResetToken:
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// @Router /accounts/verify [get]
+// @Tags accounts
+// @Summary Verify email
+// @Description Redeems a verification token, a pending email replaces the current one
+// @Accept json
+// @Produce json
+// @Param token query string true "verification token"
+// @Success 200 {object} model.AccountResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+func (h *accountHandler) Verify() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := model.AccountVerifyRequest{
+			Token: web.GetUrlQueryString(r, "token"),
+		}
+
+		err := validation.Struct(req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := h.accountService.Verify(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrInvalidVerifyToken:
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
+			case constant.ErrEmailRegistered:
+				web.MarshalError(w, http.StatusConflict, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+// @Router /accounts/{account_id}/verification [post]
+// @Tags accounts
+// @Summary Resend verification email
+// @Description Sends a new token to the pending email, or to the current one while it is unverified
+// @Accept json
+// @Produce json
+// @Param account_id path int true "account id" Format(int64)
+// @Success 202
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Security ApiKeyAuth
+func (h *accountHandler) ResendVerification() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := web.GetUrlPathInt64(r, "account_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.AccountVerificationResendRequest{ID: id}
+		err = h.accountService.ResendVerification(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrUnauthorized:
+				web.MarshalError(w, http.StatusUnauthorized, err)
+				return
+			case constant.ErrAccountNotFound:
+				web.MarshalError(w, http.StatusNotFound, err)
+				return
+			case constant.ErrEmailVerified:
+				web.MarshalError(w, http.StatusConflict, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// @Router /accounts/{account_id}/role [put]
+// @Tags accounts
+// @Summary Update account role
+// @Description Admin only, the account has to log in again for the new role to apply
+// @Accept json
+// @Produce json
+// @Param account_id path int true "account id" Format(int64)
+// @Param payload body model.AccountRoleUpdateRequest true "body request"
+// @Success 200 {object} model.AccountResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Security ApiKeyAuth
+func (h *accountHandler) UpdateRole() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := web.GetUrlPathInt64(r, "account_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.AccountRoleUpdateRequest{ID: id}
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+			return
+		}
+
+		err = validation.Struct(req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := h.accountService.UpdateRole(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrForbidden:
+				web.MarshalError(w, http.StatusForbidden, err)
+				return
+			case constant.ErrAccountNotFound:
+				web.MarshalError(w, http.StatusNotFound, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
 // @Router /accounts/{account_id} [delete]
 // @Tags accounts
 // @Summary Delete account
-// @Description TODO
+// @Description Hides the account right away, logging in within ACCOUNT_DELETE_GRACE restores it. After that its content is anonymized or deleted
 // @Produce json
 // @Param account_id path int true "account id" Format(int64)
 // @Success 204
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/app/service"
+	"github.com/osamaesmail/go-post-api/internal/constant"
+	"github.com/osamaesmail/go-post-api/internal/web"
+)
+
+type AuditHandler interface {
+	List() http.HandlerFunc
+}
+
+func NewAuditHandler(auditService service.AuditService) AuditHandler {
+	return &auditHandler{auditService}
+}
+
+type auditHandler struct {
+	auditService service.AuditService
+}
+
+// @Router /audit [get]
+// @Tags audit
+// @Summary List audit events
+// @Description Lists the audit log newest first, pass next_cursor as cursor to get the next page
+// @Produce json
+// @Param limit query int false "pagination limit"
+// @Param cursor query string false "pagination cursor"
+// @Param actor_id query int false "actor account id" Format(int64)
+// @Param action query string false "action, e.g. account.delete"
+// @Param target_type query string false "target type, e.g. post"
+// @Param target_id query int false "target id" Format(int64)
+// @Success 200 {object} model.AuditListResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Security ApiKeyAuth
+func (h *auditHandler) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, _, err := web.GetPagination(r)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.AuditListRequest{
+			Action:     web.GetUrlQueryString(r, "action"),
+			TargetType: web.GetUrlQueryString(r, "target_type"),
+			Cursor:     web.GetUrlQueryString(r, "cursor"),
+			Limit:      limit,
+		}
+		if web.GetUrlQueryString(r, "actor_id") != "" {
+			req.ActorID, err = web.GetUrlQueryInt64(r, "actor_id")
+			if err != nil {
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+		if web.GetUrlQueryString(r, "target_id") != "" {
+			req.TargetID, err = web.GetUrlQueryInt64(r, "target_id")
+			if err != nil {
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+
+		res, err := h.auditService.List(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrUrlQueryParameter:
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
+			case constant.ErrForbidden:
+				web.MarshalError(w, http.StatusForbidden, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
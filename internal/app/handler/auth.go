@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
 
 	"github.com/osamaesmail/go-post-api/internal/app/model"
@@ -13,6 +14,13 @@ import (
 
 type AuthHandler interface {
 	Login() http.HandlerFunc
+	LoginTOTP() http.HandlerFunc
+	Refresh() http.HandlerFunc
+	Logout() http.HandlerFunc
+	JWKS() http.HandlerFunc
+	Impersonate() http.HandlerFunc
+	OauthStart() http.HandlerFunc
+	OauthCallback() http.HandlerFunc
 }
 
 func NewAuthHandler(authService service.AuthService) AuthHandler {
@@ -26,13 +34,14 @@ type authHandler struct {
 // @Router /accounts/auth [post]
 // @Tags auth
 // @Summary Login account
-// @Description TODO
+// @Description Returns only a challenge_token when two-factor authentication is enabled, exchange it at /accounts/auth/totp
 // @Accept json
 // @Produce json
 // @Param payload body model.AuthRequest true "body request"
 // @Success 200 {object} model.AuthResponse
 // @Failure 400 {object} model.ErrorResponse
 // @Failure 401 {object} model.ErrorResponse
+// @Failure 429 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
 func (h *authHandler) Login() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -49,12 +58,281 @@ func (h *authHandler) Login() http.HandlerFunc {
 			return
 		}
 
+		req.IP = web.GetClientIP(r)
+		req.UserAgent = r.UserAgent()
 		res, err := h.authService.Login(r.Context(), req)
 		if err != nil {
 			switch err {
-			case constant.ErrEmailNotRegistered, constant.ErrWrongPassword:
+			case constant.ErrInvalidCredentials:
 				web.MarshalError(w, http.StatusUnauthorized, err)
 				return
+			case constant.ErrTooManyAttempts:
+				web.MarshalError(w, http.StatusTooManyRequests, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+// @Router /accounts/auth/totp [post]
+// @Tags auth
+// @Summary Login with a second factor
+// @Description Exchanges the challenge token of a login and a TOTP or recovery code, the challenge can only be tried once
+// @Accept json
+// @Produce json
+// @Param payload body model.AuthTOTPRequest true "body request"
+// @Success 200 {object} model.AuthResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 429 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+func (h *authHandler) LoginTOTP() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req model.AuthTOTPRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+			return
+		}
+
+		err = validation.Struct(req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req.IP = web.GetClientIP(r)
+		req.UserAgent = r.UserAgent()
+		res, err := h.authService.LoginTOTP(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrInvalidChallenge, constant.ErrInvalidTOTPCode:
+				web.MarshalError(w, http.StatusUnauthorized, err)
+				return
+			case constant.ErrTooManyAttempts:
+				web.MarshalError(w, http.StatusTooManyRequests, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+// @Router /accounts/auth/refresh [post]
+// @Tags auth
+// @Summary Refresh access token
+// @Description Exchanges a refresh token for a new access token and a new refresh token, the exchanged refresh token can not be used again
+// @Accept json
+// @Produce json
+// @Param payload body model.AuthRefreshRequest true "body request"
+// @Success 200 {object} model.AuthResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+func (h *authHandler) Refresh() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req model.AuthRefreshRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+			return
+		}
+
+		err = validation.Struct(req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := h.authService.Refresh(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrInvalidRefreshToken:
+				web.MarshalError(w, http.StatusUnauthorized, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+// @Router /accounts/auth/logout [post]
+// @Tags auth
+// @Summary Logout account
+// @Description Revokes the access token of the request, and the refresh token chain when a refresh token is given
+// @Accept json
+// @Produce json
+// @Param payload body model.AuthLogoutRequest false "body request"
+// @Success 204
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Security ApiKeyAuth
+func (h *authHandler) Logout() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req model.AuthLogoutRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil && err != io.EOF {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+			return
+		}
+
+		err = h.authService.Logout(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrUnauthorized:
+				web.MarshalError(w, http.StatusUnauthorized, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// JWKS serves the public keys that verify issued tokens at
+// /.well-known/jwks.json, outside of the documented /v1 base path. The set is
+// empty while tokens are signed with HS256.
+func (h *authHandler) JWKS() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		res, err := h.authService.JWKS(r.Context())
+		if err != nil {
+			web.MarshalError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+// @Router /accounts/{account_id}/impersonate [post]
+// @Tags auth
+// @Summary Impersonate account
+// @Description Issues a short lived token to act as the account, requests made with it are audited and deleting the account or changing its password, role, two-factor authentication or access tokens is refused. Admins can not be impersonated
+// @Produce json
+// @Param account_id path int true "account id" Format(int64)
+// @Success 200 {object} model.ImpersonateResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Security ApiKeyAuth
+func (h *authHandler) Impersonate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accountID, err := web.GetUrlPathInt64(r, "account_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := h.authService.Impersonate(r.Context(), model.ImpersonateRequest{AccountID: accountID})
+		if err != nil {
+			switch err {
+			case constant.ErrForbidden:
+				web.MarshalError(w, http.StatusForbidden, err)
+				return
+			case constant.ErrAccountNotFound:
+				web.MarshalError(w, http.StatusNotFound, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+// @Router /accounts/oauth/{provider}/start [get]
+// @Tags auth
+// @Summary Login with an OpenID Connect provider
+// @Description Redirects to the provider, which redirects back to /accounts/oauth/{provider}/callback
+// @Param provider path string true "provider name"
+// @Success 302
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+func (h *authHandler) OauthStart() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := model.OauthStartRequest{
+			Provider: web.GetUrlPathString(r, "provider"),
+		}
+
+		authCodeURL, err := h.authService.OauthStart(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrOauthProviderNotFound:
+				web.MarshalError(w, http.StatusNotFound, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		http.Redirect(w, r, authCodeURL, http.StatusFound)
+	}
+}
+
+// @Router /accounts/oauth/{provider}/callback [get]
+// @Tags auth
+// @Summary Finish a login with an OpenID Connect provider
+// @Description Signs in the account linked to the provider identity, or links it to the account with the same verified email, or creates an account without a password. Returns only a challenge_token when two-factor authentication is enabled
+// @Produce json
+// @Param provider path string true "provider name"
+// @Param code query string false "authorization code"
+// @Param state query string true "state"
+// @Param error query string false "error of the provider"
+// @Success 200 {object} model.AuthResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+func (h *authHandler) OauthCallback() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := model.OauthCallbackRequest{
+			Provider:  web.GetUrlPathString(r, "provider"),
+			Code:      web.GetUrlQueryString(r, "code"),
+			State:     web.GetUrlQueryString(r, "state"),
+			Error:     web.GetUrlQueryString(r, "error"),
+			IP:        web.GetClientIP(r),
+			UserAgent: r.UserAgent(),
+		}
+
+		res, err := h.authService.OauthCallback(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrInvalidOauthState, constant.ErrOauthEmailNotVerified:
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
+			case constant.ErrOauthDenied:
+				web.MarshalError(w, http.StatusUnauthorized, err)
+				return
+			case constant.ErrOauthProviderNotFound:
+				web.MarshalError(w, http.StatusNotFound, err)
+				return
+			case constant.ErrOauthAccountUnverified:
+				web.MarshalError(w, http.StatusConflict, err)
+				return
 			default:
 				web.MarshalError(w, http.StatusInternalServerError, err)
 				return
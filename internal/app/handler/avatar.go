@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/app/service"
+	"github.com/osamaesmail/go-post-api/internal/config"
+	"github.com/osamaesmail/go-post-api/internal/constant"
+	"github.com/osamaesmail/go-post-api/internal/web"
+)
+
+// avatarFormMemory is how much of a multipart form is held in memory, the
+// rest of it goes to temporary files.
+const avatarFormMemory = 1 << 20
+
+type AvatarHandler interface {
+	Update() http.HandlerFunc
+	Get() http.HandlerFunc
+}
+
+func NewAvatarHandler(avatarService service.AvatarService) AvatarHandler {
+	return &avatarHandler{avatarService}
+}
+
+type avatarHandler struct {
+	avatarService service.AvatarService
+}
+
+// @Router /accounts/{account_id}/avatar [put]
+// @Tags accounts
+// @Summary Upload avatar
+// @Description Takes a JPEG, PNG or GIF no larger than AVATAR_MAX_SIZE bytes and AVATAR_MAX_PIXELS pixels, what the file holds is sniffed from its bytes. It is cropped to a square, scaled to every size in AVATAR_SIZES and stored without its metadata
+// @Accept mpfd
+// @Produce json
+// @Param account_id path int true "account id" Format(int64)
+// @Param avatar formData file true "avatar image"
+// @Success 200 {object} model.AccountResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 413 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Security ApiKeyAuth
+func (h *avatarHandler) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := web.GetUrlPathInt64(r, "account_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		// leaves room for the rest of the form around the file
+		r.Body = http.MaxBytesReader(w, r.Body, config.Cfg().AvatarMaxSize+avatarFormMemory)
+		err = r.ParseMultipartForm(avatarFormMemory)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+
+		_, header, err := r.FormFile("avatar")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+			return
+		} else if header.Size > config.Cfg().AvatarMaxSize {
+			web.MarshalError(w, http.StatusRequestEntityTooLarge, constant.ErrAvatarTooLarge)
+			return
+		}
+
+		// the processing reads the upload several times, it gets a file of its
+		// own wherever the form kept it
+		upload, err := os.CreateTemp("", "avatar-*")
+		if err != nil {
+			web.MarshalError(w, http.StatusInternalServerError, constant.ErrServer)
+			return
+		}
+		upload.Close()
+		defer os.Remove(upload.Name())
+
+		err = web.SaveUploadedFile(header, upload.Name())
+		if err != nil {
+			web.MarshalError(w, http.StatusInternalServerError, constant.ErrServer)
+			return
+		}
+
+		file, err := os.Open(upload.Name())
+		if err != nil {
+			web.MarshalError(w, http.StatusInternalServerError, constant.ErrServer)
+			return
+		}
+		defer file.Close()
+
+		req := model.AccountAvatarUpdateRequest{ID: id, File: file}
+		res, err := h.avatarService.Update(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrInvalidAvatar:
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
+			case constant.ErrUnauthorized:
+				web.MarshalError(w, http.StatusUnauthorized, err)
+				return
+			case constant.ErrAccountNotFound:
+				web.MarshalError(w, http.StatusNotFound, err)
+				return
+			case constant.ErrAvatarTooLarge:
+				web.MarshalError(w, http.StatusRequestEntityTooLarge, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+// @Router /accounts/{account_id}/avatar [get]
+// @Tags accounts
+// @Summary Get avatar
+// @Description Serves the uploaded avatar, this is the avatar_url of accounts that uploaded one
+// @Produce png,jpeg
+// @Param account_id path int true "account id" Format(int64)
+// @Param size query int false "one of AVATAR_SIZES, the largest by default"
+// @Success 200
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+func (h *avatarHandler) Get() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := web.GetUrlPathInt64(r, "account_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.AccountAvatarGetRequest{ID: id}
+		if web.GetUrlQueryString(r, "size") != "" {
+			size, err := web.GetUrlQueryInt64(r, "size")
+			if err != nil {
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
+			}
+			req.Size = int(size)
+		}
+
+		avatar, err := h.avatarService.Get(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrUrlQueryParameter:
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
+			case constant.ErrAccountNotFound, constant.ErrAvatarNotFound:
+				web.MarshalError(w, http.StatusNotFound, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+		defer avatar.Close()
+
+		// the url stays the same when a new avatar is uploaded
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		http.ServeContent(w, r, "", time.Time{}, avatar)
+	}
+}
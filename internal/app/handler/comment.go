@@ -38,6 +38,8 @@ type commentHandler struct {
 // @Success 201 {object} model.CommentResponse
 // @Failure 400 {object} model.ErrorResponse
 // @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
 // @Security ApiKeyAuth
 func (h *commentHandler) Create() http.HandlerFunc {
@@ -61,6 +63,12 @@ func (h *commentHandler) Create() http.HandlerFunc {
 			case constant.ErrUnauthorized:
 				web.MarshalError(w, http.StatusUnauthorized, err)
 				return
+			case constant.ErrEmailNotVerified:
+				web.MarshalError(w, http.StatusForbidden, err)
+				return
+			case constant.ErrPostNotFound:
+				web.MarshalError(w, http.StatusNotFound, err)
+				return
 			default:
 				web.MarshalError(w, http.StatusInternalServerError, err)
 				return
@@ -81,7 +89,9 @@ func (h *commentHandler) Create() http.HandlerFunc {
 // @Param post_id query int false "post id"
 // @Success 200 {array} model.CommentResponse
 // @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
+// @Security ApiKeyAuth
 func (h *commentHandler) List() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		limit, offset, err := web.GetPagination(r)
@@ -105,8 +115,14 @@ func (h *commentHandler) List() http.HandlerFunc {
 
 		res, err := h.commentService.List(r.Context(), req)
 		if err != nil {
-			web.MarshalError(w, http.StatusInternalServerError, err)
-			return
+			switch err {
+			case constant.ErrPostNotFound:
+				web.MarshalError(w, http.StatusNotFound, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
 		}
 
 		web.MarshalPayload(w, http.StatusOK, res)
@@ -124,6 +140,7 @@ func (h *commentHandler) List() http.HandlerFunc {
 // @Failure 400 {object} model.ErrorResponse
 // @Failure 404 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
+// @Security ApiKeyAuth
 func (h *commentHandler) Get() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id, err := web.GetUrlPathInt64(r, "comment_id")
@@ -136,7 +153,7 @@ func (h *commentHandler) Get() http.HandlerFunc {
 		res, err := h.commentService.Get(r.Context(), req)
 		if err != nil {
 			switch err {
-			case constant.ErrCommentNotFound:
+			case constant.ErrCommentNotFound, constant.ErrPostNotFound:
 				web.MarshalError(w, http.StatusNotFound, err)
 				return
 			default:
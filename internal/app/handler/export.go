@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/app/service"
+	"github.com/osamaesmail/go-post-api/internal/constant"
+	"github.com/osamaesmail/go-post-api/internal/web"
+)
+
+type ExportHandler interface {
+	Create() http.HandlerFunc
+	Get() http.HandlerFunc
+}
+
+func NewExportHandler(exportService service.ExportService) ExportHandler {
+	return &exportHandler{exportService}
+}
+
+type exportHandler struct {
+	exportService service.ExportService
+}
+
+// @Router /accounts/{account_id}/export [post]
+// @Tags exports
+// @Summary Export account data
+// @Description Starts building a ZIP of the account profile, posts and comments as JSON and Markdown. While an export is still pending or running it is returned instead of starting another
+// @Produce json
+// @Param account_id path int true "account id" Format(int64)
+// @Success 202 {object} model.ExportJobResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Security ApiKeyAuth
+func (h *exportHandler) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accountID, err := web.GetUrlPathInt64(r, "account_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.ExportCreateRequest{AccountID: accountID}
+		res, err := h.exportService.Create(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrUnauthorized:
+				web.MarshalError(w, http.StatusUnauthorized, err)
+				return
+			case constant.ErrAccountNotFound:
+				web.MarshalError(w, http.StatusNotFound, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalPayload(w, http.StatusAccepted, res)
+	}
+}
+
+// @Router /accounts/{account_id}/export/{job_id} [get]
+// @Tags exports
+// @Summary Get or download account export
+// @Description With expires and signature from download_url the archive is downloaded without further authentication, otherwise the export status is returned
+// @Produce json,application/zip
+// @Param account_id path int true "account id" Format(int64)
+// @Param job_id path int true "export job id" Format(int64)
+// @Param expires query int false "download link expiry"
+// @Param signature query string false "download link signature"
+// @Success 200 {object} model.ExportJobResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Security ApiKeyAuth
+func (h *exportHandler) Get() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accountID, err := web.GetUrlPathInt64(r, "account_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		id, err := web.GetUrlPathInt64(r, "job_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		if web.GetUrlQueryString(r, "signature") != "" {
+			h.download(w, r, accountID, id)
+			return
+		}
+
+		req := model.ExportGetRequest{ID: id, AccountID: accountID}
+		res, err := h.exportService.Get(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrUnauthorized:
+				web.MarshalError(w, http.StatusUnauthorized, err)
+				return
+			case constant.ErrExportNotFound:
+				web.MarshalError(w, http.StatusNotFound, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+func (h *exportHandler) download(w http.ResponseWriter, r *http.Request, accountID, id int64) {
+	expires, err := web.GetUrlQueryInt64(r, "expires")
+	if err != nil {
+		web.MarshalError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	req := model.ExportDownloadRequest{
+		ID:        id,
+		AccountID: accountID,
+		Expires:   expires,
+		Signature: web.GetUrlQueryString(r, "signature"),
+	}
+	archive, err := h.exportService.Download(r.Context(), req)
+	if err != nil {
+		switch err {
+		case constant.ErrInvalidExportLink:
+			web.MarshalError(w, http.StatusForbidden, err)
+			return
+		case constant.ErrExportNotFound:
+			web.MarshalError(w, http.StatusNotFound, err)
+			return
+		default:
+			web.MarshalError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	defer archive.Close()
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="export-%d.zip"`, id))
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, archive)
+}
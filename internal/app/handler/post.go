@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/osamaesmail/go-post-api/internal/app/model"
@@ -15,6 +16,7 @@ type PostHandler interface {
 	Create() http.HandlerFunc
 	List() http.HandlerFunc
 	Get() http.HandlerFunc
+	GetBySlug() http.HandlerFunc
 	Update() http.HandlerFunc
 	Delete() http.HandlerFunc
 }
@@ -30,13 +32,14 @@ type postHandler struct {
 // @Router /posts [post]
 // @Tags posts
 // @Summary Create post
-// @Description TODO
+// @Description Published right away unless status says otherwise, scheduled posts are published at publish_at. A markdown body_format is rendered to sanitized body_html
 // @Accept json
 // @Produce json
 // @Param payload body model.PostCreateRequest true "body request"
 // @Success 201 {object} model.PostResponse
 // @Failure 400 {object} model.ErrorResponse
 // @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
 // @Security ApiKeyAuth
 func (h *postHandler) Create() http.HandlerFunc {
@@ -57,9 +60,15 @@ func (h *postHandler) Create() http.HandlerFunc {
 		res, err := h.postService.Create(r.Context(), req)
 		if err != nil {
 			switch err {
+			case constant.ErrInvalidPublishAt:
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
 			case constant.ErrUnauthorized:
 				web.MarshalError(w, http.StatusUnauthorized, err)
 				return
+			case constant.ErrEmailNotVerified:
+				web.MarshalError(w, http.StatusForbidden, err)
+				return
 			default:
 				web.MarshalError(w, http.StatusInternalServerError, err)
 				return
@@ -73,14 +82,19 @@ func (h *postHandler) Create() http.HandlerFunc {
 // @Router /posts [get]
 // @Tags posts
 // @Summary List posts
-// @Description TODO
+// @Description Lists published posts, any other status lists the posts of the caller in that status
 // @Produce json
 // @Param limit query int false "pagination limit"
 // @Param offset query int false "pagination offset"
 // @Param title query string false "post title"
+// @Param status query string false "post status" Enums(draft, scheduled, published, archived)
+// @Param tag query []string false "posts with these tags" collectionFormat(multi)
+// @Param tag_mode query string false "whether posts need all tags or any of them, all by default" Enums(all, any)
 // @Success 200 {array} model.PostResponse
 // @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
+// @Security ApiKeyAuth
 func (h *postHandler) List() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		limit, offset, err := web.GetPagination(r)
@@ -90,15 +104,27 @@ func (h *postHandler) List() http.HandlerFunc {
 		}
 
 		req := model.PostListRequest{
-			Limit:  limit,
-			Offset: offset,
-			Title:  web.GetUrlQueryString(r, "title"),
+			Limit:   limit,
+			Offset:  offset,
+			Title:   web.GetUrlQueryString(r, "title"),
+			Status:  web.GetUrlQueryString(r, "status"),
+			Tags:    web.GetUrlQueryStrings(r, "tag"),
+			TagMode: web.GetUrlQueryString(r, "tag_mode"),
 		}
 
 		res, err := h.postService.List(r.Context(), req)
 		if err != nil {
-			web.MarshalError(w, http.StatusInternalServerError, err)
-			return
+			switch err {
+			case constant.ErrUrlQueryParameter:
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
+			case constant.ErrUnauthorized:
+				web.MarshalError(w, http.StatusUnauthorized, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
 		}
 
 		web.MarshalPayload(w, http.StatusOK, res)
@@ -108,7 +134,7 @@ func (h *postHandler) List() http.HandlerFunc {
 // @Router /posts/{post_id} [get]
 // @Tags posts
 // @Summary Get post
-// @Description TODO
+// @Description Posts that are not published are only shown to their author and moderators
 // @Accept json
 // @Produce json
 // @Param post_id path int true "post id" Format(int64)
@@ -116,6 +142,7 @@ func (h *postHandler) List() http.HandlerFunc {
 // @Failure 400 {object} model.ErrorResponse
 // @Failure 404 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
+// @Security ApiKeyAuth
 func (h *postHandler) Get() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id, err := web.GetUrlPathInt64(r, "post_id")
@@ -141,10 +168,53 @@ func (h *postHandler) Get() http.HandlerFunc {
 	}
 }
 
+// @Router /posts/by-slug/{slug} [get]
+// @Tags posts
+// @Summary Get post by slug
+// @Description A slug the post had before redirects to its current one
+// @Accept json
+// @Produce json
+// @Param slug path string true "post slug"
+// @Success 200 {object} model.PostResponse
+// @Success 301
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Security ApiKeyAuth
+func (h *postHandler) GetBySlug() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := model.PostSlugGetRequest{Slug: web.GetUrlPathString(r, "slug")}
+		err := validation.Struct(req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := h.postService.GetBySlug(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrPostNotFound:
+				web.MarshalError(w, http.StatusNotFound, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		if res.Slug != req.Slug {
+			http.Redirect(w, r, fmt.Sprintf("/v1/posts/by-slug/%s", res.Slug), http.StatusMovedPermanently)
+			return
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
 // @Router /posts/{post_id} [put]
 // @Tags posts
 // @Summary Update post
-// @Description TODO
+// @Description Keeps the status when it is left out
 // @Accept json
 // @Produce json
 // @Param post_id path int true "post id" Format(int64)
@@ -179,6 +249,9 @@ func (h *postHandler) Update() http.HandlerFunc {
 		res, err := h.postService.Update(r.Context(), req)
 		if err != nil {
 			switch err {
+			case constant.ErrInvalidPublishAt:
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
 			case constant.ErrUnauthorized:
 				web.MarshalError(w, http.StatusUnauthorized, err)
 				return
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/app/service"
+	"github.com/osamaesmail/go-post-api/internal/constant"
+	"github.com/osamaesmail/go-post-api/internal/web"
+)
+
+type SearchHandler interface {
+	Search() http.HandlerFunc
+}
+
+func NewSearchHandler(searchService service.SearchService) SearchHandler {
+	return &searchHandler{searchService}
+}
+
+type searchHandler struct {
+	searchService service.SearchService
+}
+
+// @Router /search [get]
+// @Tags search
+// @Summary Search posts and comments
+// @Description Searches published posts and the comments on them, the best matches first. The snippet is HTML escaped with the matching words in mark elements
+// @Produce json
+// @Param q query string true "words to search for"
+// @Param type query string false "only posts or only comments" Enums(post, comment)
+// @Param account_id query int false "author account id" Format(int64)
+// @Param from query string false "created at or after, RFC 3339" Format(date-time)
+// @Param to query string false "created before, RFC 3339" Format(date-time)
+// @Param limit query int false "pagination limit"
+// @Param offset query int false "pagination offset"
+// @Success 200 {array} model.SearchResultResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+func (h *searchHandler) Search() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, offset, err := web.GetPagination(r)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.SearchRequest{
+			Query:  web.GetUrlQueryString(r, "q"),
+			Type:   web.GetUrlQueryString(r, "type"),
+			Limit:  limit,
+			Offset: offset,
+		}
+		if web.GetUrlQueryString(r, "account_id") != "" {
+			req.AccountID, err = web.GetUrlQueryInt64(r, "account_id")
+			if err != nil {
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+		if web.GetUrlQueryString(r, "from") != "" {
+			req.From, err = web.GetUrlQueryTime(r, "from")
+			if err != nil {
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+		if web.GetUrlQueryString(r, "to") != "" {
+			req.To, err = web.GetUrlQueryTime(r, "to")
+			if err != nil {
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+
+		res, err := h.searchService.Search(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrUrlQueryParameter:
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/app/service"
+	"github.com/osamaesmail/go-post-api/internal/constant"
+	"github.com/osamaesmail/go-post-api/internal/web"
+)
+
+type SessionHandler interface {
+	List() http.HandlerFunc
+	Delete() http.HandlerFunc
+}
+
+func NewSessionHandler(sessionService service.SessionService) SessionHandler {
+	return &sessionHandler{sessionService}
+}
+
+type sessionHandler struct {
+	sessionService service.SessionService
+}
+
+// @Router /accounts/{account_id}/sessions [get]
+// @Tags sessions
+// @Summary List sessions
+// @Description Lists the devices the account is logged in on, the most recently seen first
+// @Produce json
+// @Param account_id path int true "account id" Format(int64)
+// @Success 200 {array} model.SessionResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Security ApiKeyAuth
+func (h *sessionHandler) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accountID, err := web.GetUrlPathInt64(r, "account_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.SessionListRequest{AccountID: accountID}
+		res, err := h.sessionService.List(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrUnauthorized:
+				web.MarshalError(w, http.StatusUnauthorized, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+// @Router /accounts/{account_id}/sessions/{session_id} [delete]
+// @Tags sessions
+// @Summary Revoke session
+// @Description Logs the device out, its access and refresh tokens stop working
+// @Produce json
+// @Param account_id path int true "account id" Format(int64)
+// @Param session_id path int true "session id" Format(int64)
+// @Success 204
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Security ApiKeyAuth
+func (h *sessionHandler) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accountID, err := web.GetUrlPathInt64(r, "account_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		id, err := web.GetUrlPathInt64(r, "session_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.SessionDeleteRequest{ID: id, AccountID: accountID}
+		err = h.sessionService.Delete(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrUnauthorized:
+				web.MarshalError(w, http.StatusUnauthorized, err)
+				return
+			case constant.ErrSessionNotFound:
+				web.MarshalError(w, http.StatusNotFound, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/app/service"
+	"github.com/osamaesmail/go-post-api/internal/constant"
+	"github.com/osamaesmail/go-post-api/internal/validation"
+	"github.com/osamaesmail/go-post-api/internal/web"
+)
+
+type TagHandler interface {
+	List() http.HandlerFunc
+	Update() http.HandlerFunc
+}
+
+func NewTagHandler(tagService service.TagService) TagHandler {
+	return &tagHandler{tagService}
+}
+
+type tagHandler struct {
+	tagService service.TagService
+}
+
+// @Router /tags [get]
+// @Tags tags
+// @Summary List tags
+// @Description Lists the tags of published posts with how many use them, the most used first
+// @Produce json
+// @Param limit query int false "pagination limit"
+// @Param offset query int false "pagination offset"
+// @Success 200 {array} model.TagResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+func (h *tagHandler) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, offset, err := web.GetPagination(r)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.TagListRequest{Limit: limit, Offset: offset}
+		res, err := h.tagService.List(r.Context(), req)
+		if err != nil {
+			web.MarshalError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+// @Router /tags/{tag} [put]
+// @Tags tags
+// @Summary Rename tag
+// @Description Renames the tag on every post, when a tag with the new name exists the tag is merged into it. Admin only
+// @Accept json
+// @Produce json
+// @Param tag path string true "tag name"
+// @Param payload body model.TagUpdateRequest true "body request"
+// @Success 200 {object} model.TagResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Security ApiKeyAuth
+func (h *tagHandler) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := model.TagUpdateRequest{Tag: web.GetUrlPathString(r, "tag")}
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+			return
+		}
+
+		err = validation.Struct(req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := h.tagService.Update(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrForbidden:
+				web.MarshalError(w, http.StatusForbidden, err)
+				return
+			case constant.ErrTagNotFound:
+				web.MarshalError(w, http.StatusNotFound, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
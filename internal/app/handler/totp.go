@@ -0,0 +1,259 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/app/service"
+	"github.com/osamaesmail/go-post-api/internal/constant"
+	"github.com/osamaesmail/go-post-api/internal/validation"
+	"github.com/osamaesmail/go-post-api/internal/web"
+)
+
+type TOTPHandler interface {
+	Enroll() http.HandlerFunc
+	Confirm() http.HandlerFunc
+	Disable() http.HandlerFunc
+	RegenerateRecoveryCodes() http.HandlerFunc
+}
+
+func NewTOTPHandler(totpService service.TOTPService) TOTPHandler {
+	return &totpHandler{totpService}
+}
+
+type totpHandler struct {
+	totpService service.TOTPService
+}
+
+// @Router /accounts/{account_id}/totp [post]
+// @Tags two-factor
+// @Summary Enroll two-factor authentication
+// @Description Returns a new secret and its otpauth URI, two-factor authentication is enabled once a code is confirmed
+// @Accept json
+// @Produce json
+// @Param account_id path int true "account id" Format(int64)
+// @Param payload body model.TOTPEnrollRequest true "body request"
+// @Success 200 {object} model.TOTPEnrollResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Security ApiKeyAuth
+func (h *totpHandler) Enroll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accountID, err := web.GetUrlPathInt64(r, "account_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.TOTPEnrollRequest{AccountID: accountID}
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+			return
+		}
+
+		err = validation.Struct(req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := h.totpService.Enroll(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrUnauthorized, constant.ErrWrongPassword:
+				web.MarshalError(w, http.StatusUnauthorized, err)
+				return
+			case constant.ErrAccountNotFound:
+				web.MarshalError(w, http.StatusNotFound, err)
+				return
+			case constant.ErrTOTPEnabled:
+				web.MarshalError(w, http.StatusConflict, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+// @Router /accounts/{account_id}/totp/confirm [post]
+// @Tags two-factor
+// @Summary Confirm two-factor authentication
+// @Description Enables two-factor authentication with a code of the enrolled secret and returns the recovery codes, every session has to log in again
+// @Accept json
+// @Produce json
+// @Param account_id path int true "account id" Format(int64)
+// @Param payload body model.TOTPConfirmRequest true "body request"
+// @Success 200 {object} model.TOTPRecoveryCodesResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Security ApiKeyAuth
+func (h *totpHandler) Confirm() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accountID, err := web.GetUrlPathInt64(r, "account_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.TOTPConfirmRequest{AccountID: accountID}
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+			return
+		}
+
+		err = validation.Struct(req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := h.totpService.Confirm(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrUnauthorized, constant.ErrInvalidTOTPCode:
+				web.MarshalError(w, http.StatusUnauthorized, err)
+				return
+			case constant.ErrAccountNotFound:
+				web.MarshalError(w, http.StatusNotFound, err)
+				return
+			case constant.ErrTOTPEnabled, constant.ErrTOTPNotEnrolled:
+				web.MarshalError(w, http.StatusConflict, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+// @Router /accounts/{account_id}/totp [delete]
+// @Tags two-factor
+// @Summary Disable two-factor authentication
+// @Description Requires the password and a TOTP or recovery code
+// @Accept json
+// @Produce json
+// @Param account_id path int true "account id" Format(int64)
+// @Param payload body model.TOTPDisableRequest true "body request"
+// @Success 204
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Security ApiKeyAuth
+func (h *totpHandler) Disable() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accountID, err := web.GetUrlPathInt64(r, "account_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.TOTPDisableRequest{AccountID: accountID}
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+			return
+		}
+
+		err = validation.Struct(req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		err = h.totpService.Disable(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrUnauthorized, constant.ErrWrongPassword, constant.ErrInvalidTOTPCode:
+				web.MarshalError(w, http.StatusUnauthorized, err)
+				return
+			case constant.ErrAccountNotFound:
+				web.MarshalError(w, http.StatusNotFound, err)
+				return
+			case constant.ErrTOTPNotEnabled:
+				web.MarshalError(w, http.StatusConflict, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// @Router /accounts/{account_id}/totp/recovery-codes [post]
+// @Tags two-factor
+// @Summary Regenerate recovery codes
+// @Description Requires the password and a TOTP or recovery code, the previous recovery codes stop working
+// @Accept json
+// @Produce json
+// @Param account_id path int true "account id" Format(int64)
+// @Param payload body model.TOTPRecoveryCodesRequest true "body request"
+// @Success 200 {object} model.TOTPRecoveryCodesResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Security ApiKeyAuth
+func (h *totpHandler) RegenerateRecoveryCodes() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accountID, err := web.GetUrlPathInt64(r, "account_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.TOTPRecoveryCodesRequest{AccountID: accountID}
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+			return
+		}
+
+		err = validation.Struct(req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := h.totpService.RegenerateRecoveryCodes(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrUnauthorized, constant.ErrWrongPassword, constant.ErrInvalidTOTPCode:
+				web.MarshalError(w, http.StatusUnauthorized, err)
+				return
+			case constant.ErrAccountNotFound:
+				web.MarshalError(w, http.StatusNotFound, err)
+				return
+			case constant.ErrTOTPNotEnabled:
+				web.MarshalError(w, http.StatusConflict, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
@@ -0,0 +1,95 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	ScopeAccountsRead  = "accounts:read"
+	ScopeAccountsWrite = "accounts:write"
+	ScopePostsRead     = "posts:read"
+	ScopePostsWrite    = "posts:write"
+	ScopeCommentsRead  = "comments:read"
+	ScopeCommentsWrite = "comments:write"
+)
+
+// AccessToken is a personal access token, only the hash of the token itself
+// is ever stored.
+type AccessToken struct {
+	ID        int64
+	Name      string
+	TokenHash string
+	Scopes    []string
+	ExpiresAt sql.NullTime
+	RevokedAt sql.NullTime
+	CreatedAt time.Time
+
+	AccountID int64
+	Account   Account
+}
+
+func (t *AccessToken) IsActive(now time.Time) bool {
+	if t.RevokedAt.Valid {
+		return false
+	}
+	return !t.ExpiresAt.Valid || now.Before(t.ExpiresAt.Time)
+}
+
+type AccessTokenCreateRequest struct {
+	AccountID int64      `json:"-"`
+	Name      string     `json:"name" validate:"required,max=255"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,oneof=accounts:read accounts:write posts:read posts:write comments:read comments:write"`
+	ExpiresAt *time.Time `json:"expires_at" validate:"omitempty,gt"`
+}
+
+type AccessTokenListRequest struct {
+	AccountID int64
+}
+
+type AccessTokenDeleteRequest struct {
+	ID        int64
+	AccountID int64
+}
+
+type AccessTokenResponse struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+
+	AccountID int64 `json:"account_id"`
+}
+
+// AccessTokenCreateResponse is the only response that carries the token.
+type AccessTokenCreateResponse struct {
+	AccessTokenResponse
+	Token string `json:"token"`
+}
+
+func NewAccessTokenResponse(payload *AccessToken) *AccessTokenResponse {
+	res := &AccessTokenResponse{
+		ID:        payload.ID,
+		Name:      payload.Name,
+		Scopes:    payload.Scopes,
+		CreatedAt: payload.CreatedAt,
+		AccountID: payload.AccountID,
+	}
+	if payload.ExpiresAt.Valid {
+		res.ExpiresAt = &payload.ExpiresAt.Time
+	}
+	if payload.RevokedAt.Valid {
+		res.RevokedAt = &payload.RevokedAt.Time
+	}
+	return res
+}
+
+func NewAccessTokenListResponse(payloads []*AccessToken) []*AccessTokenResponse {
+	res := make([]*AccessTokenResponse, len(payloads))
+	for i, payload := range payloads {
+		res[i] = NewAccessTokenResponse(payload)
+	}
+	return res
+}
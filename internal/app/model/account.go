@@ -2,28 +2,62 @@ package model
 
 import (
 	"database/sql"
+	"io"
 	"time"
 
 	jwt "github.com/dgrijalva/jwt-go"
 )
 
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// what happens to the content of an account once its deletion grace period is
+// over, the account itself is gone either way
+const (
+	AccountPurgeAnonymize = "anonymize"
+	AccountPurgeDelete    = "delete"
+)
+
 type Account struct {
-	ID        int64
-	Name      string
-	Email     string
-	Password  string
-	CreatedAt time.Time
-	UpdatedAt sql.NullTime
+	ID              int64
+	Name            string
+	Handle          sql.NullString
+	Bio             string
+	Website         string
+	AvatarURL       string
+	Email           string
+	Password        string
+	Role            string
+	EmailVerifiedAt sql.NullTime
+	PendingEmail    sql.NullString
+	TOTPSecret      sql.NullString
+	TOTPEnabledAt   sql.NullTime
+	CreatedAt       time.Time
+	UpdatedAt       sql.NullTime
+	DeletedAt       sql.NullTime
 }
 
 func (a *Account) GenerateClaims() jwt.MapClaims {
-	return jwt.MapClaims{"id": a.ID}
+	return jwt.MapClaims{"id": a.ID, "role": a.Role}
+}
+
+// AccountHandle is a handle the account used before, it keeps redirecting to
+// the account and nobody else can take it.
+type AccountHandle struct {
+	ID        int64
+	Handle    string
+	CreatedAt time.Time
+	AccountID int64
 }
 
 type AccountCreateRequest struct {
 	Name     string `json:"name" validate:"required"`
+	Handle   string `json:"handle" validate:"omitempty,handle"`
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,gte=8"`
+	Password string `json:"password" validate:"required"`
 }
 
 type AccountListRequest struct {
@@ -36,36 +70,116 @@ type AccountGetRequest struct {
 	ID int64
 }
 
+type AccountHandleGetRequest struct {
+	Handle string `validate:"required"`
+}
+
 type AccountUpdateRequest struct {
-	ID    int64  `json:"-"`
-	Name  string `json:"name" validate:"required"`
-	Email string `json:"email" validate:"required,email"`
+	ID        int64  `json:"-"`
+	Name      string `json:"name" validate:"required"`
+	Email     string `json:"email" validate:"required,email"`
+	Bio       string `json:"bio" validate:"max=500"`
+	Website   string `json:"website" validate:"omitempty,weburl,max=255"`
+	AvatarURL string `json:"avatar_url" validate:"omitempty,weburl,max=512"`
+}
+
+type AccountHandleUpdateRequest struct {
+	ID     int64  `json:"-"`
+	Handle string `json:"handle" validate:"required,handle"`
+}
+
+// AccountAvatarUpdateRequest carries the upload as is, what it holds is only
+// known once its bytes are sniffed.
+type AccountAvatarUpdateRequest struct {
+	ID   int64
+	File io.ReadSeeker
+}
+
+// AccountAvatarGetRequest asks for the largest variant when Size is 0.
+type AccountAvatarGetRequest struct {
+	ID   int64
+	Size int
 }
 
 type AccountPasswordUpdateRequest struct {
 	ID          int64  `json:"-"`
-	OldPassword string `json:"old_password" validate:"required,gte=8"`
-	NewPassword string `json:"new_password" validate:"required,gte=8"`
+	OldPassword string `json:"old_password" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
+}
+
+type AccountPasswordForgotRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type AccountPasswordResetRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type AccountVerifyRequest struct {
+	Token string `validate:"required"`
+}
+
+type AccountVerificationResendRequest struct {
+	ID int64
+}
+
+// EmailVerificationToken is what a verification token stands for, the email
+// has to still be the current or the pending one of the account on redemption.
+type EmailVerificationToken struct {
+	AccountID int64  `json:"account_id"`
+	Email     string `json:"email"`
+}
+
+// PasswordResetToken is what a reset token stands for until it is redeemed.
+type PasswordResetToken struct {
+	AccountID int64 `json:"account_id"`
+}
+
+type AccountRoleUpdateRequest struct {
+	ID   int64  `json:"-"`
+	Role string `json:"role" validate:"required,oneof=user moderator admin"`
 }
 
 type AccountDeleteRequest struct {
 	ID int64
 }
 
+// AccountResponse leaves out the private fields when AccountPrivateResponse is
+// nil, see NewAccountPublicResponse.
 type AccountResponse struct {
-	ID        int64      `json:"id"`
-	Name      string     `json:"name"`
-	Email     string     `json:"email"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt *time.Time `json:"updated_at"`
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Handle    *string   `json:"handle"`
+	Bio       string    `json:"bio"`
+	Website   string    `json:"website"`
+	AvatarURL string    `json:"avatar_url"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	*AccountPrivateResponse
+}
+
+// AccountPrivateResponse holds the fields only the account itself and admins
+// may see.
+type AccountPrivateResponse struct {
+	Email           string     `json:"email"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	PendingEmail    *string    `json:"pending_email"`
+	TOTPEnabled     bool       `json:"totp_enabled"`
+	UpdatedAt       *time.Time `json:"updated_at"`
 }
 
 func NewAccountResponse(payload *Account) *AccountResponse {
-	res := &AccountResponse{
-		ID:        payload.ID,
-		Name:      payload.Name,
-		Email:     payload.Email,
-		CreatedAt: payload.CreatedAt,
+	res := NewAccountPublicResponse(payload)
+	res.AccountPrivateResponse = &AccountPrivateResponse{
+		Email:       payload.Email,
+		TOTPEnabled: payload.TOTPEnabledAt.Valid,
+	}
+	if payload.EmailVerifiedAt.Valid {
+		res.EmailVerifiedAt = &payload.EmailVerifiedAt.Time
+	}
+	if payload.PendingEmail.Valid {
+		res.PendingEmail = &payload.PendingEmail.String
 	}
 	if payload.UpdatedAt.Valid {
 		res.UpdatedAt = &payload.UpdatedAt.Time
@@ -73,10 +187,18 @@ func NewAccountResponse(payload *Account) *AccountResponse {
 	return res
 }
 
-func NewAccountListResponse(payloads []*Account) []*AccountResponse {
-	res := make([]*AccountResponse, len(payloads))
-	for i, payload := range payloads {
-		res[i] = NewAccountResponse(payload)
+func NewAccountPublicResponse(payload *Account) *AccountResponse {
+	res := &AccountResponse{
+		ID:        payload.ID,
+		Name:      payload.Name,
+		Bio:       payload.Bio,
+		Website:   payload.Website,
+		AvatarURL: payload.AvatarURL,
+		Role:      payload.Role,
+		CreatedAt: payload.CreatedAt,
+	}
+	if payload.Handle.Valid {
+		res.Handle = &payload.Handle.String
 	}
 	return res
 }
@@ -0,0 +1,173 @@
+package model
+
+import (
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+const (
+	AuditActionAccountCreate         = "account.create"
+	AuditActionAccountUpdate         = "account.update"
+	AuditActionAccountHandleUpdate   = "account.handle_update"
+	AuditActionAccountAvatarUpdate   = "account.avatar_update"
+	AuditActionAccountEmailVerify    = "account.email_verify"
+	AuditActionAccountIdentityLink   = "account.identity_link"
+	AuditActionAccountPasswordUpdate = "account.password_update"
+	AuditActionAccountRoleUpdate     = "account.role_update"
+	AuditActionAccountPasswordReset  = "account.password_reset"
+	AuditActionAccountTOTPEnable     = "account.totp_enable"
+	AuditActionAccountTOTPDisable    = "account.totp_disable"
+	AuditActionAccountRecoveryCodes  = "account.recovery_codes_regenerate"
+	AuditActionAccountLockout        = "account.lockout"
+	AuditActionAccountDelete         = "account.delete"
+	AuditActionAccountRestore        = "account.restore"
+	AuditActionAccountPurge          = "account.purge"
+	AuditActionAccountExport         = "account.export"
+	AuditActionAuthLogin             = "auth.login"
+	AuditActionAuthLogout            = "auth.logout"
+	AuditActionAuthImpersonate       = "auth.impersonate"
+	AuditActionImpersonatedRequest   = "auth.impersonated_request"
+	AuditActionSessionRevoke         = "session.revoke"
+	AuditActionAccessTokenCreate     = "access_token.create"
+	AuditActionAccessTokenRevoke     = "access_token.revoke"
+	AuditActionPostUpdate            = "post.update"
+	AuditActionPostDelete            = "post.delete"
+	AuditActionCommentUpdate         = "comment.update"
+	AuditActionCommentDelete         = "comment.delete"
+	AuditActionTagRename             = "tag.rename"
+	AuditActionTagMerge              = "tag.merge"
+)
+
+const (
+	AuditTargetAccount     = "account"
+	AuditTargetSession     = "session"
+	AuditTargetAccessToken = "access_token"
+	AuditTargetPost        = "post"
+	AuditTargetComment     = "comment"
+	AuditTargetTag         = "tag"
+)
+
+// AuditEvent is an entry of the append only audit log, Diff holds the fields
+// that changed as {"field": {"before": ..., "after": ...}}.
+type AuditEvent struct {
+	ID         int64
+	ActorID    sql.NullInt64
+	Action     string
+	TargetType string
+	TargetID   int64
+	IP         sql.NullString
+	RequestID  sql.NullString
+	Diff       json.RawMessage
+	CreatedAt  time.Time
+}
+
+type AuditDiffField struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// NewAuditDiff compares two JSON objects field by field, either may be nil
+// for a target that was created or deleted. It returns nil when nothing
+// changed.
+func NewAuditDiff(before, after json.RawMessage) (json.RawMessage, error) {
+	beforeFields := map[string]interface{}{}
+	if before != nil {
+		err := json.Unmarshal(before, &beforeFields)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	afterFields := map[string]interface{}{}
+	if after != nil {
+		err := json.Unmarshal(after, &afterFields)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	diff := map[string]AuditDiffField{}
+	for field, value := range beforeFields {
+		if !reflect.DeepEqual(value, afterFields[field]) {
+			diff[field] = AuditDiffField{Before: value, After: afterFields[field]}
+		}
+	}
+	for field, value := range afterFields {
+		if _, exists := beforeFields[field]; !exists {
+			diff[field] = AuditDiffField{After: value}
+		}
+	}
+
+	if len(diff) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(diff)
+}
+
+type AuditListRequest struct {
+	ActorID    int64
+	Action     string
+	TargetType string
+	TargetID   int64
+	Cursor     string
+	Limit      int
+}
+
+// AuditFilter is what AuditListRequest asks the repository for, zero values
+// do not filter. Events come newest first, BeforeID continues after an event.
+type AuditFilter struct {
+	ActorID    int64
+	Action     string
+	TargetType string
+	TargetID   int64
+	BeforeID   int64
+	Limit      int
+}
+
+type AuditEventResponse struct {
+	ID         int64           `json:"id"`
+	ActorID    *int64          `json:"actor_id"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   int64           `json:"target_id"`
+	IP         *string         `json:"ip"`
+	RequestID  *string         `json:"request_id"`
+	Diff       json.RawMessage `json:"diff" swaggertype:"object"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+type AuditListResponse struct {
+	Events     []*AuditEventResponse `json:"events"`
+	NextCursor string                `json:"next_cursor,omitempty"`
+}
+
+func NewAuditEventResponse(payload *AuditEvent) *AuditEventResponse {
+	res := &AuditEventResponse{
+		ID:         payload.ID,
+		Action:     payload.Action,
+		TargetType: payload.TargetType,
+		TargetID:   payload.TargetID,
+		Diff:       payload.Diff,
+		CreatedAt:  payload.CreatedAt,
+	}
+	if payload.ActorID.Valid {
+		res.ActorID = &payload.ActorID.Int64
+	}
+	if payload.IP.Valid {
+		res.IP = &payload.IP.String
+	}
+	if payload.RequestID.Valid {
+		res.RequestID = &payload.RequestID.String
+	}
+	return res
+}
+
+func NewAuditListResponse(payloads []*AuditEvent, nextCursor string) *AuditListResponse {
+	res := &AuditListResponse{Events: make([]*AuditEventResponse, len(payloads)), NextCursor: nextCursor}
+	for i, payload := range payloads {
+		res.Events[i] = NewAuditEventResponse(payload)
+	}
+	return res
+}
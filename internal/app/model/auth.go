@@ -1,10 +1,87 @@
 package model
 
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"time"
+)
+
+// purposes of the one time tokens kept by the token repository
+const (
+	OneTimeTokenPasswordReset     = "password_reset"
+	OneTimeTokenEmailVerification = "email_verification"
+	OneTimeTokenLoginChallenge    = "login_challenge"
+	OneTimeTokenOauthState        = "oauth_state"
+)
+
 type AuthRequest struct {
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,gte=8"`
+	Email     string `json:"email" validate:"required,email"`
+	Password  string `json:"password" validate:"required,gte=8"`
+	IP        string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+type AuthTOTPRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+	IP             string `json:"-"`
+	UserAgent      string `json:"-"`
+}
+
+type AuthRefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type AuthLogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
 }
 
+// AuthResponse carries only a ChallengeToken when the account has two factor
+// authentication enabled, see AuthTOTPRequest.
 type AuthResponse struct {
-	Token string `json:"token"`
+	Token          string `json:"token,omitempty"`
+	RefreshToken   string `json:"refresh_token,omitempty"`
+	ChallengeToken string `json:"challenge_token,omitempty"`
+}
+
+// RefreshToken is the server side record of an opaque refresh token. Every
+// token minted by rotating another one shares its FamilyID, so reusing an
+// already rotated token can revoke the whole chain.
+type RefreshToken struct {
+	AccountID int64     `json:"account_id"`
+	FamilyID  string    `json:"family_id"`
+	SessionID int64     `json:"session_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSResponse struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+func NewJSONWebKey(kid, alg string, publicKey interface{}) JSONWebKey {
+	res := JSONWebKey{Use: "sig", Alg: alg, Kid: kid}
+	switch publicKey := publicKey.(type) {
+	case *rsa.PublicKey:
+		res.Kty = "RSA"
+		res.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+		res.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+	case ed25519.PublicKey:
+		res.Kty = "OKP"
+		res.Crv = "Ed25519"
+		res.X = base64.RawURLEncoding.EncodeToString(publicKey)
+	}
+	return res
 }
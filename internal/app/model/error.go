@@ -1,5 +1,6 @@
 package model
 
 type ErrorResponse struct {
-	Message string `json:"message"`
+	Message string              `json:"message"`
+	Fields  map[string][]string `json:"fields,omitempty"`
 }
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	ExportJobPending = "pending"
+	ExportJobRunning = "running"
+	ExportJobDone    = "done"
+	ExportJobFailed  = "failed"
+	ExportJobExpired = "expired"
+)
+
+// ExportJob builds the archive of everything held about an account, the
+// archive is kept until the job expires.
+type ExportJob struct {
+	ID         int64
+	Status     string
+	CreatedAt  time.Time
+	FinishedAt sql.NullTime
+	AccountID  int64
+}
+
+type ExportCreateRequest struct {
+	AccountID int64
+}
+
+type ExportGetRequest struct {
+	ID        int64
+	AccountID int64
+}
+
+// ExportDownloadRequest carries the signed link instead of claims.
+type ExportDownloadRequest struct {
+	ID        int64
+	AccountID int64
+	Expires   int64
+	Signature string
+}
+
+type ExportJobResponse struct {
+	ID          int64      `json:"id"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+	DownloadURL string     `json:"download_url,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+func NewExportJobResponse(payload *ExportJob) *ExportJobResponse {
+	res := &ExportJobResponse{
+		ID:        payload.ID,
+		Status:    payload.Status,
+		CreatedAt: payload.CreatedAt,
+	}
+	if payload.FinishedAt.Valid {
+		res.FinishedAt = &payload.FinishedAt.Time
+	}
+	return res
+}
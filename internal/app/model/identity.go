@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// AccountIdentity links an account to the subject of an OpenID Connect
+// provider, an account may sign in with several.
+type AccountIdentity struct {
+	ID        int64
+	Provider  string
+	Subject   string
+	Email     string
+	CreatedAt time.Time
+	AccountID int64
+}
+
+// OauthState is what the state parameter of a login stands for until the
+// provider redirects back.
+type OauthState struct {
+	Provider     string `json:"provider"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+}
+
+type OauthStartRequest struct {
+	Provider string
+}
+
+type OauthCallbackRequest struct {
+	Provider  string
+	Code      string
+	State     string
+	Error     string
+	IP        string
+	UserAgent string
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"strconv"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// ImpersonationClaims are the claims of Account, with the admin acting on its
+// behalf named in the act claim of RFC 8693.
+type ImpersonationClaims struct {
+	Account *Account
+	ActorID int64
+}
+
+func (c *ImpersonationClaims) GenerateClaims() jwt.MapClaims {
+	claims := c.Account.GenerateClaims()
+	claims["act"] = map[string]interface{}{"sub": strconv.FormatInt(c.ActorID, 10)}
+	return claims
+}
+
+type ImpersonateRequest struct {
+	AccountID int64
+}
+
+// ImpersonateResponse has no refresh token, impersonating ends when the token
+// expires.
+type ImpersonateResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -5,59 +5,149 @@ import (
 	"time"
 )
 
+const (
+	PostStatusDraft     = "draft"
+	PostStatusScheduled = "scheduled"
+	PostStatusPublished = "published"
+	PostStatusArchived  = "archived"
+)
+
+const (
+	PostBodyFormatPlain    = "plain"
+	PostBodyFormatMarkdown = "markdown"
+)
+
+// Post is only public while it is published. PublishAt is when a scheduled
+// post gets published, or when a published one was.
 type Post struct {
-	ID        int64
-	Title     string
-	Body      string
-	CreatedAt time.Time
-	UpdatedAt sql.NullTime
+	ID         int64
+	Title      string
+	Slug       string
+	Body       string
+	BodyFormat string
+	Status     string
+	PublishAt  sql.NullTime
+	Tags       []string
+	CreatedAt  time.Time
+	UpdatedAt  sql.NullTime
 
 	AccountID int64
 	Account   Account
 }
 
+// PostCreateRequest publishes the post right away unless Status says
+// otherwise, a scheduled post needs a PublishAt in the future. The body is
+// plain text unless BodyFormat says otherwise.
 type PostCreateRequest struct {
-	Title string `json:"title" validate:"required"`
-	Body  string `json:"body" validate:"required"`
+	Title      string     `json:"title" validate:"required"`
+	Body       string     `json:"body" validate:"required"`
+	BodyFormat string     `json:"body_format" validate:"omitempty,oneof=plain markdown"`
+	Status     string     `json:"status" validate:"omitempty,oneof=draft scheduled published archived"`
+	PublishAt  *time.Time `json:"publish_at"`
+	Tags       []string   `json:"tags" validate:"omitempty,max=10,dive,tag"`
 }
 
+// PostListRequest lists published posts, any other Status lists the posts of
+// the caller in that status. Posts need all Tags unless TagMode is any.
 type PostListRequest struct {
-	Limit  int
-	Offset int
-	Title  string
+	Limit   int
+	Offset  int
+	Title   string
+	Status  string
+	Tags    []string
+	TagMode string
+}
+
+// PostFilter is what PostListRequest asks the repository for, zero values do
+// not filter.
+type PostFilter struct {
+	Title     string
+	Status    string
+	AccountID int64
+	Tags      []string
+	AnyTag    bool
+	Limit     int
+	Offset    int
+}
+
+// PostSlug is a slug the post had before its title changed, it keeps
+// redirecting to the post and no other post can take it.
+type PostSlug struct {
+	ID        int64
+	Slug      string
+	CreatedAt time.Time
+	PostID    int64
 }
 
 type PostGetRequest struct {
 	ID int64
 }
 
+type PostSlugGetRequest struct {
+	Slug string `validate:"required"`
+}
+
+// PostUpdateRequest keeps the status and body format of the post when Status
+// and BodyFormat are empty and its tags when Tags is left out.
 type PostUpdateRequest struct {
-	ID    int64  `json:"-"`
-	Title string `json:"title" validate:"required"`
-	Body  string `json:"body" validate:"required"`
+	ID         int64      `json:"-"`
+	Title      string     `json:"title" validate:"required"`
+	Body       string     `json:"body" validate:"required"`
+	BodyFormat string     `json:"body_format" validate:"omitempty,oneof=plain markdown"`
+	Status     string     `json:"status" validate:"omitempty,oneof=draft scheduled published archived"`
+	PublishAt  *time.Time `json:"publish_at"`
+	Tags       []string   `json:"tags" validate:"omitempty,max=10,dive,tag"`
 }
 
 type PostDeleteRequest struct {
 	ID int64
 }
 
+// PostRender is the body of a post rendered to sanitized HTML, with the plain
+// text excerpt and the minutes it takes to read.
+type PostRender struct {
+	BodyHTML    string
+	Excerpt     string
+	ReadingTime int
+}
+
+// PostResponse leaves BodyHTML, Excerpt and ReadingTime to SetRender, the body
+// is rendered apart from the post.
 type PostResponse struct {
-	ID        int64      `json:"id"`
-	Title     string     `json:"title"`
-	Body      string     `json:"body"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt *time.Time `json:"updated_at"`
+	ID          int64      `json:"id"`
+	Title       string     `json:"title"`
+	Slug        string     `json:"slug"`
+	Body        string     `json:"body"`
+	BodyFormat  string     `json:"body_format"`
+	BodyHTML    string     `json:"body_html"`
+	Excerpt     string     `json:"excerpt"`
+	ReadingTime int        `json:"reading_time"`
+	Status      string     `json:"status"`
+	PublishAt   *time.Time `json:"publish_at"`
+	Tags        []string   `json:"tags"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at"`
 
-	AccountID int64            `json:"account_id"`
+	AccountID int64 `json:"account_id"`
 }
 
 func NewPostResponse(payload *Post) *PostResponse {
 	res := &PostResponse{
-		ID:        payload.ID,
-		Title:     payload.Title,
-		Body:      payload.Body,
-		CreatedAt: payload.CreatedAt,
-		AccountID: payload.AccountID,
+		ID:         payload.ID,
+		Title:      payload.Title,
+		Slug:       payload.Slug,
+		Body:       payload.Body,
+		BodyFormat: payload.BodyFormat,
+		Status:     payload.Status,
+		Tags:       payload.Tags,
+		CreatedAt:  payload.CreatedAt,
+		AccountID:  payload.AccountID,
+	}
+	if payload.PublishAt.Valid {
+		res.PublishAt = &payload.PublishAt.Time
+	}
+	if res.Tags == nil {
+		res.Tags = []string{}
 	}
 	if payload.UpdatedAt.Valid {
 		res.UpdatedAt = &payload.UpdatedAt.Time
@@ -65,10 +155,8 @@ func NewPostResponse(payload *Post) *PostResponse {
 	return res
 }
 
-func NewPostListResponse(payloads []*Post) []*PostResponse {
-	res := make([]*PostResponse, len(payloads))
-	for i, payload := range payloads {
-		res[i] = NewPostResponse(payload)
-	}
-	return res
+func (res *PostResponse) SetRender(render *PostRender) {
+	res.BodyHTML = render.BodyHTML
+	res.Excerpt = render.Excerpt
+	res.ReadingTime = render.ReadingTime
 }
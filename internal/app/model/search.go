@@ -0,0 +1,91 @@
+package model
+
+import "time"
+
+const (
+	SearchTypePost    = "post"
+	SearchTypeComment = "comment"
+)
+
+// SearchDocument is what a search index holds of a post or a comment, Status
+// is the status of the post either way.
+type SearchDocument struct {
+	Type      string
+	ID        int64
+	PostID    int64
+	Title     string
+	Body      string
+	Status    string
+	AccountID int64
+	CreatedAt time.Time
+}
+
+func NewPostSearchDocument(post *Post) *SearchDocument {
+	return &SearchDocument{
+		Type:      SearchTypePost,
+		ID:        post.ID,
+		PostID:    post.ID,
+		Title:     post.Title,
+		Body:      post.Body,
+		Status:    post.Status,
+		AccountID: post.AccountID,
+		CreatedAt: post.CreatedAt,
+	}
+}
+
+func NewCommentSearchDocument(comment *Comment, post *Post) *SearchDocument {
+	return &SearchDocument{
+		Type:      SearchTypeComment,
+		ID:        comment.ID,
+		PostID:    comment.PostID,
+		Title:     post.Title,
+		Body:      comment.Body,
+		Status:    post.Status,
+		AccountID: comment.AccountID,
+		CreatedAt: comment.CreatedAt,
+	}
+}
+
+// SearchRequest searches posts and comments, zero values do not filter and
+// To is exclusive.
+type SearchRequest struct {
+	Query     string
+	Type      string
+	AccountID int64
+	From      time.Time
+	To        time.Time
+	Limit     int
+	Offset    int
+}
+
+// SearchResult is a matching document, the higher its Score the better it
+// matches.
+type SearchResult struct {
+	SearchDocument
+	Score float64
+}
+
+type SearchResultResponse struct {
+	Type      string    `json:"type"`
+	ID        int64     `json:"id"`
+	PostID    int64     `json:"post_id"`
+	Title     string    `json:"title"`
+	Snippet   string    `json:"snippet"`
+	Score     float64   `json:"score"`
+	AccountID int64     `json:"account_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewSearchResultResponse leaves the snippet to the caller, it depends on the
+// query.
+func NewSearchResultResponse(payload *SearchResult) *SearchResultResponse {
+	return &SearchResultResponse{
+		Type:      payload.Type,
+		ID:        payload.ID,
+		PostID:    payload.PostID,
+		Title:     payload.Title,
+		Score:     payload.Score,
+		AccountID: payload.AccountID,
+		CreatedAt: payload.CreatedAt,
+	}
+}
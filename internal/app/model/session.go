@@ -0,0 +1,77 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// Session is a login on one device. Every refresh token rotated from the
+// login shares the session's FamilyID, revoking the session revokes them.
+type Session struct {
+	ID         int64
+	FamilyID   string
+	UserAgent  string
+	IP         string
+	LastSeenAt sql.NullTime
+	RevokedAt  sql.NullTime
+	CreatedAt  time.Time
+
+	AccountID int64
+}
+
+// SessionClaims generates the claims of an access token issued for a session.
+type SessionClaims struct {
+	Account   *Account
+	SessionID int64
+}
+
+func (c *SessionClaims) GenerateClaims() jwt.MapClaims {
+	claims := c.Account.GenerateClaims()
+	claims["sid"] = c.SessionID
+	return claims
+}
+
+type SessionListRequest struct {
+	AccountID int64
+}
+
+type SessionDeleteRequest struct {
+	ID        int64
+	AccountID int64
+}
+
+type SessionResponse struct {
+	ID         int64      `json:"id"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	LastSeenAt *time.Time `json:"last_seen_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Current    bool       `json:"current"`
+
+	AccountID int64 `json:"account_id"`
+}
+
+func NewSessionResponse(payload *Session, currentID int64) *SessionResponse {
+	res := &SessionResponse{
+		ID:        payload.ID,
+		UserAgent: payload.UserAgent,
+		IP:        payload.IP,
+		CreatedAt: payload.CreatedAt,
+		Current:   payload.ID == currentID,
+		AccountID: payload.AccountID,
+	}
+	if payload.LastSeenAt.Valid {
+		res.LastSeenAt = &payload.LastSeenAt.Time
+	}
+	return res
+}
+
+func NewSessionListResponse(payloads []*Session, currentID int64) []*SessionResponse {
+	res := make([]*SessionResponse, len(payloads))
+	for i, payload := range payloads {
+		res[i] = NewSessionResponse(payload, currentID)
+	}
+	return res
+}
@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+// Tag is shared by every post using it, PostCount only counts the published
+// ones.
+type Tag struct {
+	ID        int64
+	Name      string
+	CreatedAt time.Time
+	PostCount int64
+}
+
+type TagListRequest struct {
+	Limit  int
+	Offset int
+}
+
+// TagUpdateRequest renames the tag, it is merged into the tag already named
+// Name when there is one.
+type TagUpdateRequest struct {
+	Tag  string `json:"-"`
+	Name string `json:"name" validate:"required,tag"`
+}
+
+type TagResponse struct {
+	ID        int64  `json:"id"`
+	Name      string `json:"name"`
+	PostCount int64  `json:"post_count"`
+}
+
+func NewTagResponse(payload *Tag) *TagResponse {
+	return &TagResponse{
+		ID:        payload.ID,
+		Name:      payload.Name,
+		PostCount: payload.PostCount,
+	}
+}
+
+func NewTagListResponse(payloads []*Tag) []*TagResponse {
+	res := make([]*TagResponse, len(payloads))
+	for i, payload := range payloads {
+		res[i] = NewTagResponse(payload)
+	}
+	return res
+}
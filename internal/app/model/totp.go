@@ -0,0 +1,42 @@
+package model
+
+// RecoveryCodeCount is how many recovery codes an account gets at a time.
+const RecoveryCodeCount = 10
+
+// LoginChallenge is what a challenge token stands for, it proves the password
+// step of a login and is exchanged with a TOTP or recovery code.
+type LoginChallenge struct {
+	AccountID int64 `json:"account_id"`
+}
+
+type TOTPEnrollRequest struct {
+	AccountID int64  `json:"-"`
+	Password  string `json:"password" validate:"required"`
+}
+
+type TOTPConfirmRequest struct {
+	AccountID int64  `json:"-"`
+	Code      string `json:"code" validate:"required"`
+}
+
+type TOTPDisableRequest struct {
+	AccountID int64  `json:"-"`
+	Password  string `json:"password" validate:"required"`
+	Code      string `json:"code" validate:"required"`
+}
+
+type TOTPRecoveryCodesRequest struct {
+	AccountID int64  `json:"-"`
+	Password  string `json:"password" validate:"required"`
+	Code      string `json:"code" validate:"required"`
+}
+
+type TOTPEnrollResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// TOTPRecoveryCodesResponse is the only response that carries the codes.
+type TOTPRecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
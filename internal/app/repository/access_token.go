@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/db/mysql"
+)
+
+type AccessTokenRepository interface {
+	Create(ctx context.Context, accessToken *model.AccessToken) error
+	List(ctx context.Context, accountID int64) ([]*model.AccessToken, error)
+	Get(ctx context.Context, id int64) (*model.AccessToken, error)
+	GetByHash(ctx context.Context, tokenHash string) (*model.AccessToken, error)
+	Revoke(ctx context.Context, id int64, revokedAt time.Time) error
+	DeleteByAccountID(ctx context.Context, accountID int64) error
+}
+
+// NewAccessTokenRepository does not cache, a revoked token has to stop working
+// on every instance right away.
+func NewAccessTokenRepository(mysqlClient mysql.Client) AccessTokenRepository {
+	return &accessTokenRepository{mysqlClient}
+}
+
+type accessTokenRepository struct {
+	mysqlClient mysql.Client
+}
+
+func (r *accessTokenRepository) Create(ctx context.Context, accessToken *model.AccessToken) error {
+	res, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+	INSERT INTO
+		access_token (name, token_hash, scopes, expires_at, account_id, created_at)
+	VALUES
+		(?, ?, ?, ?, ?, ?)
+	`, accessToken.Name, accessToken.TokenHash, strings.Join(accessToken.Scopes, " "), accessToken.ExpiresAt, accessToken.AccountID, accessToken.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	accessToken.ID, err = res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	temp, err := r.Get(ctx, accessToken.ID)
+	*accessToken = *temp
+	return err
+}
+
+func (r *accessTokenRepository) List(ctx context.Context, accountID int64) ([]*model.AccessToken, error) {
+	var accessTokens []*model.AccessToken
+	rows, err := r.mysqlClient.Executor(ctx).QueryContext(ctx, `
+	SELECT
+		id, name, token_hash, scopes, expires_at, revoked_at, created_at, account_id
+	FROM
+		access_token
+	WHERE
+		account_id = ?
+	ORDER BY
+		id DESC
+	`, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		accessToken, err := scanAccessToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		accessTokens = append(accessTokens, accessToken)
+	}
+
+	return accessTokens, rows.Err()
+}
+
+func (r *accessTokenRepository) Get(ctx context.Context, id int64) (*model.AccessToken, error) {
+	return scanAccessToken(r.mysqlClient.Executor(ctx).QueryRowContext(ctx, `
+	SELECT
+		id, name, token_hash, scopes, expires_at, revoked_at, created_at, account_id
+	FROM
+		access_token
+	WHERE
+		id = ?
+	`, id))
+}
+
+// GetByHash also loads the role and two factor state of the owning account, the
+// token acts with them. Tokens of deleted accounts are not found.
+func (r *accessTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*model.AccessToken, error) {
+	var scopes string
+	accessToken := new(model.AccessToken)
+	err := r.mysqlClient.Executor(ctx).QueryRowContext(ctx, `
+	SELECT
+		access_token.id, access_token.name, access_token.token_hash, access_token.scopes, access_token.expires_at,
+		access_token.revoked_at, access_token.created_at, access_token.account_id, account.role, account.totp_enabled_at
+	FROM
+		access_token
+	JOIN
+		account ON account.id = access_token.account_id
+	WHERE
+		access_token.token_hash = ? AND account.deleted_at IS NULL
+	`, tokenHash,
+	).Scan(&accessToken.ID, &accessToken.Name, &accessToken.TokenHash, &scopes, &accessToken.ExpiresAt,
+		&accessToken.RevokedAt, &accessToken.CreatedAt, &accessToken.AccountID, &accessToken.Account.Role, &accessToken.Account.TOTPEnabledAt)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken.Scopes = strings.Fields(scopes)
+	accessToken.Account.ID = accessToken.AccountID
+	return accessToken, nil
+}
+
+func (r *accessTokenRepository) Revoke(ctx context.Context, id int64, revokedAt time.Time) error {
+	res, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+	UPDATE
+		access_token
+	SET
+		revoked_at = ?
+	WHERE
+		id = ? AND revoked_at IS NULL
+	`, revokedAt, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAccessToken(row scanner) (*model.AccessToken, error) {
+	var scopes string
+	accessToken := new(model.AccessToken)
+	err := row.Scan(&accessToken.ID, &accessToken.Name, &accessToken.TokenHash, &scopes, &accessToken.ExpiresAt,
+		&accessToken.RevokedAt, &accessToken.CreatedAt, &accessToken.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken.Scopes = strings.Fields(scopes)
+	return accessToken, nil
+}
+
+func (r *accessTokenRepository) DeleteByAccountID(ctx context.Context, accountID int64) error {
+	_, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+	DELETE FROM
+		access_token
+	WHERE
+		account_id = ?
+	`, accountID)
+	return err
+}
@@ -2,13 +2,13 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/osamaesmail/go-post-api/internal/app/model"
-	"github.com/osamaesmail/go-post-api/internal/config"
 	"github.com/osamaesmail/go-post-api/internal/db/mysql"
 	"github.com/osamaesmail/go-post-api/internal/db/redis"
-	cache "github.com/go-redis/cache/v8"
 )
 
 type AccountRepository interface {
@@ -16,10 +16,20 @@ type AccountRepository interface {
 	List(ctx context.Context, limit, offset int, name string) ([]*model.Account, error)
 	Get(ctx context.Context, id int64) (*model.Account, error)
 	GetByEmail(ctx context.Context, email string) (*model.Account, error)
+	GetByHandle(ctx context.Context, handle string) (*model.Account, error)
+	GetWithDeleted(ctx context.Context, id int64, deletedSince time.Time) (*model.Account, error)
+	GetByEmailWithDeleted(ctx context.Context, email string, deletedSince time.Time) (*model.Account, error)
+	GetByHandleWithDeleted(ctx context.Context, handle string, deletedSince time.Time) (*model.Account, error)
 	Update(ctx context.Context, account *model.Account) error
-	Delete(ctx context.Context, id int64) error
+	Delete(ctx context.Context, id int64, deletedAt time.Time) error
+	Restore(ctx context.Context, id int64) error
+	ListPurgeable(ctx context.Context, deletedBefore time.Time, limit int) ([]*model.Account, error)
+	Anonymize(ctx context.Context, id int64, purgedAt time.Time) error
+	Purge(ctx context.Context, id int64) error
 }
 
+// NewAccountRepository hides soft deleted accounts from List, Get, GetByEmail
+// and GetByHandle, the WithDeleted variants find them within their grace period.
 func NewAccountRepository(mysqlClient mysql.Client, redisClient redis.Client) AccountRepository {
 	return &accountRepository{mysqlClient, redisClient}
 }
@@ -30,12 +40,12 @@ type accountRepository struct {
 }
 
 func (r *accountRepository) Create(ctx context.Context, account *model.Account) error {
-	res, err := r.mysqlClient.Conn().ExecContext(ctx, `
+	res, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
 	INSERT INTO
-		account (name, email, password, created_at)
+		account (name, handle, email, password, role, created_at)
 	VALUES
-		(?, ?, ?, ?)
-	`, account.Name, account.Email, account.Password, account.CreatedAt)
+		(?, ?, ?, ?, ?, ?)
+	`, account.Name, account.Handle, account.Email, account.Password, account.Role, account.CreatedAt)
 	if err != nil {
 		return err
 	}
@@ -46,19 +56,22 @@ func (r *accountRepository) Create(ctx context.Context, account *model.Account)
 	}
 
 	temp, err := r.Get(ctx, account.ID)
+	if err != nil {
+		return err
+	}
 	*account = *temp
-	return err
+	return nil
 }
 
 func (r *accountRepository) List(ctx context.Context, limit, offset int, name string) ([]*model.Account, error) {
 	var accounts []*model.Account
-	rows, err := r.mysqlClient.Conn().QueryContext(ctx, `
+	rows, err := r.mysqlClient.Executor(ctx).QueryContext(ctx, `
 	SELECT
-		id, name, email, created_at, updated_at
+		id, name, handle, bio, website, avatar_url, email, role, email_verified_at, pending_email, totp_enabled_at, created_at, updated_at
 	FROM
 		account
 	WHERE
-		name LIKE ?
+		name LIKE ? AND deleted_at IS NULL
 	LIMIT
 		? OFFSET ?
 	`, "%"+name+"%", limit, offset)
@@ -69,7 +82,8 @@ func (r *accountRepository) List(ctx context.Context, limit, offset int, name st
 
 	for rows.Next() {
 		account := new(model.Account)
-		err := rows.Scan(&account.ID, &account.Name, &account.Email, &account.CreatedAt, &account.UpdatedAt)
+		err := rows.Scan(&account.ID, &account.Name, &account.Handle, &account.Bio, &account.Website, &account.AvatarURL, &account.Email, &account.Role, &account.EmailVerifiedAt, &account.PendingEmail,
+			&account.TOTPEnabledAt, &account.CreatedAt, &account.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -81,102 +95,264 @@ func (r *accountRepository) List(ctx context.Context, limit, offset int, name st
 
 func (r *accountRepository) Get(ctx context.Context, id int64) (*model.Account, error) {
 	account := new(model.Account)
-	err := r.redisClient.Cache().Get(ctx, fmt.Sprintf("account_%d", id), account)
-	if err != nil && err != cache.ErrCacheMiss {
+	found, err := getCached(ctx, r.redisClient, fmt.Sprintf("account_%d", id), account)
+	if err != nil {
 		return nil, err
-	} else if err == nil {
+	} else if found {
 		return account, nil
 	}
 
-	err = r.mysqlClient.Conn().QueryRowContext(ctx, `
+	err = r.mysqlClient.Executor(ctx).QueryRowContext(ctx, `
 	SELECT
-		id, name, email, password, created_at, updated_at
+		id, name, handle, bio, website, avatar_url, email, password, role, email_verified_at, pending_email, totp_secret, totp_enabled_at, created_at, updated_at
 	FROM
 		account
 	WHERE
-		id = ?
+		id = ? AND deleted_at IS NULL
 	`, id,
-	).Scan(&account.ID, &account.Name, &account.Email, &account.Password, &account.CreatedAt, &account.UpdatedAt)
+	).Scan(&account.ID, &account.Name, &account.Handle, &account.Bio, &account.Website, &account.AvatarURL, &account.Email, &account.Password, &account.Role, &account.EmailVerifiedAt, &account.PendingEmail,
+		&account.TOTPSecret, &account.TOTPEnabledAt, &account.CreatedAt, &account.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
 
-	return account, r.redisClient.Cache().Set(&cache.Item{
-		Ctx:   ctx,
-		Key:   fmt.Sprintf("account_%d", id),
-		Value: account,
-		TTL:   config.Cfg().RedisTTL,
-	})
+	return account, setCached(ctx, r.redisClient, fmt.Sprintf("account_%d", id), account)
 }
 
 func (r *accountRepository) GetByEmail(ctx context.Context, email string) (*model.Account, error) {
 	account := new(model.Account)
-	err := r.redisClient.Cache().Get(ctx, fmt.Sprintf("account_%s", email), account)
-	if err != nil && err != cache.ErrCacheMiss {
+	found, err := getCached(ctx, r.redisClient, fmt.Sprintf("account_%s", email), account)
+	if err != nil {
 		return nil, err
-	} else if err == nil {
+	} else if found {
 		return account, nil
 	}
 
-	err = r.mysqlClient.Conn().QueryRowContext(ctx, `
+	err = r.mysqlClient.Executor(ctx).QueryRowContext(ctx, `
 	SELECT
-		id, name, email, password, created_at, updated_at
+		id, name, handle, bio, website, avatar_url, email, password, role, email_verified_at, pending_email, totp_secret, totp_enabled_at, created_at, updated_at
 	FROM
 		account
 	WHERE
-		email = ?
+		email = ? AND deleted_at IS NULL
 	`, email,
-	).Scan(&account.ID, &account.Name, &account.Email, &account.Password, &account.CreatedAt, &account.UpdatedAt)
+	).Scan(&account.ID, &account.Name, &account.Handle, &account.Bio, &account.Website, &account.AvatarURL, &account.Email, &account.Password, &account.Role, &account.EmailVerifiedAt, &account.PendingEmail,
+		&account.TOTPSecret, &account.TOTPEnabledAt, &account.CreatedAt, &account.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return account, setCached(ctx, r.redisClient, fmt.Sprintf("account_%s", email), account)
+}
+
+// GetByHandle expects the handle in lower case, the way it is stored.
+func (r *accountRepository) GetByHandle(ctx context.Context, handle string) (*model.Account, error) {
+	account := new(model.Account)
+	found, err := getCached(ctx, r.redisClient, fmt.Sprintf("account_@%s", handle), account)
+	if err != nil {
+		return nil, err
+	} else if found {
+		return account, nil
+	}
+
+	err = r.mysqlClient.Executor(ctx).QueryRowContext(ctx, `
+	SELECT
+		id, name, handle, bio, website, avatar_url, email, password, role, email_verified_at, pending_email, totp_secret, totp_enabled_at, created_at, updated_at
+	FROM
+		account
+	WHERE
+		handle = ? AND deleted_at IS NULL
+	`, handle,
+	).Scan(&account.ID, &account.Name, &account.Handle, &account.Bio, &account.Website, &account.AvatarURL, &account.Email, &account.Password, &account.Role, &account.EmailVerifiedAt, &account.PendingEmail,
+		&account.TOTPSecret, &account.TOTPEnabledAt, &account.CreatedAt, &account.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
 
-	return account, r.redisClient.Cache().Set(&cache.Item{
-		Ctx:   ctx,
-		Key:   fmt.Sprintf("account_%s", email),
-		Value: account,
-		TTL:   config.Cfg().RedisTTL,
-	})
+	return account, setCached(ctx, r.redisClient, fmt.Sprintf("account_@%s", handle), account)
+}
+
+// GetWithDeleted also returns the account when it was deleted at or after
+// deletedSince and is not purged yet, it is not cached.
+func (r *accountRepository) GetWithDeleted(ctx context.Context, id int64, deletedSince time.Time) (*model.Account, error) {
+	return r.getWithDeleted(ctx, "id = ?", id, deletedSince)
+}
+
+// GetByEmailWithDeleted finds the account holding the email like
+// GetWithDeleted, a zero deletedSince finds any account still holding it.
+func (r *accountRepository) GetByEmailWithDeleted(ctx context.Context, email string, deletedSince time.Time) (*model.Account, error) {
+	return r.getWithDeleted(ctx, "email = ?", email, deletedSince)
 }
 
+// GetByHandleWithDeleted finds the account holding the handle like
+// GetByEmailWithDeleted.
+func (r *accountRepository) GetByHandleWithDeleted(ctx context.Context, handle string, deletedSince time.Time) (*model.Account, error) {
+	return r.getWithDeleted(ctx, "handle = ?", handle, deletedSince)
+}
+
+func (r *accountRepository) getWithDeleted(ctx context.Context, where string, arg interface{}, deletedSince time.Time) (*model.Account, error) {
+	account := new(model.Account)
+	err := r.mysqlClient.Executor(ctx).QueryRowContext(ctx, `
+	SELECT
+		id, name, handle, bio, website, avatar_url, email, password, role, email_verified_at, pending_email, totp_secret, totp_enabled_at, created_at, updated_at, deleted_at
+	FROM
+		account
+	WHERE
+		`+where+` AND purged_at IS NULL AND (deleted_at IS NULL OR deleted_at >= ?)
+	`, arg, deletedSince,
+	).Scan(&account.ID, &account.Name, &account.Handle, &account.Bio, &account.Website, &account.AvatarURL, &account.Email, &account.Password, &account.Role, &account.EmailVerifiedAt, &account.PendingEmail,
+		&account.TOTPSecret, &account.TOTPEnabledAt, &account.CreatedAt, &account.UpdatedAt, &account.DeletedAt)
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// Update also applies to soft deleted accounts, so a login can upgrade the
+// password hash of the account it is about to restore.
 func (r *accountRepository) Update(ctx context.Context, account *model.Account) error {
-	_, err := r.mysqlClient.Conn().ExecContext(ctx, `
+	prev, err := r.GetWithDeleted(ctx, account.ID, time.Time{})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.mysqlClient.Executor(ctx).ExecContext(ctx, `
 	UPDATE
 		account
 	SET
-		name = ?, email = ?, password = ?, updated_at = ?
+		name = ?, handle = ?, bio = ?, website = ?, avatar_url = ?, email = ?, password = ?, role = ?, email_verified_at = ?, pending_email = ?,
+		totp_secret = ?, totp_enabled_at = ?, updated_at = ?
 	WHERE
 		id = ?
-	`, account.Name, account.Email, account.Password, account.UpdatedAt.Time, account.ID)
+	`, account.Name, account.Handle, account.Bio, account.Website, account.AvatarURL, account.Email, account.Password, account.Role, account.EmailVerifiedAt, account.PendingEmail,
+		account.TOTPSecret, account.TOTPEnabledAt, account.UpdatedAt.Time, account.ID)
 	if err != nil {
 		return err
 	}
 
-	err = r.redisClient.Cache().Delete(ctx, fmt.Sprintf("account_%d", account.ID))
-	if err != nil && err != cache.ErrCacheMiss {
+	err = r.deleteCache(ctx, prev)
+	if err != nil {
 		return err
 	}
 
-	temp, err := r.Get(ctx, account.ID)
+	temp, err := r.GetWithDeleted(ctx, account.ID, time.Time{})
+	if err != nil {
+		return err
+	}
 	*account = *temp
-	return err
+	return nil
 }
 
-func (r *accountRepository) Delete(ctx context.Context, id int64) error {
-	_, err := r.mysqlClient.Conn().ExecContext(ctx, `
-	DELETE FROM
+// Delete only soft deletes the account, Purge removes it for good.
+func (r *accountRepository) Delete(ctx context.Context, id int64, deletedAt time.Time) error {
+	prev, err := r.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+	UPDATE
 		account
+	SET
+		deleted_at = ?
 	WHERE
 		id = ?
-	`, id)
+	`, deletedAt, id)
 	if err != nil {
 		return err
 	}
 
-	err = r.redisClient.Cache().Delete(ctx, fmt.Sprintf("account_%d", id))
-	if err != nil && err != cache.ErrCacheMiss {
+	return r.deleteCache(ctx, prev)
+}
+
+func (r *accountRepository) Restore(ctx context.Context, id int64) error {
+	res, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+	UPDATE
+		account
+	SET
+		deleted_at = NULL
+	WHERE
+		id = ? AND purged_at IS NULL
+	`, id)
+	if err != nil {
 		return err
 	}
 
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
 	return nil
 }
+
+// ListPurgeable returns the accounts deleted before deletedBefore that are not
+// purged yet, the longest deleted first.
+func (r *accountRepository) ListPurgeable(ctx context.Context, deletedBefore time.Time, limit int) ([]*model.Account, error) {
+	var accounts []*model.Account
+	rows, err := r.mysqlClient.Executor(ctx).QueryContext(ctx, `
+	SELECT
+		id, name, handle, bio, website, avatar_url, email, role, email_verified_at, pending_email, totp_enabled_at, created_at, updated_at, deleted_at
+	FROM
+		account
+	WHERE
+		deleted_at < ? AND purged_at IS NULL
+	ORDER BY
+		deleted_at
+	LIMIT
+		?
+	`, deletedBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		account := new(model.Account)
+		err := rows.Scan(&account.ID, &account.Name, &account.Handle, &account.Bio, &account.Website, &account.AvatarURL, &account.Email, &account.Role, &account.EmailVerifiedAt, &account.PendingEmail,
+			&account.TOTPEnabledAt, &account.CreatedAt, &account.UpdatedAt, &account.DeletedAt)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+
+	return accounts, rows.Err()
+}
+
+// Anonymize keeps the row for the content that still references it, but
+// clears everything that identifies the person and frees the email and handle.
+func (r *accountRepository) Anonymize(ctx context.Context, id int64, purgedAt time.Time) error {
+	_, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+	UPDATE
+		account
+	SET
+		name = 'Deleted account', handle = NULL, bio = '', website = '', avatar_url = '',
+		email = CONCAT('deleted-', id, '@invalid'), password = '', email_verified_at = NULL,
+		pending_email = NULL, totp_secret = NULL, totp_enabled_at = NULL, purged_at = ?
+	WHERE
+		id = ?
+	`, purgedAt, id)
+	return err
+}
+
+func (r *accountRepository) Purge(ctx context.Context, id int64) error {
+	_, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+	DELETE FROM
+		account
+	WHERE
+		id = ?
+	`, id)
+	return err
+}
+
+// deleteCache drops the id, email and handle entries of the account, the email
+// entry would otherwise keep serving the old password and role on login.
+func (r *accountRepository) deleteCache(ctx context.Context, account *model.Account) error {
+	keys := []string{fmt.Sprintf("account_%d", account.ID), fmt.Sprintf("account_%s", account.Email)}
+	if account.Handle.Valid {
+		keys = append(keys, fmt.Sprintf("account_@%s", account.Handle.String))
+	}
+	return deleteCached(ctx, r.mysqlClient, r.redisClient, keys...)
+}
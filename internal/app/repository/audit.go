@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/db/mysql"
+)
+
+// AuditRepository has no way to change or delete an event, the audit log is
+// append only.
+type AuditRepository interface {
+	Create(ctx context.Context, event *model.AuditEvent) error
+	List(ctx context.Context, filter model.AuditFilter) ([]*model.AuditEvent, error)
+}
+
+func NewAuditRepository(mysqlClient mysql.Client) AuditRepository {
+	return &auditRepository{mysqlClient}
+}
+
+type auditRepository struct {
+	mysqlClient mysql.Client
+}
+
+func (r *auditRepository) Create(ctx context.Context, event *model.AuditEvent) error {
+	var diff interface{}
+	if event.Diff != nil {
+		diff = string(event.Diff)
+	}
+
+	res, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+	INSERT INTO
+		audit_event (actor_id, action, target_type, target_id, ip, request_id, diff, created_at)
+	VALUES
+		(?, ?, ?, ?, ?, ?, ?, ?)
+	`, event.ActorID, event.Action, event.TargetType, event.TargetID, event.IP, event.RequestID, diff, event.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	event.ID, err = res.LastInsertId()
+	return err
+}
+
+func (r *auditRepository) List(ctx context.Context, filter model.AuditFilter) ([]*model.AuditEvent, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.ActorID != 0 {
+		conditions = append(conditions, "actor_id = ?")
+		args = append(args, filter.ActorID)
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, filter.Action)
+	}
+	if filter.TargetType != "" {
+		conditions = append(conditions, "target_type = ?")
+		args = append(args, filter.TargetType)
+	}
+	if filter.TargetID != 0 {
+		conditions = append(conditions, "target_id = ?")
+		args = append(args, filter.TargetID)
+	}
+	if filter.BeforeID != 0 {
+		conditions = append(conditions, "id < ?")
+		args = append(args, filter.BeforeID)
+	}
+
+	where := "TRUE"
+	if len(conditions) > 0 {
+		where = strings.Join(conditions, " AND ")
+	}
+
+	var events []*model.AuditEvent
+	rows, err := r.mysqlClient.Executor(ctx).QueryContext(ctx, `
+	SELECT
+		id, actor_id, action, target_type, target_id, ip, request_id, diff, created_at
+	FROM
+		audit_event
+	WHERE
+		`+where+`
+	ORDER BY
+		id DESC
+	LIMIT
+		?
+	`, append(args, filter.Limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var diff []byte
+		event := new(model.AuditEvent)
+		err := rows.Scan(&event.ID, &event.ActorID, &event.Action, &event.TargetType, &event.TargetID,
+			&event.IP, &event.RequestID, &diff, &event.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if diff != nil {
+			event.Diff = diff
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	cache "github.com/go-redis/cache/v8"
+	"github.com/osamaesmail/go-post-api/internal/config"
+	"github.com/osamaesmail/go-post-api/internal/db/mysql"
+	"github.com/osamaesmail/go-post-api/internal/db/redis"
+)
+
+// getCached reads what is cached under key into value and reports whether
+// there was anything. A transaction reads its own writes, never the cache.
+func getCached(ctx context.Context, redisClient redis.Client, key string, value interface{}) (bool, error) {
+	if mysql.InTransaction(ctx) {
+		return false, nil
+	}
+
+	err := redisClient.Cache().Get(ctx, key, value)
+	if err == cache.ErrCacheMiss {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// setCached caches value under key for REDIS_TTL. What a transaction read is
+// not cached, it may still be rolled back.
+func setCached(ctx context.Context, redisClient redis.Client, key string, value interface{}) error {
+	if mysql.InTransaction(ctx) {
+		return nil
+	}
+
+	return redisClient.Cache().Set(&cache.Item{
+		Ctx:   ctx,
+		Key:   key,
+		Value: value,
+		TTL:   config.Cfg().RedisTTL,
+	})
+}
+
+// deleteCached drops the keys once the transaction in ctx commits, a read
+// before the commit would otherwise cache the old row again.
+func deleteCached(ctx context.Context, mysqlClient mysql.Client, redisClient redis.Client, keys ...string) error {
+	return mysqlClient.AfterCommit(ctx, func(ctx context.Context) error {
+		for _, key := range keys {
+			err := redisClient.Cache().Delete(ctx, key)
+			if err != nil && err != cache.ErrCacheMiss {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// cacheKeys formats a key for every id.
+func cacheKeys(format string, ids []int64) []string {
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = fmt.Sprintf(format, id)
+	}
+	return keys
+}
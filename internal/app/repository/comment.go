@@ -4,9 +4,7 @@ import (
 	"context"
 	"fmt"
 
-	cache "github.com/go-redis/cache/v8"
 	"github.com/osamaesmail/go-post-api/internal/app/model"
-	"github.com/osamaesmail/go-post-api/internal/config"
 	"github.com/osamaesmail/go-post-api/internal/db/mysql"
 	"github.com/osamaesmail/go-post-api/internal/db/redis"
 )
@@ -14,9 +12,11 @@ import (
 type CommentRepository interface {
 	Create(ctx context.Context, comment *model.Comment) error
 	List(ctx context.Context, limit, offset, post_id int) ([]*model.Comment, error)
+	ListByAccountID(ctx context.Context, accountID, afterID int64, limit int) ([]*model.Comment, error)
 	Get(ctx context.Context, id int64) (*model.Comment, error)
 	Update(ctx context.Context, comment *model.Comment) error
 	Delete(ctx context.Context, id int64) error
+	DeleteByAccountID(ctx context.Context, accountID int64) error
 }
 
 func NewCommentRepository(mysqlClient mysql.Client, redisClient redis.Client) CommentRepository {
@@ -29,7 +29,7 @@ type commentRepository struct {
 }
 
 func (r *commentRepository) Create(ctx context.Context, comment *model.Comment) error {
-	res, err := r.mysqlClient.Conn().ExecContext(ctx, `
+	res, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
 	INSERT INTO
 		comment (body, account_id, post_id, created_at)
 	VALUES
@@ -45,13 +45,16 @@ func (r *commentRepository) Create(ctx context.Context, comment *model.Comment)
 	}
 
 	temp, err := r.Get(ctx, comment.ID)
+	if err != nil {
+		return err
+	}
 	*comment = *temp
 	return nil
 }
 
 func (r *commentRepository) List(ctx context.Context, limit, offset, post_id int) ([]*model.Comment, error) {
 	var comments []*model.Comment
-	rows, err := r.mysqlClient.Conn().QueryContext(ctx, `
+	rows, err := r.mysqlClient.Executor(ctx).QueryContext(ctx, `
 	SELECT
 		comment.id, comment.body, comment.created_at, comment.updated_at, comment.account_id, comment.post_id
 	FROM comment
@@ -75,16 +78,49 @@ func (r *commentRepository) List(ctx context.Context, limit, offset, post_id int
 	return comments, nil
 }
 
+// ListByAccountID pages through the comments written by the account by id,
+// pass the id of the last comment of the previous page as afterID.
+func (r *commentRepository) ListByAccountID(ctx context.Context, accountID, afterID int64, limit int) ([]*model.Comment, error) {
+	var comments []*model.Comment
+	rows, err := r.mysqlClient.Executor(ctx).QueryContext(ctx, `
+	SELECT
+		id, body, created_at, updated_at, account_id, post_id
+	FROM
+		comment
+	WHERE
+		account_id = ? AND id > ?
+	ORDER BY
+		id
+	LIMIT
+		?
+	`, accountID, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		comment := new(model.Comment)
+		err := rows.Scan(&comment.ID, &comment.Body, &comment.CreatedAt, &comment.UpdatedAt, &comment.AccountID, &comment.PostID)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+
+	return comments, rows.Err()
+}
+
 func (r *commentRepository) Get(ctx context.Context, id int64) (*model.Comment, error) {
 	comment := new(model.Comment)
-	err := r.redisClient.Cache().Get(ctx, fmt.Sprintf("comment_%d", id), comment)
-	if err != nil && err != cache.ErrCacheMiss {
+	found, err := getCached(ctx, r.redisClient, fmt.Sprintf("comment_%d", id), comment)
+	if err != nil {
 		return nil, err
-	} else if err == nil {
+	} else if found {
 		return comment, nil
 	}
 
-	err = r.mysqlClient.Conn().QueryRowContext(ctx, `
+	err = r.mysqlClient.Executor(ctx).QueryRowContext(ctx, `
 	SELECT comment.id, comment.body, comment.created_at, comment.updated_at, comment.account_id, comment.post_id
 	FROM comment
 	WHERE comment.id = ?
@@ -94,16 +130,11 @@ func (r *commentRepository) Get(ctx context.Context, id int64) (*model.Comment,
 		return nil, err
 	}
 
-	return comment, r.redisClient.Cache().Set(&cache.Item{
-		Ctx:   ctx,
-		Key:   fmt.Sprintf("comment_%d", id),
-		Value: comment,
-		TTL:   config.Cfg().RedisTTL,
-	})
+	return comment, setCached(ctx, r.redisClient, fmt.Sprintf("comment_%d", id), comment)
 }
 
 func (r *commentRepository) Update(ctx context.Context, comment *model.Comment) error {
-	_, err := r.mysqlClient.Conn().ExecContext(ctx, `
+	_, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
 	UPDATE
 		comment
 	SET
@@ -115,18 +146,21 @@ func (r *commentRepository) Update(ctx context.Context, comment *model.Comment)
 		return err
 	}
 
-	err = r.redisClient.Cache().Delete(ctx, fmt.Sprintf("comment_%d", comment.ID))
-	if err != nil && err != cache.ErrCacheMiss {
+	err = deleteCached(ctx, r.mysqlClient, r.redisClient, fmt.Sprintf("comment_%d", comment.ID))
+	if err != nil {
 		return err
 	}
 
 	temp, err := r.Get(ctx, comment.ID)
+	if err != nil {
+		return err
+	}
 	*comment = *temp
-	return err
+	return nil
 }
 
 func (r *commentRepository) Delete(ctx context.Context, id int64) error {
-	_, err := r.mysqlClient.Conn().ExecContext(ctx, `
+	_, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
 	DELETE FROM
 		comment
 	WHERE
@@ -136,10 +170,38 @@ func (r *commentRepository) Delete(ctx context.Context, id int64) error {
 		return err
 	}
 
-	err = r.redisClient.Cache().Delete(ctx, fmt.Sprintf("comment_%d", id))
-	if err != nil && err != cache.ErrCacheMiss {
+	err = deleteCached(ctx, r.mysqlClient, r.redisClient, fmt.Sprintf("comment_%d", id))
+	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// DeleteByAccountID removes the comments written by the account along with the
+// comments on its posts.
+func (r *commentRepository) DeleteByAccountID(ctx context.Context, accountID int64) error {
+	ids, err := queryIDs(ctx, r.mysqlClient, `
+	SELECT
+		id
+	FROM
+		comment
+	WHERE
+		account_id = ? OR post_id IN (SELECT id FROM post WHERE account_id = ?)
+	`, accountID, accountID)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+	DELETE FROM
+		comment
+	WHERE
+		account_id = ? OR post_id IN (SELECT id FROM post WHERE account_id = ?)
+	`, accountID, accountID)
+	if err != nil {
+		return err
+	}
+
+	return deleteCached(ctx, r.mysqlClient, r.redisClient, cacheKeys("comment_%d", ids)...)
+}
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/db/mysql"
+)
+
+type ExportJobRepository interface {
+	Create(ctx context.Context, job *model.ExportJob) error
+	Get(ctx context.Context, id int64) (*model.ExportJob, error)
+	GetActiveByAccountID(ctx context.Context, accountID int64) (*model.ExportJob, error)
+	ClaimPending(ctx context.Context) (*model.ExportJob, error)
+	Finish(ctx context.Context, id int64, status string, finishedAt time.Time) error
+	ListFinishedBefore(ctx context.Context, finishedBefore time.Time, limit int) ([]*model.ExportJob, error)
+	Expire(ctx context.Context, id int64) error
+	DeleteByAccountID(ctx context.Context, accountID int64) error
+}
+
+func NewExportJobRepository(mysqlClient mysql.Client) ExportJobRepository {
+	return &exportJobRepository{mysqlClient}
+}
+
+type exportJobRepository struct {
+	mysqlClient mysql.Client
+}
+
+func (r *exportJobRepository) Create(ctx context.Context, job *model.ExportJob) error {
+	res, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+	INSERT INTO
+		export_job (status, account_id, created_at)
+	VALUES
+		(?, ?, ?)
+	`, job.Status, job.AccountID, job.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	job.ID, err = res.LastInsertId()
+	return err
+}
+
+func (r *exportJobRepository) Get(ctx context.Context, id int64) (*model.ExportJob, error) {
+	return r.get(ctx, "id = ?", id)
+}
+
+// GetActiveByAccountID returns the pending or running job of the account.
+func (r *exportJobRepository) GetActiveByAccountID(ctx context.Context, accountID int64) (*model.ExportJob, error) {
+	return r.get(ctx, "account_id = ? AND status IN ('pending', 'running')", accountID)
+}
+
+func (r *exportJobRepository) get(ctx context.Context, where string, arg interface{}) (*model.ExportJob, error) {
+	job := new(model.ExportJob)
+	err := r.mysqlClient.Executor(ctx).QueryRowContext(ctx, `
+	SELECT
+		id, status, created_at, finished_at, account_id
+	FROM
+		export_job
+	WHERE
+		`+where+`
+	ORDER BY
+		id
+	LIMIT
+		1
+	`, arg,
+	).Scan(&job.ID, &job.Status, &job.CreatedAt, &job.FinishedAt, &job.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// ClaimPending marks the oldest pending job as running and returns it, so
+// several instances never run the same job. It returns sql.ErrNoRows when
+// there is nothing to run.
+func (r *exportJobRepository) ClaimPending(ctx context.Context) (*model.ExportJob, error) {
+	for {
+		job, err := r.get(ctx, "status = ?", model.ExportJobPending)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+		UPDATE
+			export_job
+		SET
+			status = ?
+		WHERE
+			id = ? AND status = ?
+		`, model.ExportJobRunning, job.ID, model.ExportJobPending)
+		if err != nil {
+			return nil, err
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return nil, err
+		} else if n == 1 {
+			job.Status = model.ExportJobRunning
+			return job, nil
+		}
+		// another instance claimed it first
+	}
+}
+
+func (r *exportJobRepository) Finish(ctx context.Context, id int64, status string, finishedAt time.Time) error {
+	_, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+	UPDATE
+		export_job
+	SET
+		status = ?, finished_at = ?
+	WHERE
+		id = ?
+	`, status, finishedAt, id)
+	return err
+}
+
+// ListFinishedBefore returns the jobs whose archive is still kept although
+// they finished before finishedBefore.
+func (r *exportJobRepository) ListFinishedBefore(ctx context.Context, finishedBefore time.Time, limit int) ([]*model.ExportJob, error) {
+	var jobs []*model.ExportJob
+	rows, err := r.mysqlClient.Executor(ctx).QueryContext(ctx, `
+	SELECT
+		id, status, created_at, finished_at, account_id
+	FROM
+		export_job
+	WHERE
+		status IN ('done', 'failed') AND finished_at < ?
+	ORDER BY
+		id
+	LIMIT
+		?
+	`, finishedBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		job := new(model.ExportJob)
+		err := rows.Scan(&job.ID, &job.Status, &job.CreatedAt, &job.FinishedAt, &job.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+func (r *exportJobRepository) Expire(ctx context.Context, id int64) error {
+	res, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+	UPDATE
+		export_job
+	SET
+		status = ?
+	WHERE
+		id = ?
+	`, model.ExportJobExpired, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *exportJobRepository) DeleteByAccountID(ctx context.Context, accountID int64) error {
+	_, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+	DELETE FROM
+		export_job
+	WHERE
+		account_id = ?
+	`, accountID)
+	return err
+}
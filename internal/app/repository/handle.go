@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/db/mysql"
+)
+
+// HandleRepository keeps the handles accounts used before.
+type HandleRepository interface {
+	Create(ctx context.Context, handle *model.AccountHandle) error
+	GetByHandle(ctx context.Context, handle string) (*model.AccountHandle, error)
+	DeleteByHandle(ctx context.Context, handle string) error
+	DeleteByAccountID(ctx context.Context, accountID int64) error
+}
+
+func NewHandleRepository(mysqlClient mysql.Client) HandleRepository {
+	return &handleRepository{mysqlClient}
+}
+
+type handleRepository struct {
+	mysqlClient mysql.Client
+}
+
+func (r *handleRepository) Create(ctx context.Context, handle *model.AccountHandle) error {
+	res, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+	INSERT INTO
+		account_handle_history (handle, account_id, created_at)
+	VALUES
+		(?, ?, ?)
+	`, handle.Handle, handle.AccountID, handle.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	handle.ID, err = res.LastInsertId()
+	return err
+}
+
+func (r *handleRepository) GetByHandle(ctx context.Context, handle string) (*model.AccountHandle, error) {
+	accountHandle := new(model.AccountHandle)
+	err := r.mysqlClient.Executor(ctx).QueryRowContext(ctx, `
+	SELECT
+		id, handle, created_at, account_id
+	FROM
+		account_handle_history
+	WHERE
+		handle = ?
+	`, handle,
+	).Scan(&accountHandle.ID, &accountHandle.Handle, &accountHandle.CreatedAt, &accountHandle.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	return accountHandle, nil
+}
+
+func (r *handleRepository) DeleteByHandle(ctx context.Context, handle string) error {
+	_, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+	DELETE FROM
+		account_handle_history
+	WHERE
+		handle = ?
+	`, handle)
+	return err
+}
+
+func (r *handleRepository) DeleteByAccountID(ctx context.Context, accountID int64) error {
+	_, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+	DELETE FROM
+		account_handle_history
+	WHERE
+		account_id = ?
+	`, accountID)
+	return err
+}
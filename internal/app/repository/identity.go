@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/db/mysql"
+)
+
+type IdentityRepository interface {
+	Create(ctx context.Context, identity *model.AccountIdentity) error
+	GetByProviderSubject(ctx context.Context, provider, subject string) (*model.AccountIdentity, error)
+	DeleteByAccountID(ctx context.Context, accountID int64) error
+}
+
+func NewIdentityRepository(mysqlClient mysql.Client) IdentityRepository {
+	return &identityRepository{mysqlClient}
+}
+
+type identityRepository struct {
+	mysqlClient mysql.Client
+}
+
+func (r *identityRepository) Create(ctx context.Context, identity *model.AccountIdentity) error {
+	res, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+	INSERT INTO
+		account_identity (provider, subject, email, account_id, created_at)
+	VALUES
+		(?, ?, ?, ?, ?)
+	`, identity.Provider, identity.Subject, identity.Email, identity.AccountID, identity.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	identity.ID, err = res.LastInsertId()
+	return err
+}
+
+func (r *identityRepository) GetByProviderSubject(ctx context.Context, provider, subject string) (*model.AccountIdentity, error) {
+	identity := new(model.AccountIdentity)
+	err := r.mysqlClient.Executor(ctx).QueryRowContext(ctx, `
+	SELECT
+		id, provider, subject, email, created_at, account_id
+	FROM
+		account_identity
+	WHERE
+		provider = ? AND subject = ?
+	`, provider, subject,
+	).Scan(&identity.ID, &identity.Provider, &identity.Subject, &identity.Email, &identity.CreatedAt, &identity.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+func (r *identityRepository) DeleteByAccountID(ctx context.Context, accountID int64) error {
+	_, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+	DELETE FROM
+		account_identity
+	WHERE
+		account_id = ?
+	`, accountID)
+	return err
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/osamaesmail/go-post-api/internal/db/redis"
+)
+
+type LoginAttemptRepository interface {
+	IsLocked(ctx context.Context, key string) (bool, error)
+	RecordFailure(ctx context.Context, key string, window time.Duration) (int64, error)
+	Lock(ctx context.Context, key string, d time.Duration) error
+	Reset(ctx context.Context, key string) error
+}
+
+// NewLoginAttemptRepository keeps the counters straight in redis, every
+// instance has to see the same count.
+func NewLoginAttemptRepository(redisClient redis.Client) LoginAttemptRepository {
+	return &loginAttemptRepository{redisClient}
+}
+
+type loginAttemptRepository struct {
+	redisClient redis.Client
+}
+
+func (r *loginAttemptRepository) IsLocked(ctx context.Context, key string) (bool, error) {
+	n, err := r.redisClient.Conn().Exists(ctx, fmt.Sprintf("login_lock_%s", key)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// RecordFailure counts a failed attempt and returns the count, the count is
+// forgotten once no attempt failed for window.
+func (r *loginAttemptRepository) RecordFailure(ctx context.Context, key string, window time.Duration) (int64, error) {
+	pipe := r.redisClient.Conn().TxPipeline()
+	incr := pipe.Incr(ctx, fmt.Sprintf("login_failures_%s", key))
+	pipe.Expire(ctx, fmt.Sprintf("login_failures_%s", key), window)
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+func (r *loginAttemptRepository) Lock(ctx context.Context, key string, d time.Duration) error {
+	return r.redisClient.Conn().Set(ctx, fmt.Sprintf("login_lock_%s", key), 1, d).Err()
+}
+
+func (r *loginAttemptRepository) Reset(ctx context.Context, key string) error {
+	return r.redisClient.Conn().Del(ctx, fmt.Sprintf("login_failures_%s", key)).Err()
+}
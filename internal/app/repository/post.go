@@ -2,21 +2,26 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"strings"
+	"time"
 
-	cache "github.com/go-redis/cache/v8"
 	"github.com/osamaesmail/go-post-api/internal/app/model"
-	"github.com/osamaesmail/go-post-api/internal/config"
 	"github.com/osamaesmail/go-post-api/internal/db/mysql"
 	"github.com/osamaesmail/go-post-api/internal/db/redis"
 )
 
 type PostRepository interface {
 	Create(ctx context.Context, post *model.Post) error
-	List(ctx context.Context, limit, offset int, title string) ([]*model.Post, error)
+	List(ctx context.Context, filter model.PostFilter) ([]*model.Post, error)
+	ListByAccountID(ctx context.Context, accountID, afterID int64, limit int) ([]*model.Post, error)
 	Get(ctx context.Context, id int64) (*model.Post, error)
+	GetBySlug(ctx context.Context, slug string) (*model.Post, error)
 	Update(ctx context.Context, post *model.Post) error
 	Delete(ctx context.Context, id int64) error
+	DeleteByAccountID(ctx context.Context, accountID int64) error
+	PublishDue(ctx context.Context, now time.Time, limit int) ([]int64, error)
 }
 
 func NewPostRepository(mysqlClient mysql.Client, redisClient redis.Client) PostRepository {
@@ -28,14 +33,31 @@ type postRepository struct {
 	redisClient redis.Client
 }
 
+// postTagsColumn selects the tags of a post as a comma separated list, tags
+// never hold a comma.
+const postTagsColumn = `(
+		SELECT GROUP_CONCAT(tag.name ORDER BY tag.name SEPARATOR ',')
+		FROM post_tag JOIN tag ON tag.id = post_tag.tag_id
+		WHERE post_tag.post_id = post.id
+	)`
+
+func splitTags(tags sql.NullString) []string {
+	if !tags.Valid || tags.String == "" {
+		return nil
+	}
+	return strings.Split(tags.String, ",")
+}
+
 func (r *postRepository) Create(ctx context.Context, post *model.Post) error {
-	res, err := r.mysqlClient.Conn().ExecContext(ctx, `
+	res, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
 	INSERT INTO
-		post (title, body, account_id, created_at)
+		post (title, slug, body, body_format, status, publish_at, account_id, created_at)
 	VALUES
-		(?, ?, ?, ?)
-	`, post.Title, post.Body, post.AccountID, post.CreatedAt)
-	if err != nil {
+		(?, ?, ?, ?, ?, ?, ?, ?)
+	`, post.Title, post.Slug, post.Body, post.BodyFormat, post.Status, post.PublishAt, post.AccountID, post.CreatedAt)
+	if mysql.IsDuplicate(err, "post_slug") {
+		return ErrSlugTaken
+	} else if err != nil {
 		return err
 	}
 
@@ -44,83 +66,192 @@ func (r *postRepository) Create(ctx context.Context, post *model.Post) error {
 		return err
 	}
 
+	err = r.setTags(ctx, post.ID, post.Tags)
+	if err != nil {
+		return err
+	}
+
 	temp, err := r.Get(ctx, post.ID)
+	if err != nil {
+		return err
+	}
 	*post = *temp
 	return nil
 }
 
-func (r *postRepository) List(ctx context.Context, limit, offset int, title string) ([]*model.Post, error) {
+func (r *postRepository) List(ctx context.Context, filter model.PostFilter) ([]*model.Post, error) {
+	conditions := []string{"post.title LIKE ?"}
+	args := []interface{}{"%" + filter.Title + "%"}
+	if filter.Status != "" {
+		conditions = append(conditions, "post.status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.AccountID != 0 {
+		conditions = append(conditions, "post.account_id = ?")
+		args = append(args, filter.AccountID)
+	}
+	if len(filter.Tags) > 0 {
+		having := "COUNT(*) = ?"
+		if filter.AnyTag {
+			having = "TRUE"
+		}
+		conditions = append(conditions, `post.id IN (
+		SELECT post_tag.post_id FROM post_tag JOIN tag ON tag.id = post_tag.tag_id
+		WHERE tag.name IN (?`+strings.Repeat(", ?", len(filter.Tags)-1)+`)
+		GROUP BY post_tag.post_id HAVING `+having+`)`)
+		for _, tag := range filter.Tags {
+			args = append(args, tag)
+		}
+		if !filter.AnyTag {
+			args = append(args, len(filter.Tags))
+		}
+	}
+
 	var posts []*model.Post
-	rows, err := r.mysqlClient.Conn().QueryContext(ctx, `
-	SELECT post.id, post.title, post.body, post.created_at, post.updated_at, post.account_id
-	FROM post WHERE post.title LIKE ? LIMIT ? OFFSET ?`, "%"+title+"%", limit, offset)
+	rows, err := r.mysqlClient.Executor(ctx).QueryContext(ctx, `
+	SELECT post.id, post.title, post.slug, post.body, post.body_format, post.status, post.publish_at, `+postTagsColumn+`, post.created_at, post.updated_at, post.account_id
+	FROM post WHERE `+strings.Join(conditions, " AND ")+` LIMIT ? OFFSET ?`, append(args, filter.Limit, filter.Offset)...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
+		var tags sql.NullString
 		post := new(model.Post)
-		err := rows.Scan(&post.ID, &post.Title, &post.Body, &post.CreatedAt, &post.UpdatedAt, &post.AccountID)
+		err := rows.Scan(&post.ID, &post.Title, &post.Slug, &post.Body, &post.BodyFormat, &post.Status, &post.PublishAt, &tags, &post.CreatedAt, &post.UpdatedAt, &post.AccountID)
 		if err != nil {
 			return nil, err
 		}
+		post.Tags = splitTags(tags)
 		posts = append(posts, post)
 	}
 
 	return posts, nil
 }
 
+// ListByAccountID pages through the posts of the account by id, pass the id of
+// the last post of the previous page as afterID.
+func (r *postRepository) ListByAccountID(ctx context.Context, accountID, afterID int64, limit int) ([]*model.Post, error) {
+	var posts []*model.Post
+	rows, err := r.mysqlClient.Executor(ctx).QueryContext(ctx, `
+	SELECT
+		id, title, slug, body, body_format, status, publish_at, `+postTagsColumn+`, created_at, updated_at, account_id
+	FROM
+		post
+	WHERE
+		account_id = ? AND id > ?
+	ORDER BY
+		id
+	LIMIT
+		?
+	`, accountID, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tags sql.NullString
+		post := new(model.Post)
+		err := rows.Scan(&post.ID, &post.Title, &post.Slug, &post.Body, &post.BodyFormat, &post.Status, &post.PublishAt, &tags, &post.CreatedAt, &post.UpdatedAt, &post.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		post.Tags = splitTags(tags)
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
+
 func (r *postRepository) Get(ctx context.Context, id int64) (*model.Post, error) {
 	post := new(model.Post)
-	err := r.redisClient.Cache().Get(ctx, fmt.Sprintf("post_%d", id), post)
-	if err != nil && err != cache.ErrCacheMiss {
+	found, err := getCached(ctx, r.redisClient, fmt.Sprintf("post_%d", id), post)
+	if err != nil {
 		return nil, err
-	} else if err == nil {
+	} else if found {
 		return post, nil
 	}
 
-	err = r.mysqlClient.Conn().QueryRowContext(ctx, `
-	SELECT post.id, post.title, post.body, post.created_at, post.updated_at, post.account_id
+	var tags sql.NullString
+	err = r.mysqlClient.Executor(ctx).QueryRowContext(ctx, `
+	SELECT post.id, post.title, post.slug, post.body, post.body_format, post.status, post.publish_at, `+postTagsColumn+`, post.created_at, post.updated_at, post.account_id
 	FROM post WHERE post.id = ?`, id).
-		Scan(&post.ID, &post.Title, &post.Body, &post.CreatedAt, &post.UpdatedAt, &post.AccountID)
+		Scan(&post.ID, &post.Title, &post.Slug, &post.Body, &post.BodyFormat, &post.Status, &post.PublishAt, &tags, &post.CreatedAt, &post.UpdatedAt, &post.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	post.Tags = splitTags(tags)
+
+	return post, setCached(ctx, r.redisClient, fmt.Sprintf("post_%d", id), post)
+}
+
+// GetBySlug finds the post by its current slug, the cache is kept by id.
+func (r *postRepository) GetBySlug(ctx context.Context, slug string) (*model.Post, error) {
+	var id int64
+	err := r.mysqlClient.Executor(ctx).QueryRowContext(ctx, `
+	SELECT
+		id
+	FROM
+		post
+	WHERE
+		slug = ?
+	`, slug).Scan(&id)
 	if err != nil {
 		return nil, err
 	}
 
-	return post, r.redisClient.Cache().Set(&cache.Item{
-		Ctx:   ctx,
-		Key:   fmt.Sprintf("post_%d", id),
-		Value: post,
-		TTL:   config.Cfg().RedisTTL,
-	})
+	return r.Get(ctx, id)
 }
 
 func (r *postRepository) Update(ctx context.Context, post *model.Post) error {
-	_, err := r.mysqlClient.Conn().ExecContext(ctx, `
+	_, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
 	UPDATE
 		post
 	SET
-		title = ?, body = ?, updated_at = ?
+		title = ?, slug = ?, body = ?, body_format = ?, status = ?, publish_at = ?, updated_at = ?
 	WHERE
 		id = ?
-	`, post.Title, post.Body, post.UpdatedAt.Time, post.ID)
+	`, post.Title, post.Slug, post.Body, post.BodyFormat, post.Status, post.PublishAt, post.UpdatedAt.Time, post.ID)
+	if mysql.IsDuplicate(err, "post_slug") {
+		return ErrSlugTaken
+	} else if err != nil {
+		return err
+	}
+
+	err = r.setTags(ctx, post.ID, post.Tags)
 	if err != nil {
 		return err
 	}
 
-	err = r.redisClient.Cache().Delete(ctx, fmt.Sprintf("post_%d", post.ID))
-	if err != nil && err != cache.ErrCacheMiss {
+	err = deleteCached(ctx, r.mysqlClient, r.redisClient, fmt.Sprintf("post_%d", post.ID))
+	if err != nil {
 		return err
 	}
 
 	temp, err := r.Get(ctx, post.ID)
+	if err != nil {
+		return err
+	}
 	*post = *temp
-	return err
+	return nil
 }
 
 func (r *postRepository) Delete(ctx context.Context, id int64) error {
-	_, err := r.mysqlClient.Conn().ExecContext(ctx, `
+	for _, table := range []string{"post_tag", "post_slug_history"} {
+		_, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+		DELETE FROM
+			`+table+`
+		WHERE
+			post_id = ?
+		`, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
 	DELETE FROM
 		post
 	WHERE
@@ -130,10 +261,146 @@ func (r *postRepository) Delete(ctx context.Context, id int64) error {
 		return err
 	}
 
-	err = r.redisClient.Cache().Delete(ctx, fmt.Sprintf("post_%d", id))
-	if err != nil && err != cache.ErrCacheMiss {
+	err = deleteCached(ctx, r.mysqlClient, r.redisClient, fmt.Sprintf("post_%d", id))
+	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// DeleteByAccountID removes every post of the account, their comments have to
+// be deleted first.
+func (r *postRepository) DeleteByAccountID(ctx context.Context, accountID int64) error {
+	ids, err := queryIDs(ctx, r.mysqlClient, `
+	SELECT
+		id
+	FROM
+		post
+	WHERE
+		account_id = ?
+	`, accountID)
+	if err != nil {
+		return err
+	}
+
+	for _, table := range []string{"post_tag", "post_slug_history"} {
+		_, err = r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+		DELETE
+			`+table+`
+		FROM
+			`+table+` JOIN post ON post.id = `+table+`.post_id
+		WHERE
+			post.account_id = ?
+		`, accountID)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+	DELETE FROM
+		post
+	WHERE
+		account_id = ?
+	`, accountID)
+	if err != nil {
+		return err
+	}
+
+	return deleteCached(ctx, r.mysqlClient, r.redisClient, cacheKeys("post_%d", ids)...)
+}
+
+// PublishDue publishes the scheduled posts whose publish_at passed and returns
+// their ids. Each post is published by a conditional update, so instances
+// running it at the same time never publish a post twice.
+func (r *postRepository) PublishDue(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	ids, err := queryIDs(ctx, r.mysqlClient, `
+	SELECT
+		id
+	FROM
+		post
+	WHERE
+		status = ? AND publish_at <= ?
+	ORDER BY
+		publish_at
+	LIMIT
+		?
+	`, model.PostStatusScheduled, now, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var published []int64
+	for _, id := range ids {
+		res, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+		UPDATE
+			post
+		SET
+			status = ?
+		WHERE
+			id = ? AND status = ? AND publish_at <= ?
+		`, model.PostStatusPublished, id, model.PostStatusScheduled, now)
+		if err != nil {
+			return published, err
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return published, err
+		} else if n == 0 {
+			// another instance published it first or it was rescheduled
+			continue
+		}
+
+		err = deleteCached(ctx, r.mysqlClient, r.redisClient, fmt.Sprintf("post_%d", id))
+		if err != nil {
+			return published, err
+		}
+		published = append(published, id)
+	}
+	return published, nil
+}
+
+// setTags replaces the tags of the post, creating the tags no post used yet.
+func (r *postRepository) setTags(ctx context.Context, postID int64, tags []string) error {
+	_, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+	DELETE FROM
+		post_tag
+	WHERE
+		post_id = ?
+	`, postID)
+	if err != nil || len(tags) == 0 {
+		return err
+	}
+
+	placeholders := "(?, ?)" + strings.Repeat(", (?, ?)", len(tags)-1)
+	args := make([]interface{}, 0, len(tags)*2)
+	for _, tag := range tags {
+		args = append(args, tag, time.Now())
+	}
+	_, err = r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+	INSERT IGNORE INTO
+		tag (name, created_at)
+	VALUES
+		`+placeholders, args...)
+	if err != nil {
+		return err
+	}
+
+	args = []interface{}{postID}
+	for _, tag := range tags {
+		args = append(args, tag)
+	}
+	_, err = r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+	INSERT INTO
+		post_tag (post_id, tag_id)
+	SELECT
+		?, id
+	FROM
+		tag
+	WHERE
+		name IN (?`+strings.Repeat(", ?", len(tags)-1)+`)
+	`, args...)
+	return err
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	cache "github.com/go-redis/cache/v8"
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/config"
+	"github.com/osamaesmail/go-post-api/internal/db/redis"
+)
+
+// PostRenderRepository caches rendered post bodies by what they were rendered
+// from, an edited body is rendered again rather than served stale from the
+// local cache of another instance.
+type PostRenderRepository interface {
+	// Get returns the cached rendering of the post body, render builds it
+	// when there is none.
+	Get(ctx context.Context, post *model.Post, render func() *model.PostRender) (*model.PostRender, error)
+	Delete(ctx context.Context, post *model.Post) error
+}
+
+func NewPostRenderRepository(redisClient redis.Client) PostRenderRepository {
+	return &postRenderRepository{redisClient}
+}
+
+type postRenderRepository struct {
+	redisClient redis.Client
+}
+
+func (r *postRenderRepository) Get(ctx context.Context, post *model.Post, render func() *model.PostRender) (*model.PostRender, error) {
+	rendered := new(model.PostRender)
+	err := r.redisClient.Cache().Once(&cache.Item{
+		Ctx:   ctx,
+		Key:   postRenderKey(post),
+		Value: rendered,
+		TTL:   config.Cfg().RedisTTL,
+		Do: func(*cache.Item) (interface{}, error) {
+			return render(), nil
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rendered, nil
+}
+
+func (r *postRenderRepository) Delete(ctx context.Context, post *model.Post) error {
+	err := r.redisClient.Cache().Delete(ctx, postRenderKey(post))
+	if err != nil && err != cache.ErrCacheMiss {
+		return err
+	}
+	return nil
+}
+
+func postRenderKey(post *model.Post) string {
+	sum := sha256.Sum256([]byte(post.BodyFormat + "\n" + post.Body))
+	return fmt.Sprintf("post_render_%d_%s", post.ID, hex.EncodeToString(sum[:8]))
+}
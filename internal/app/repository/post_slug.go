@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/db/mysql"
+)
+
+// ErrSlugTaken is returned when a post is written with a slug another post
+// has or had before.
+var ErrSlugTaken = errors.New("slug taken")
+
+// PostSlugRepository keeps the slugs posts had before, PostRepository deletes
+// them along with their post.
+type PostSlugRepository interface {
+	Create(ctx context.Context, slug *model.PostSlug) error
+	GetBySlug(ctx context.Context, slug string) (*model.PostSlug, error)
+	DeleteBySlug(ctx context.Context, slug string) error
+}
+
+func NewPostSlugRepository(mysqlClient mysql.Client) PostSlugRepository {
+	return &postSlugRepository{mysqlClient}
+}
+
+type postSlugRepository struct {
+	mysqlClient mysql.Client
+}
+
+func (r *postSlugRepository) Create(ctx context.Context, slug *model.PostSlug) error {
+	res, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+	INSERT INTO
+		post_slug_history (slug, post_id, created_at)
+	VALUES
+		(?, ?, ?)
+	`, slug.Slug, slug.PostID, slug.CreatedAt)
+	if mysql.IsDuplicate(err, "slug") {
+		return ErrSlugTaken
+	} else if err != nil {
+		return err
+	}
+
+	slug.ID, err = res.LastInsertId()
+	return err
+}
+
+func (r *postSlugRepository) GetBySlug(ctx context.Context, slug string) (*model.PostSlug, error) {
+	postSlug := new(model.PostSlug)
+	err := r.mysqlClient.Executor(ctx).QueryRowContext(ctx, `
+	SELECT
+		id, slug, created_at, post_id
+	FROM
+		post_slug_history
+	WHERE
+		slug = ?
+	`, slug,
+	).Scan(&postSlug.ID, &postSlug.Slug, &postSlug.CreatedAt, &postSlug.PostID)
+	if err != nil {
+		return nil, err
+	}
+	return postSlug, nil
+}
+
+func (r *postSlugRepository) DeleteBySlug(ctx context.Context, slug string) error {
+	_, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+	DELETE FROM
+		post_slug_history
+	WHERE
+		slug = ?
+	`, slug)
+	return err
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/osamaesmail/go-post-api/internal/db/mysql"
+)
+
+// queryIDs collects the single id column a query selects, bulk deletes use it
+// to know which cache entries to drop.
+func queryIDs(ctx context.Context, mysqlClient mysql.Client, query string, args ...interface{}) ([]int64, error) {
+	rows, err := mysqlClient.Executor(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
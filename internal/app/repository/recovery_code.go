@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/osamaesmail/go-post-api/internal/db/mysql"
+)
+
+type RecoveryCodeRepository interface {
+	Replace(ctx context.Context, accountID int64, codeHashes []string) error
+	Use(ctx context.Context, accountID int64, codeHash string, usedAt time.Time) error
+	DeleteByAccountID(ctx context.Context, accountID int64) error
+}
+
+func NewRecoveryCodeRepository(mysqlClient mysql.Client) RecoveryCodeRepository {
+	return &recoveryCodeRepository{mysqlClient}
+}
+
+type recoveryCodeRepository struct {
+	mysqlClient mysql.Client
+}
+
+// Replace drops every code of the account, used or not, for the new ones.
+func (r *recoveryCodeRepository) Replace(ctx context.Context, accountID int64, codeHashes []string) error {
+	return r.mysqlClient.Transaction(ctx, func(ctx context.Context) error {
+		_, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+		DELETE FROM
+			recovery_code
+		WHERE
+			account_id = ?
+		`, accountID)
+		if err != nil {
+			return err
+		}
+
+		for _, codeHash := range codeHashes {
+			_, err = r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+			INSERT INTO
+				recovery_code (code_hash, account_id, created_at)
+			VALUES
+				(?, ?, ?)
+			`, codeHash, accountID, time.Now())
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Use marks the code as used, it returns sql.ErrNoRows when the account has no
+// such unused code.
+func (r *recoveryCodeRepository) Use(ctx context.Context, accountID int64, codeHash string, usedAt time.Time) error {
+	res, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+	UPDATE
+		recovery_code
+	SET
+		used_at = ?
+	WHERE
+		account_id = ? AND code_hash = ? AND used_at IS NULL
+	`, usedAt, accountID, codeHash)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *recoveryCodeRepository) DeleteByAccountID(ctx context.Context, accountID int64) error {
+	_, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+	DELETE FROM
+		recovery_code
+	WHERE
+		account_id = ?
+	`, accountID)
+	return err
+}
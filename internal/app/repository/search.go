@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/config"
+	"github.com/osamaesmail/go-post-api/internal/db/mysql"
+)
+
+// SearchIndex finds published posts and the comments on them. Index and
+// Remove keep it up to date as posts and comments change, indexing a post also
+// updates the title and status its comments are found with. An index reading
+// the tables themselves may ignore them.
+type SearchIndex interface {
+	Search(ctx context.Context, req model.SearchRequest) ([]*model.SearchResult, error)
+	Index(ctx context.Context, doc *model.SearchDocument) error
+	Remove(ctx context.Context, docType string, id int64) error
+	// RemoveByAccountID removes what the account wrote and the comments on its
+	// posts.
+	RemoveByAccountID(ctx context.Context, accountID int64) error
+}
+
+// NewSearchIndex picks the implementation named by SEARCH_DRIVER.
+func NewSearchIndex(mysqlClient mysql.Client) (SearchIndex, error) {
+	switch config.Cfg().SearchDriver {
+	case "", "mysql":
+		return &mysqlSearchIndex{mysqlClient}, nil
+	default:
+		return nil, fmt.Errorf("unsupported search driver %s", config.Cfg().SearchDriver)
+	}
+}
+
+// mysqlSearchIndex searches the FULLTEXT indexes of the post and comment
+// tables, matches in a post title count twice.
+type mysqlSearchIndex struct {
+	mysqlClient mysql.Client
+}
+
+func (r *mysqlSearchIndex) Search(ctx context.Context, req model.SearchRequest) ([]*model.SearchResult, error) {
+	var queries []string
+	var args []interface{}
+	if req.Type == "" || req.Type == model.SearchTypePost {
+		where, whereArgs := searchConditions("post", "MATCH(post.title, post.body) AGAINST (? IN NATURAL LANGUAGE MODE)", req)
+		queries = append(queries, `
+		SELECT
+			'post', post.id, post.id, post.title, post.body, post.status, post.account_id, post.created_at,
+			MATCH(post.title) AGAINST (? IN NATURAL LANGUAGE MODE) * 2 + MATCH(post.title, post.body) AGAINST (? IN NATURAL LANGUAGE MODE) AS score
+		FROM
+			post
+		WHERE
+			`+where)
+		args = append(append(args, req.Query, req.Query), whereArgs...)
+	}
+	if req.Type == "" || req.Type == model.SearchTypeComment {
+		where, whereArgs := searchConditions("comment", "MATCH(comment.body) AGAINST (? IN NATURAL LANGUAGE MODE)", req)
+		queries = append(queries, `
+		SELECT
+			'comment', comment.id, comment.post_id, post.title, comment.body, post.status, comment.account_id, comment.created_at,
+			MATCH(comment.body) AGAINST (? IN NATURAL LANGUAGE MODE) AS score
+		FROM
+			comment JOIN post ON post.id = comment.post_id
+		WHERE
+			`+where)
+		args = append(append(args, req.Query), whereArgs...)
+	}
+
+	var results []*model.SearchResult
+	rows, err := r.mysqlClient.Executor(ctx).QueryContext(ctx,
+		strings.Join(queries, " UNION ALL ")+`
+	ORDER BY
+		score DESC, created_at DESC
+	LIMIT
+		?
+	OFFSET
+		?
+	`, append(args, req.Limit, req.Offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		result := new(model.SearchResult)
+		err := rows.Scan(&result.Type, &result.ID, &result.PostID, &result.Title, &result.Body, &result.Status,
+			&result.AccountID, &result.CreatedAt, &result.Score)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
+
+// searchConditions filters the rows of table, only published posts and the
+// comments on them are searched.
+func searchConditions(table, match string, req model.SearchRequest) (string, []interface{}) {
+	conditions := []string{match, "post.status = ?"}
+	args := []interface{}{req.Query, model.PostStatusPublished}
+	if req.AccountID != 0 {
+		conditions = append(conditions, table+".account_id = ?")
+		args = append(args, req.AccountID)
+	}
+	if !req.From.IsZero() {
+		conditions = append(conditions, table+".created_at >= ?")
+		args = append(args, req.From)
+	}
+	if !req.To.IsZero() {
+		conditions = append(conditions, table+".created_at < ?")
+		args = append(args, req.To)
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+func (r *mysqlSearchIndex) Index(ctx context.Context, doc *model.SearchDocument) error {
+	return nil
+}
+
+func (r *mysqlSearchIndex) Remove(ctx context.Context, docType string, id int64) error {
+	return nil
+}
+
+func (r *mysqlSearchIndex) RemoveByAccountID(ctx context.Context, accountID int64) error {
+	return nil
+}
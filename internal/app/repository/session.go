@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/config"
+	"github.com/osamaesmail/go-post-api/internal/db/mysql"
+	"github.com/osamaesmail/go-post-api/internal/db/redis"
+)
+
+const sessionLastSeenKey = "session_last_seen"
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *model.Session) error
+	List(ctx context.Context, accountID int64, activeSince time.Time) ([]*model.Session, error)
+	Get(ctx context.Context, id int64) (*model.Session, error)
+	Revoke(ctx context.Context, id int64, revokedAt time.Time) error
+	IsRevoked(ctx context.Context, id int64) (bool, error)
+	Touch(ctx context.Context, id int64, t time.Time) error
+	FlushLastSeen(ctx context.Context) (int, error)
+	DeleteByAccountID(ctx context.Context, accountID int64) error
+}
+
+// NewSessionRepository keeps the sessions in mysql, revocations and last seen
+// times go through redis so authenticating a request never hits mysql.
+func NewSessionRepository(mysqlClient mysql.Client, redisClient redis.Client) SessionRepository {
+	return &sessionRepository{mysqlClient, redisClient}
+}
+
+type sessionRepository struct {
+	mysqlClient mysql.Client
+	redisClient redis.Client
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *model.Session) error {
+	res, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+	INSERT INTO
+		session (family_id, user_agent, ip, account_id, created_at)
+	VALUES
+		(?, ?, ?, ?, ?)
+	`, session.FamilyID, session.UserAgent, session.IP, session.AccountID, session.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	session.ID, err = res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	temp, err := r.Get(ctx, session.ID)
+	*session = *temp
+	return err
+}
+
+// List returns the sessions of the account that are not revoked and were seen
+// since activeSince, the most recently seen first.
+func (r *sessionRepository) List(ctx context.Context, accountID int64, activeSince time.Time) ([]*model.Session, error) {
+	var sessions []*model.Session
+	rows, err := r.mysqlClient.Executor(ctx).QueryContext(ctx, `
+	SELECT
+		id, family_id, user_agent, ip, last_seen_at, revoked_at, created_at, account_id
+	FROM
+		session
+	WHERE
+		account_id = ? AND revoked_at IS NULL AND COALESCE(last_seen_at, created_at) > ?
+	ORDER BY
+		COALESCE(last_seen_at, created_at) DESC
+	`, accountID, activeSince)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (r *sessionRepository) Get(ctx context.Context, id int64) (*model.Session, error) {
+	return scanSession(r.mysqlClient.Executor(ctx).QueryRowContext(ctx, `
+	SELECT
+		id, family_id, user_agent, ip, last_seen_at, revoked_at, created_at, account_id
+	FROM
+		session
+	WHERE
+		id = ?
+	`, id))
+}
+
+// Revoke returns sql.ErrNoRows when the session is already revoked. The
+// revocation is mirrored in redis for as long as an access token of the
+// session can live.
+func (r *sessionRepository) Revoke(ctx context.Context, id int64, revokedAt time.Time) error {
+	res, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+	UPDATE
+		session
+	SET
+		revoked_at = ?
+	WHERE
+		id = ? AND revoked_at IS NULL
+	`, revokedAt, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return r.redisClient.Conn().Set(ctx, fmt.Sprintf("session_revoked_%d", id), 1, config.Cfg().JwtTTL).Err()
+}
+
+func (r *sessionRepository) IsRevoked(ctx context.Context, id int64) (bool, error) {
+	n, err := r.redisClient.Conn().Exists(ctx, fmt.Sprintf("session_revoked_%d", id)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// Touch only records the last seen time in redis, FlushLastSeen writes the
+// recorded times to mysql in one batch.
+func (r *sessionRepository) Touch(ctx context.Context, id int64, t time.Time) error {
+	return r.redisClient.Conn().HSet(ctx, sessionLastSeenKey, id, t.Unix()).Err()
+}
+
+// FlushLastSeen writes the times recorded by Touch since the previous flush
+// and returns how many sessions it updated.
+func (r *sessionRepository) FlushLastSeen(ctx context.Context) (int, error) {
+	pipe := r.redisClient.Conn().TxPipeline()
+	getAll := pipe.HGetAll(ctx, sessionLastSeenKey)
+	pipe.Del(ctx, sessionLastSeenKey)
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for field, value := range getAll.Val() {
+		id, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			continue
+		}
+		unix, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		lastSeenAt := time.Unix(unix, 0)
+		_, err = r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+		UPDATE
+			session
+		SET
+			last_seen_at = ?
+		WHERE
+			id = ? AND (last_seen_at IS NULL OR last_seen_at < ?)
+		`, lastSeenAt, id, lastSeenAt)
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	return n, nil
+}
+
+func scanSession(row scanner) (*model.Session, error) {
+	session := new(model.Session)
+	err := row.Scan(&session.ID, &session.FamilyID, &session.UserAgent, &session.IP, &session.LastSeenAt,
+		&session.RevokedAt, &session.CreatedAt, &session.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (r *sessionRepository) DeleteByAccountID(ctx context.Context, accountID int64) error {
+	_, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+	DELETE FROM
+		session
+	WHERE
+		account_id = ?
+	`, accountID)
+	return err
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/db/mysql"
+	"github.com/osamaesmail/go-post-api/internal/db/redis"
+)
+
+// TagRepository manages the tags themselves, PostRepository sets the tags of a
+// post.
+type TagRepository interface {
+	List(ctx context.Context, limit, offset int) ([]*model.Tag, error)
+	GetByName(ctx context.Context, name string) (*model.Tag, error)
+	Rename(ctx context.Context, id int64, name string) error
+	Merge(ctx context.Context, id, intoID int64) error
+}
+
+func NewTagRepository(mysqlClient mysql.Client, redisClient redis.Client) TagRepository {
+	return &tagRepository{mysqlClient, redisClient}
+}
+
+type tagRepository struct {
+	mysqlClient mysql.Client
+	redisClient redis.Client
+}
+
+// List returns the tags of published posts, the most used first.
+func (r *tagRepository) List(ctx context.Context, limit, offset int) ([]*model.Tag, error) {
+	var tags []*model.Tag
+	rows, err := r.mysqlClient.Executor(ctx).QueryContext(ctx, `
+	SELECT
+		tag.id, tag.name, tag.created_at, COUNT(*)
+	FROM
+		tag
+		JOIN post_tag ON post_tag.tag_id = tag.id
+		JOIN post ON post.id = post_tag.post_id
+	WHERE
+		post.status = ?
+	GROUP BY
+		tag.id, tag.name, tag.created_at
+	ORDER BY
+		COUNT(*) DESC, tag.name
+	LIMIT
+		?
+	OFFSET
+		?
+	`, model.PostStatusPublished, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		tag := new(model.Tag)
+		err := rows.Scan(&tag.ID, &tag.Name, &tag.CreatedAt, &tag.PostCount)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+func (r *tagRepository) GetByName(ctx context.Context, name string) (*model.Tag, error) {
+	tag := new(model.Tag)
+	err := r.mysqlClient.Executor(ctx).QueryRowContext(ctx, `
+	SELECT
+		tag.id, tag.name, tag.created_at, (
+			SELECT COUNT(*)
+			FROM post_tag JOIN post ON post.id = post_tag.post_id
+			WHERE post_tag.tag_id = tag.id AND post.status = ?
+		)
+	FROM
+		tag
+	WHERE
+		tag.name = ?
+	`, model.PostStatusPublished, name,
+	).Scan(&tag.ID, &tag.Name, &tag.CreatedAt, &tag.PostCount)
+	if err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+func (r *tagRepository) Rename(ctx context.Context, id int64, name string) error {
+	_, err := r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+	UPDATE
+		tag
+	SET
+		name = ?
+	WHERE
+		id = ?
+	`, name, id)
+	if err != nil {
+		return err
+	}
+
+	return r.deletePostCache(ctx, id)
+}
+
+// Merge moves the posts of the tag over to the tag intoID and deletes it,
+// posts that had both keep one.
+func (r *tagRepository) Merge(ctx context.Context, id, intoID int64) error {
+	err := r.deletePostCache(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+	INSERT IGNORE INTO
+		post_tag (post_id, tag_id)
+	SELECT
+		post_id, ?
+	FROM
+		post_tag
+	WHERE
+		tag_id = ?
+	`, intoID, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+	DELETE FROM
+		post_tag
+	WHERE
+		tag_id = ?
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.mysqlClient.Executor(ctx).ExecContext(ctx, `
+	DELETE FROM
+		tag
+	WHERE
+		id = ?
+	`, id)
+	return err
+}
+
+// deletePostCache drops the cached posts using the tag, they hold its name.
+func (r *tagRepository) deletePostCache(ctx context.Context, id int64) error {
+	postIDs, err := queryIDs(ctx, r.mysqlClient, `
+	SELECT
+		post_id
+	FROM
+		post_tag
+	WHERE
+		tag_id = ?
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	return deleteCached(ctx, r.mysqlClient, r.redisClient, cacheKeys("post_%d", postIDs)...)
+}
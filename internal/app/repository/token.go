@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/config"
+	"github.com/osamaesmail/go-post-api/internal/db/redis"
+	"github.com/osamaesmail/go-post-api/internal/security/token"
+	"github.com/osamaesmail/go-post-api/internal/security/totp"
+)
+
+type TokenRepository interface {
+	CreateRefreshToken(ctx context.Context, refreshToken string, payload *model.RefreshToken) error
+	GetRefreshToken(ctx context.Context, refreshToken string) (*model.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, refreshToken string) (bool, error)
+	IsRefreshTokenFamilyActive(ctx context.Context, familyID string) (bool, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string) error
+	RevokeToken(ctx context.Context, tokenID string) error
+	IsTokenRevoked(ctx context.Context, tokenID string) (bool, error)
+	SetRevokedBefore(ctx context.Context, accountID int64, t time.Time) error
+	GetRevokedBefore(ctx context.Context, accountID int64) (time.Time, error)
+	CreateOneTimeToken(ctx context.Context, purpose, oneTimeToken string, payload interface{}, ttl time.Duration) error
+	GetOneTimeToken(ctx context.Context, purpose, oneTimeToken string, payload interface{}) error
+	ConsumeOneTimeToken(ctx context.Context, purpose, oneTimeToken string, payload interface{}) error
+	UseTOTPStep(ctx context.Context, accountID int64, step int64) (bool, error)
+}
+
+// NewTokenRepository stores token state straight in redis rather than through
+// the cache, the local cache layer would let revocations go unnoticed.
+func NewTokenRepository(redisClient redis.Client) TokenRepository {
+	return &tokenRepository{redisClient}
+}
+
+type tokenRepository struct {
+	redisClient redis.Client
+}
+
+func (r *tokenRepository) CreateRefreshToken(ctx context.Context, refreshToken string, payload *model.RefreshToken) error {
+	value, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	pipe := r.redisClient.Conn().TxPipeline()
+	pipe.Set(ctx, refreshTokenKey(refreshToken), value, config.Cfg().JwtRefreshTTL)
+	pipe.Set(ctx, refreshTokenFamilyKey(payload.FamilyID), payload.AccountID, config.Cfg().JwtRefreshTTL)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (r *tokenRepository) GetRefreshToken(ctx context.Context, refreshToken string) (*model.RefreshToken, error) {
+	value, err := r.redisClient.Conn().Get(ctx, refreshTokenKey(refreshToken)).Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	payload := new(model.RefreshToken)
+	err = json.Unmarshal(value, payload)
+	if err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// RotateRefreshToken marks the token as used, it reports false when the token
+// had already been rotated before.
+func (r *tokenRepository) RotateRefreshToken(ctx context.Context, refreshToken string) (bool, error) {
+	return r.redisClient.Conn().SetNX(ctx, refreshTokenKey(refreshToken)+"_rotated", 1, config.Cfg().JwtRefreshTTL).Result()
+}
+
+func (r *tokenRepository) IsRefreshTokenFamilyActive(ctx context.Context, familyID string) (bool, error) {
+	n, err := r.redisClient.Conn().Exists(ctx, refreshTokenFamilyKey(familyID)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (r *tokenRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	return r.redisClient.Conn().Del(ctx, refreshTokenFamilyKey(familyID)).Err()
+}
+
+// RevokeToken deny lists a single access token, the entry only has to outlive
+// the token itself.
+func (r *tokenRepository) RevokeToken(ctx context.Context, tokenID string) error {
+	return r.redisClient.Conn().Set(ctx, fmt.Sprintf("revoked_token_%s", tokenID), 1, config.Cfg().JwtTTL).Err()
+}
+
+func (r *tokenRepository) IsTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	n, err := r.redisClient.Conn().Exists(ctx, fmt.Sprintf("revoked_token_%s", tokenID)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// SetRevokedBefore invalidates every token of the account issued before t. The
+// watermark expires once the longest lived token issued before it would have.
+func (r *tokenRepository) SetRevokedBefore(ctx context.Context, accountID int64, t time.Time) error {
+	ttl := config.Cfg().JwtTTL
+	if config.Cfg().JwtRefreshTTL > ttl {
+		ttl = config.Cfg().JwtRefreshTTL
+	}
+	// kept to the millisecond like the iat_ms claim, so a login right after the
+	// revocation is not revoked along with the tokens before it
+	millis := t.UnixNano() / int64(time.Millisecond)
+	return r.redisClient.Conn().Set(ctx, fmt.Sprintf("revoked_before_%d", accountID), millis, ttl).Err()
+}
+
+// GetRevokedBefore returns the zero time when nothing was revoked.
+func (r *tokenRepository) GetRevokedBefore(ctx context.Context, accountID int64) (time.Time, error) {
+	millis, err := r.redisClient.Conn().Get(ctx, fmt.Sprintf("revoked_before_%d", accountID)).Int64()
+	if err == goredis.Nil {
+		return time.Time{}, nil
+	} else if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, millis*int64(time.Millisecond)), nil
+}
+
+// CreateOneTimeToken stores payload under the token for the given purpose, such
+// as a password reset, until it is consumed or expires.
+func (r *tokenRepository) CreateOneTimeToken(ctx context.Context, purpose, oneTimeToken string, payload interface{}, ttl time.Duration) error {
+	value, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return r.redisClient.Conn().Set(ctx, oneTimeTokenKey(purpose, oneTimeToken), value, ttl).Err()
+}
+
+// GetOneTimeToken reads the payload and leaves the token to be consumed later,
+// it returns redis.Nil for unknown or expired tokens.
+func (r *tokenRepository) GetOneTimeToken(ctx context.Context, purpose, oneTimeToken string, payload interface{}) error {
+	value, err := r.redisClient.Conn().Get(ctx, oneTimeTokenKey(purpose, oneTimeToken)).Bytes()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(value, payload)
+}
+
+// ConsumeOneTimeToken reads and deletes the token in one transaction so only
+// one caller ever gets the payload, the others get redis.Nil.
+func (r *tokenRepository) ConsumeOneTimeToken(ctx context.Context, purpose, oneTimeToken string, payload interface{}) error {
+	key := oneTimeTokenKey(purpose, oneTimeToken)
+
+	pipe := r.redisClient.Conn().TxPipeline()
+	get := pipe.Get(ctx, key)
+	pipe.Del(ctx, key)
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	value, err := get.Bytes()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(value, payload)
+}
+
+// UseTOTPStep reports false when a code of that time step was already used by
+// the account, the entry outlives every window the step is accepted in.
+func (r *tokenRepository) UseTOTPStep(ctx context.Context, accountID int64, step int64) (bool, error) {
+	ttl := time.Duration((2*totp.Skew+1)*totp.Period) * time.Second
+	return r.redisClient.Conn().SetNX(ctx, fmt.Sprintf("totp_step_%d_%d", accountID, step), 1, ttl).Result()
+}
+
+func oneTimeTokenKey(purpose, oneTimeToken string) string {
+	return fmt.Sprintf("%s_%s", purpose, token.HashOpaqueToken(oneTimeToken))
+}
+
+func refreshTokenKey(refreshToken string) string {
+	return fmt.Sprintf("refresh_token_%s", token.HashOpaqueToken(refreshToken))
+}
+
+func refreshTokenFamilyKey(familyID string) string {
+	return fmt.Sprintf("refresh_token_family_%s", familyID)
+}
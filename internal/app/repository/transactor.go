@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/osamaesmail/go-post-api/internal/db/mysql"
+)
+
+// Transactor lets services group writes of several repositories, the MySQL
+// repositories run on the transaction carried by ctx.
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+func NewTransactor(mysqlClient mysql.Client) Transactor {
+	return &transactor{mysqlClient}
+}
+
+type transactor struct {
+	mysqlClient mysql.Client
+}
+
+func (t *transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return t.mysqlClient.Transaction(ctx, fn)
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/app/repository"
+	"github.com/osamaesmail/go-post-api/internal/constant"
+	"github.com/osamaesmail/go-post-api/internal/logger"
+	"github.com/osamaesmail/go-post-api/internal/security/middleware"
+	"github.com/osamaesmail/go-post-api/internal/security/token"
+)
+
+type AccessTokenService interface {
+	Create(ctx context.Context, req model.AccessTokenCreateRequest) (*model.AccessTokenCreateResponse, error)
+	List(ctx context.Context, req model.AccessTokenListRequest) ([]*model.AccessTokenResponse, error)
+	Delete(ctx context.Context, req model.AccessTokenDeleteRequest) error
+}
+
+func NewAccessTokenService(accessTokenRepository repository.AccessTokenRepository, auditRepository repository.AuditRepository, transactor repository.Transactor) AccessTokenService {
+	return &accessTokenService{accessTokenRepository, auditRepository, transactor}
+}
+
+type accessTokenService struct {
+	accessTokenRepository repository.AccessTokenRepository
+	auditRepository       repository.AuditRepository
+	transactor            repository.Transactor
+}
+
+func (s *accessTokenService) Create(ctx context.Context, req model.AccessTokenCreateRequest) (*model.AccessTokenCreateResponse, error) {
+	// an access token must not be able to mint more access tokens
+	if !middleware.IsMe(ctx, req.AccountID) || middleware.IsAccessToken(ctx) {
+		return nil, constant.ErrUnauthorized
+	}
+
+	secret, err := token.GenerateOpaqueToken()
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to generate access token")
+		return nil, constant.ErrServer
+	}
+	plain := constant.ACCESS_TOKEN_PREFIX + secret
+
+	accessToken := &model.AccessToken{
+		Name:      req.Name,
+		TokenHash: token.HashOpaqueToken(plain),
+		Scopes:    req.Scopes,
+		CreatedAt: time.Now(),
+		AccountID: req.AccountID,
+	}
+	if req.ExpiresAt != nil {
+		accessToken.ExpiresAt = sql.NullTime{Time: *req.ExpiresAt, Valid: true}
+	}
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := s.accessTokenRepository.Create(ctx, accessToken)
+		if err != nil {
+			logger.Log().Err(err).Msg("failed to create access token")
+			return err
+		}
+
+		return recordAudit(ctx, s.auditRepository, model.AuditActionAccessTokenCreate, model.AuditTargetAccessToken, accessToken.ID,
+			nil, auditSnapshot(model.NewAccessTokenResponse(accessToken)))
+	})
+	if err != nil {
+		return nil, constant.ErrServer
+	}
+
+	return &model.AccessTokenCreateResponse{
+		AccessTokenResponse: *model.NewAccessTokenResponse(accessToken),
+		Token:               plain,
+	}, nil
+}
+
+func (s *accessTokenService) List(ctx context.Context, req model.AccessTokenListRequest) ([]*model.AccessTokenResponse, error) {
+	if !middleware.IsMe(ctx, req.AccountID) {
+		return nil, constant.ErrUnauthorized
+	}
+
+	accessTokens, err := s.accessTokenRepository.List(ctx, req.AccountID)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to list access tokens")
+		return nil, constant.ErrServer
+	}
+
+	return model.NewAccessTokenListResponse(accessTokens), nil
+}
+
+func (s *accessTokenService) Delete(ctx context.Context, req model.AccessTokenDeleteRequest) error {
+	if !middleware.IsMe(ctx, req.AccountID) {
+		return constant.ErrUnauthorized
+	}
+
+	accessToken, err := s.accessTokenRepository.Get(ctx, req.ID)
+	if err != nil {
+		return s.switchErrAccessTokenNotFoundOrErrServer(err)
+	} else if accessToken.AccountID != req.AccountID {
+		return constant.ErrAccessTokenNotFound
+	}
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := s.accessTokenRepository.Revoke(ctx, req.ID, time.Now())
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, s.auditRepository, model.AuditActionAccessTokenRevoke, model.AuditTargetAccessToken, req.ID, nil, nil)
+	})
+	if err != nil {
+		return s.switchErrAccessTokenNotFoundOrErrServer(err)
+	}
+
+	return nil
+}
+
+func (s *accessTokenService) switchErrAccessTokenNotFoundOrErrServer(err error) error {
+	switch err {
+	case sql.ErrNoRows:
+		return constant.ErrAccessTokenNotFound
+	default:
+		logger.Log().Err(err).Msg("failed to execute operation access token repository")
+		return constant.ErrServer
+	}
+}
@@ -3,35 +3,57 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
+	redis "github.com/go-redis/redis/v8"
 	"github.com/osamaesmail/go-post-api/internal/app/model"
 	"github.com/osamaesmail/go-post-api/internal/app/repository"
+	"github.com/osamaesmail/go-post-api/internal/config"
 	"github.com/osamaesmail/go-post-api/internal/constant"
 	"github.com/osamaesmail/go-post-api/internal/logger"
+	"github.com/osamaesmail/go-post-api/internal/mailer"
 	"github.com/osamaesmail/go-post-api/internal/security/middleware"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/osamaesmail/go-post-api/internal/security/password"
+	"github.com/osamaesmail/go-post-api/internal/security/token"
 )
 
 type AccountService interface {
 	Create(ctx context.Context, req model.AccountCreateRequest) (*model.AccountResponse, error)
 	List(ctx context.Context, req model.AccountListRequest) ([]*model.AccountResponse, error)
 	Get(ctx context.Context, req model.AccountGetRequest) (*model.AccountResponse, error)
+	GetByHandle(ctx context.Context, req model.AccountHandleGetRequest) (*model.AccountResponse, error)
 	Update(ctx context.Context, req model.AccountUpdateRequest) (*model.AccountResponse, error)
+	UpdateHandle(ctx context.Context, req model.AccountHandleUpdateRequest) (*model.AccountResponse, error)
 	UpdatePassword(ctx context.Context, req model.AccountPasswordUpdateRequest) (*model.AccountResponse, error)
+	ForgotPassword(ctx context.Context, req model.AccountPasswordForgotRequest) error
+	ResetPassword(ctx context.Context, req model.AccountPasswordResetRequest) error
+	UpdateRole(ctx context.Context, req model.AccountRoleUpdateRequest) (*model.AccountResponse, error)
+	Verify(ctx context.Context, req model.AccountVerifyRequest) (*model.AccountResponse, error)
+	ResendVerification(ctx context.Context, req model.AccountVerificationResendRequest) error
 	Delete(ctx context.Context, req model.AccountDeleteRequest) error
 }
 
-func NewAccountService(accountRepository repository.AccountRepository) AccountService {
-	return &accountService{accountRepository}
+func NewAccountService(accountRepository repository.AccountRepository, handleRepository repository.HandleRepository, tokenRepository repository.TokenRepository, auditRepository repository.AuditRepository, transactor repository.Transactor, passwordHasher password.PasswordHasher, passwordPolicy *password.Policy, mailer mailer.Mailer) AccountService {
+	return &accountService{accountRepository, handleRepository, tokenRepository, auditRepository, transactor, passwordHasher, passwordPolicy, mailer}
 }
 
 type accountService struct {
 	accountRepository repository.AccountRepository
+	handleRepository  repository.HandleRepository
+	tokenRepository   repository.TokenRepository
+	auditRepository   repository.AuditRepository
+	transactor        repository.Transactor
+	passwordHasher    password.PasswordHasher
+	passwordPolicy    *password.Policy
+	mailer            mailer.Mailer
 }
 
 func (s *accountService) Create(ctx context.Context, req model.AccountCreateRequest) (*model.AccountResponse, error) {
-	_, err := s.accountRepository.GetByEmail(ctx, req.Email)
+	// a soft deleted account keeps its email until it is purged
+	_, err := s.accountRepository.GetByEmailWithDeleted(ctx, req.Email, time.Time{})
 	if err != nil && err != sql.ErrNoRows {
 		logger.Log().Err(err).Msg("failed to get account by email")
 		return nil, constant.ErrServer
@@ -39,22 +61,51 @@ func (s *accountService) Create(ctx context.Context, req model.AccountCreateRequ
 		return nil, constant.ErrEmailRegistered
 	}
 
-	password, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	var handle sql.NullString
+	if req.Handle != "" {
+		handle = sql.NullString{String: strings.ToLower(req.Handle), Valid: true}
+		err = s.checkHandleAvailable(ctx, handle.String, 0)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.checkPasswordPolicy("password", req.Password, req.Name, req.Email)
 	if err != nil {
-		logger.Log().Err(err).Msg("failed to generate from password")
+		return nil, err
+	}
+
+	passwordHash, err := s.passwordHasher.Hash(req.Password)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to hash password")
 		return nil, constant.ErrServer
 	}
 
 	account := &model.Account{
 		Name:      req.Name,
+		Handle:    handle,
 		Email:     req.Email,
-		Password:  string(password),
+		Password:  passwordHash,
+		Role:      model.RoleUser,
 		CreatedAt: time.Now(),
 	}
 
-	err = s.accountRepository.Create(ctx, account)
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := s.accountRepository.Create(ctx, account)
+		if err != nil {
+			logger.Log().Err(err).Msg("failed to create account")
+			return err
+		}
+
+		return recordAudit(withAuditActor(ctx, account.ID), s.auditRepository, model.AuditActionAccountCreate, model.AuditTargetAccount, account.ID,
+			nil, auditSnapshot(model.NewAccountResponse(account)))
+	})
+	if err != nil {
+		return nil, constant.ErrServer
+	}
+
+	err = s.sendVerification(ctx, account, account.Email)
 	if err != nil {
-		logger.Log().Err(err).Msg("failed to create account")
 		return nil, constant.ErrServer
 	}
 
@@ -68,7 +119,11 @@ func (s *accountService) List(ctx context.Context, req model.AccountListRequest)
 		return nil, constant.ErrServer
 	}
 
-	return model.NewAccountListResponse(accounts), nil
+	res := make([]*model.AccountResponse, len(accounts))
+	for i, account := range accounts {
+		res[i] = accountResponse(ctx, account)
+	}
+	return res, nil
 }
 
 func (s *accountService) Get(ctx context.Context, req model.AccountGetRequest) (*model.AccountResponse, error) {
@@ -77,37 +132,160 @@ func (s *accountService) Get(ctx context.Context, req model.AccountGetRequest) (
 		return nil, s.switchErrAccountNotFoundOrErrServer(err)
 	}
 
-	return model.NewAccountResponse(account), nil
+	return accountResponse(ctx, account), nil
+}
+
+// GetByHandle also finds the account by a handle it used before, the response
+// then carries the current handle to redirect to.
+func (s *accountService) GetByHandle(ctx context.Context, req model.AccountHandleGetRequest) (*model.AccountResponse, error) {
+	handle := strings.ToLower(req.Handle)
+	account, err := s.accountRepository.GetByHandle(ctx, handle)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, s.switchErrAccountNotFoundOrErrServer(err)
+	} else if err == nil {
+		return accountResponse(ctx, account), nil
+	}
+
+	previous, err := s.handleRepository.GetByHandle(ctx, handle)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, constant.ErrAccountNotFound
+		default:
+			logger.Log().Err(err).Msg("failed to get previous handle")
+			return nil, constant.ErrServer
+		}
+	}
+
+	account, err = s.accountRepository.Get(ctx, previous.AccountID)
+	if err != nil {
+		return nil, s.switchErrAccountNotFoundOrErrServer(err)
+	}
+
+	return accountResponse(ctx, account), nil
 }
 
 func (s *accountService) Update(ctx context.Context, req model.AccountUpdateRequest) (*model.AccountResponse, error) {
-	if !middleware.IsMe(ctx, req.ID) {
+	account, err := s.accountRepository.Get(ctx, req.ID)
+	if err != nil {
+		return nil, s.switchErrAccountNotFoundOrErrServer(err)
+	}
+
+	override := !middleware.IsMe(ctx, req.ID)
+	if override && !canManage(ctx, account) {
 		return nil, constant.ErrUnauthorized
 	}
 
-	account, err := s.accountRepository.GetByEmail(ctx, req.Email)
+	existing, err := s.accountRepository.GetByEmailWithDeleted(ctx, req.Email, time.Time{})
 	if err != nil && err != sql.ErrNoRows {
 		logger.Log().Err(err).Msg("failed to get account by email")
 		return nil, constant.ErrServer
-	} else if err == nil && account.ID != req.ID {
+	} else if err == nil && existing.ID != req.ID {
 		return nil, constant.ErrEmailRegistered
 	}
 
-	account, err = s.accountRepository.Get(ctx, req.ID)
+	if isOtherAvatarURL(req.AvatarURL, account.ID) {
+		return nil, constant.ErrAvatarURLNotOwn
+	}
+
+	before := auditSnapshot(model.NewAccountResponse(account))
+
+	// a new email only replaces the current one once it is verified
+	changeEmail := req.Email != account.Email && req.Email != account.PendingEmail.String
+	if req.Email == account.Email {
+		account.PendingEmail = sql.NullString{}
+	} else {
+		account.PendingEmail = sql.NullString{String: req.Email, Valid: true}
+	}
+
+	account.Name = req.Name
+	account.Bio = req.Bio
+	account.Website = req.Website
+	account.AvatarURL = req.AvatarURL
+	account.UpdatedAt.Time = time.Now()
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := s.accountRepository.Update(ctx, account)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, s.auditRepository, model.AuditActionAccountUpdate, model.AuditTargetAccount, account.ID,
+			before, auditSnapshot(model.NewAccountResponse(account)))
+	})
+	if err != nil {
+		return nil, s.switchErrAccountNotFoundOrErrServer(err)
+	}
+
+	if changeEmail {
+		err = s.sendVerification(ctx, account, req.Email)
+		if err != nil {
+			return nil, constant.ErrServer
+		}
+	}
+
+	return accountResponse(ctx, account), nil
+}
+
+// UpdateHandle keeps the previous handle for the account, links to it redirect
+// to the new one and the account may take it back later.
+func (s *accountService) UpdateHandle(ctx context.Context, req model.AccountHandleUpdateRequest) (*model.AccountResponse, error) {
+	account, err := s.accountRepository.Get(ctx, req.ID)
 	if err != nil {
 		return nil, s.switchErrAccountNotFoundOrErrServer(err)
 	}
 
-	account.Name = req.Name
-	account.Email = req.Email
+	override := !middleware.IsMe(ctx, req.ID)
+	if override && !canManage(ctx, account) {
+		return nil, constant.ErrUnauthorized
+	}
+
+	handle := strings.ToLower(req.Handle)
+	if account.Handle.Valid && account.Handle.String == handle {
+		return accountResponse(ctx, account), nil
+	}
+
+	err = s.checkHandleAvailable(ctx, handle, account.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	before := auditSnapshot(model.NewAccountResponse(account))
+	previous := account.Handle
+
+	account.Handle = sql.NullString{String: handle, Valid: true}
 	account.UpdatedAt.Time = time.Now()
 
-	err = s.accountRepository.Update(ctx, account)
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := s.handleRepository.DeleteByHandle(ctx, handle)
+		if err != nil {
+			return err
+		}
+
+		if previous.Valid {
+			err = s.handleRepository.Create(ctx, &model.AccountHandle{
+				Handle:    previous.String,
+				CreatedAt: time.Now(),
+				AccountID: account.ID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		err = s.accountRepository.Update(ctx, account)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, s.auditRepository, model.AuditActionAccountHandleUpdate, model.AuditTargetAccount, account.ID,
+			before, auditSnapshot(model.NewAccountResponse(account)))
+	})
 	if err != nil {
 		return nil, s.switchErrAccountNotFoundOrErrServer(err)
 	}
 
-	return model.NewAccountResponse(account), nil
+	return accountResponse(ctx, account), nil
 }
 
 func (s *accountService) UpdatePassword(ctx context.Context, req model.AccountPasswordUpdateRequest) (*model.AccountResponse, error) {
@@ -120,41 +298,404 @@ func (s *accountService) UpdatePassword(ctx context.Context, req model.AccountPa
 		return nil, s.switchErrAccountNotFoundOrErrServer(err)
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(account.Password), []byte(req.OldPassword))
+	err = s.passwordHasher.Verify(req.OldPassword, account.Password)
 	if err != nil {
 		return nil, constant.ErrWrongPassword
 	}
 
-	password, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	err = s.checkPasswordPolicy("new_password", req.NewPassword, account.Name, account.Email)
 	if err != nil {
-		logger.Log().Err(err).Msg("failed to generate from password")
+		return nil, err
+	}
+
+	passwordHash, err := s.passwordHasher.Hash(req.NewPassword)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to hash password")
 		return nil, constant.ErrServer
 	}
 
-	account.Password = string(password)
+	account.Password = passwordHash
 	account.UpdatedAt.Time = time.Now()
 
-	err = s.accountRepository.Update(ctx, account)
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := s.accountRepository.Update(ctx, account)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, s.auditRepository, model.AuditActionAccountPasswordUpdate, model.AuditTargetAccount, account.ID, nil, nil)
+	})
 	if err != nil {
 		return nil, s.switchErrAccountNotFoundOrErrServer(err)
 	}
 
+	err = s.tokenRepository.SetRevokedBefore(ctx, account.ID, time.Now())
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to revoke account tokens")
+		return nil, constant.ErrServer
+	}
+
 	return model.NewAccountResponse(account), nil
 }
 
-func (s *accountService) Delete(ctx context.Context, req model.AccountDeleteRequest) error {
+// ForgotPassword never tells whether the email is registered, the mail is sent
+// in the background so the response time does not tell either.
+func (s *accountService) ForgotPassword(ctx context.Context, req model.AccountPasswordForgotRequest) error {
+	account, err := s.accountRepository.GetByEmail(ctx, req.Email)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Log().Err(err).Msg("failed to get account by email")
+		return constant.ErrServer
+	} else if err == sql.ErrNoRows {
+		return nil
+	}
+
+	resetToken, err := token.GenerateOpaqueToken()
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to generate password reset token")
+		return constant.ErrServer
+	}
+
+	err = s.tokenRepository.CreateOneTimeToken(ctx, model.OneTimeTokenPasswordReset, resetToken,
+		&model.PasswordResetToken{AccountID: account.ID}, config.Cfg().PasswordResetTTL)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to create password reset token")
+		return constant.ErrServer
+	}
+
+	msg := mailer.Message{
+		To:      account.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to choose a new password, it expires in %s.\n\n%s?token=%s\n\n"+
+			"If you did not ask for a password reset you can ignore this email.\n",
+			account.Name, config.Cfg().PasswordResetTTL, config.Cfg().PasswordResetURL, resetToken),
+	}
+	go func() {
+		err := s.mailer.Send(context.Background(), msg)
+		if err != nil {
+			logger.Log().Err(err).Int64("account_id", account.ID).Msg("failed to send password reset email")
+		}
+	}()
+
+	return nil
+}
+
+// ResetPassword redeems a reset token, every session of the account issued
+// before is revoked. The token is only consumed once the new password meets
+// the policy, so a rejected one can be retried.
+func (s *accountService) ResetPassword(ctx context.Context, req model.AccountPasswordResetRequest) error {
+	var resetToken model.PasswordResetToken
+	err := s.tokenRepository.GetOneTimeToken(ctx, model.OneTimeTokenPasswordReset, req.Token, &resetToken)
+	if err != nil {
+		switch err {
+		case redis.Nil:
+			return constant.ErrInvalidResetToken
+		default:
+			logger.Log().Err(err).Msg("failed to get password reset token")
+			return constant.ErrServer
+		}
+	}
+
+	account, err := s.accountRepository.Get(ctx, resetToken.AccountID)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return constant.ErrInvalidResetToken
+		default:
+			return s.switchErrAccountNotFoundOrErrServer(err)
+		}
+	}
+
+	err = s.checkPasswordPolicy("password", req.Password, account.Name, account.Email)
+	if err != nil {
+		return err
+	}
+
+	err = s.tokenRepository.ConsumeOneTimeToken(ctx, model.OneTimeTokenPasswordReset, req.Token, &resetToken)
+	if err != nil {
+		switch err {
+		case redis.Nil:
+			return constant.ErrInvalidResetToken
+		default:
+			logger.Log().Err(err).Msg("failed to consume password reset token")
+			return constant.ErrServer
+		}
+	}
+
+	passwordHash, err := s.passwordHasher.Hash(req.Password)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to hash password")
+		return constant.ErrServer
+	}
+
+	account.Password = passwordHash
+	account.UpdatedAt.Time = time.Now()
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := s.accountRepository.Update(ctx, account)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(withAuditActor(ctx, account.ID), s.auditRepository, model.AuditActionAccountPasswordReset, model.AuditTargetAccount, account.ID, nil, nil)
+	})
+	if err != nil {
+		return s.switchErrAccountNotFoundOrErrServer(err)
+	}
+
+	err = s.tokenRepository.SetRevokedBefore(ctx, account.ID, time.Now())
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to revoke account tokens")
+		return constant.ErrServer
+	}
+
+	return nil
+}
+
+func (s *accountService) UpdateRole(ctx context.Context, req model.AccountRoleUpdateRequest) (*model.AccountResponse, error) {
+	if !middleware.HasRole(ctx, model.RoleAdmin) {
+		return nil, constant.ErrForbidden
+	}
+
+	account, err := s.accountRepository.Get(ctx, req.ID)
+	if err != nil {
+		return nil, s.switchErrAccountNotFoundOrErrServer(err)
+	}
+
+	before := auditSnapshot(model.NewAccountResponse(account))
+
+	account.Role = req.Role
+	account.UpdatedAt.Time = time.Now()
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := s.accountRepository.Update(ctx, account)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, s.auditRepository, model.AuditActionAccountRoleUpdate, model.AuditTargetAccount, account.ID,
+			before, auditSnapshot(model.NewAccountResponse(account)))
+	})
+	if err != nil {
+		return nil, s.switchErrAccountNotFoundOrErrServer(err)
+	}
+
+	// issued tokens carry the previous role
+	err = s.tokenRepository.SetRevokedBefore(ctx, account.ID, time.Now())
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to revoke account tokens")
+		return nil, constant.ErrServer
+	}
+
+	return model.NewAccountResponse(account), nil
+}
+
+// Verify redeems a verification token. It either confirms the current email
+// or swaps in the pending one, a token for any other email is stale.
+func (s *accountService) Verify(ctx context.Context, req model.AccountVerifyRequest) (*model.AccountResponse, error) {
+	var verificationToken model.EmailVerificationToken
+	err := s.tokenRepository.ConsumeOneTimeToken(ctx, model.OneTimeTokenEmailVerification, req.Token, &verificationToken)
+	if err != nil {
+		switch err {
+		case redis.Nil:
+			return nil, constant.ErrInvalidVerifyToken
+		default:
+			logger.Log().Err(err).Msg("failed to consume email verification token")
+			return nil, constant.ErrServer
+		}
+	}
+
+	account, err := s.accountRepository.Get(ctx, verificationToken.AccountID)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, constant.ErrInvalidVerifyToken
+		default:
+			return nil, s.switchErrAccountNotFoundOrErrServer(err)
+		}
+	}
+
+	before := auditSnapshot(model.NewAccountResponse(account))
+
+	switch verificationToken.Email {
+	case account.Email:
+	case account.PendingEmail.String:
+		existing, err := s.accountRepository.GetByEmailWithDeleted(ctx, verificationToken.Email, time.Time{})
+		if err != nil && err != sql.ErrNoRows {
+			logger.Log().Err(err).Msg("failed to get account by email")
+			return nil, constant.ErrServer
+		} else if err == nil && existing.ID != account.ID {
+			return nil, constant.ErrEmailRegistered
+		}
+
+		account.Email = verificationToken.Email
+		account.PendingEmail = sql.NullString{}
+	default:
+		return nil, constant.ErrInvalidVerifyToken
+	}
+
+	account.EmailVerifiedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	account.UpdatedAt.Time = time.Now()
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := s.accountRepository.Update(ctx, account)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(withAuditActor(ctx, account.ID), s.auditRepository, model.AuditActionAccountEmailVerify, model.AuditTargetAccount, account.ID,
+			before, auditSnapshot(model.NewAccountResponse(account)))
+	})
+	if err != nil {
+		return nil, s.switchErrAccountNotFoundOrErrServer(err)
+	}
+
+	return model.NewAccountResponse(account), nil
+}
+
+// ResendVerification sends a new token for the pending email, or for the
+// current one while it is not verified yet.
+func (s *accountService) ResendVerification(ctx context.Context, req model.AccountVerificationResendRequest) error {
 	if !middleware.IsMe(ctx, req.ID) {
 		return constant.ErrUnauthorized
 	}
 
-	err := s.accountRepository.Delete(ctx, req.ID)
+	account, err := s.accountRepository.Get(ctx, req.ID)
+	if err != nil {
+		return s.switchErrAccountNotFoundOrErrServer(err)
+	}
+
+	email := account.Email
+	if account.PendingEmail.Valid {
+		email = account.PendingEmail.String
+	} else if account.EmailVerifiedAt.Valid {
+		return constant.ErrEmailVerified
+	}
+
+	err = s.sendVerification(ctx, account, email)
+	if err != nil {
+		return constant.ErrServer
+	}
+	return nil
+}
+
+func (s *accountService) Delete(ctx context.Context, req model.AccountDeleteRequest) error {
+	account, err := s.accountRepository.Get(ctx, req.ID)
+	if err != nil {
+		return s.switchErrAccountNotFoundOrErrServer(err)
+	}
+
+	override := !middleware.IsMe(ctx, req.ID)
+	if override && !canManage(ctx, account) {
+		return constant.ErrUnauthorized
+	}
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := s.accountRepository.Delete(ctx, req.ID, time.Now())
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, s.auditRepository, model.AuditActionAccountDelete, model.AuditTargetAccount, req.ID,
+			auditSnapshot(model.NewAccountResponse(account)), nil)
+	})
 	if err != nil {
 		return s.switchErrAccountNotFoundOrErrServer(err)
 	}
 
+	err = s.tokenRepository.SetRevokedBefore(ctx, req.ID, time.Now())
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to revoke account tokens")
+		return constant.ErrServer
+	}
+
+	return nil
+}
+
+// sendVerification mails a verification token for email to that address, the
+// mail is sent in the background like the password reset one.
+func (s *accountService) sendVerification(ctx context.Context, account *model.Account, email string) error {
+	verificationToken, err := token.GenerateOpaqueToken()
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to generate email verification token")
+		return err
+	}
+
+	err = s.tokenRepository.CreateOneTimeToken(ctx, model.OneTimeTokenEmailVerification, verificationToken,
+		&model.EmailVerificationToken{AccountID: account.ID, Email: email}, config.Cfg().EmailVerificationTTL)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to create email verification token")
+		return err
+	}
+
+	msg := mailer.Message{
+		To:      email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to verify your email, it expires in %s.\n\n%s/v1/accounts/verify?token=%s\n\n"+
+			"If you did not ask for this you can ignore this email.\n",
+			account.Name, config.Cfg().EmailVerificationTTL, config.Cfg().AppBaseURL, verificationToken),
+	}
+	go func() {
+		err := s.mailer.Send(context.Background(), msg)
+		if err != nil {
+			logger.Log().Err(err).Int64("account_id", account.ID).Msg("failed to send email verification email")
+		}
+	}()
+
+	return nil
+}
+
+// checkPasswordPolicy passes a *password.PolicyError through so the handler
+// can answer with the reasons.
+func (s *accountService) checkPasswordPolicy(field, plain string, userInputs ...string) error {
+	err := s.passwordPolicy.Check(field, plain, userInputs...)
+	if err != nil && !errors.Is(err, constant.ErrPasswordPolicy) {
+		logger.Log().Err(err).Msg("failed to check password policy")
+		return constant.ErrServer
+	}
+	return err
+}
+
+// checkHandleAvailable refuses a handle held by another account, even a soft
+// deleted one, or used by another account before. accountID is the account
+// taking it, 0 for a new one.
+func (s *accountService) checkHandleAvailable(ctx context.Context, handle string, accountID int64) error {
+	existing, err := s.accountRepository.GetByHandleWithDeleted(ctx, handle, time.Time{})
+	if err != nil && err != sql.ErrNoRows {
+		logger.Log().Err(err).Msg("failed to get account by handle")
+		return constant.ErrServer
+	} else if err == nil && existing.ID != accountID {
+		return constant.ErrHandleTaken
+	}
+
+	previous, err := s.handleRepository.GetByHandle(ctx, handle)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Log().Err(err).Msg("failed to get previous handle")
+		return constant.ErrServer
+	} else if err == nil && previous.AccountID != accountID {
+		return constant.ErrHandleTaken
+	}
 	return nil
 }
 
+// accountResponse shows the private fields to the account itself and to
+// admins, unless they read with an access token lacking the accounts:read
+// scope.
+func accountResponse(ctx context.Context, account *model.Account) *model.AccountResponse {
+	if (middleware.IsMe(ctx, account.ID) || middleware.HasRole(ctx, model.RoleAdmin)) && middleware.HasScope(ctx, model.ScopeAccountsRead) {
+		return model.NewAccountResponse(account)
+	}
+	return model.NewAccountPublicResponse(account)
+}
+
+// canManage reports whether the caller may change an account it does not own,
+// moderators only get to manage regular users.
+func canManage(ctx context.Context, account *model.Account) bool {
+	if middleware.HasRole(ctx, model.RoleAdmin) {
+		return true
+	}
+	return middleware.HasRole(ctx, model.RoleModerator) && account.Role == model.RoleUser
+}
+
 func (s *accountService) switchErrAccountNotFoundOrErrServer(err error) error {
 	switch err {
 	case sql.ErrNoRows:
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/app/repository"
+	"github.com/osamaesmail/go-post-api/internal/config"
+	"github.com/osamaesmail/go-post-api/internal/logger"
+	"github.com/osamaesmail/go-post-api/internal/storage"
+)
+
+// accountPurgeBatch is how many accounts a purge handles per query.
+const accountPurgeBatch = 100
+
+type AccountPurgeService interface {
+	Purge(ctx context.Context) (int, error)
+}
+
+func NewAccountPurgeService(accountRepository repository.AccountRepository, handleRepository repository.HandleRepository, postRepository repository.PostRepository, commentRepository repository.CommentRepository, sessionRepository repository.SessionRepository, accessTokenRepository repository.AccessTokenRepository, recoveryCodeRepository repository.RecoveryCodeRepository, identityRepository repository.IdentityRepository, exportJobRepository repository.ExportJobRepository, auditRepository repository.AuditRepository, transactor repository.Transactor, fileStorage storage.Storage, searchIndex repository.SearchIndex) AccountPurgeService {
+	return &accountPurgeService{accountRepository, handleRepository, postRepository, commentRepository, sessionRepository, accessTokenRepository, recoveryCodeRepository, identityRepository, exportJobRepository, auditRepository, transactor, fileStorage, searchIndex}
+}
+
+type accountPurgeService struct {
+	accountRepository      repository.AccountRepository
+	handleRepository       repository.HandleRepository
+	postRepository         repository.PostRepository
+	commentRepository      repository.CommentRepository
+	sessionRepository      repository.SessionRepository
+	accessTokenRepository  repository.AccessTokenRepository
+	recoveryCodeRepository repository.RecoveryCodeRepository
+	identityRepository     repository.IdentityRepository
+	exportJobRepository    repository.ExportJobRepository
+	auditRepository        repository.AuditRepository
+	transactor             repository.Transactor
+	fileStorage            storage.Storage
+	searchIndex            repository.SearchIndex
+}
+
+// restorableSince is when an account must have been deleted for a login to
+// still restore it.
+func restorableSince() time.Time {
+	return time.Now().Add(-config.Cfg().AccountDeleteGrace)
+}
+
+// Purge finishes the deletion of the accounts whose grace period is over and
+// returns how many it purged. With ACCOUNT_PURGE_POLICY anonymize their posts
+// and comments stay under an anonymous account, with delete they go too.
+func (s *accountPurgeService) Purge(ctx context.Context) (int, error) {
+	purged := 0
+	for {
+		accounts, err := s.accountRepository.ListPurgeable(ctx, restorableSince(), accountPurgeBatch)
+		if err != nil {
+			logger.Log().Err(err).Msg("failed to list purgeable accounts")
+			return purged, err
+		}
+
+		for _, account := range accounts {
+			err = s.purge(ctx, account)
+			if err != nil {
+				return purged, err
+			}
+			purged++
+		}
+
+		if len(accounts) < accountPurgeBatch {
+			return purged, nil
+		}
+	}
+}
+
+func (s *accountPurgeService) purge(ctx context.Context, account *model.Account) error {
+	policy := config.Cfg().AccountPurgePolicy
+
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		for _, deleteByAccountID := range []func(ctx context.Context, accountID int64) error{
+			s.exportJobRepository.DeleteByAccountID,
+			s.handleRepository.DeleteByAccountID,
+			s.identityRepository.DeleteByAccountID,
+			s.recoveryCodeRepository.DeleteByAccountID,
+			s.accessTokenRepository.DeleteByAccountID,
+			s.sessionRepository.DeleteByAccountID,
+		} {
+			err := deleteByAccountID(ctx, account.ID)
+			if err != nil {
+				logger.Log().Err(err).Int64("account_id", account.ID).Msg("failed to delete account data")
+				return err
+			}
+		}
+
+		var err error
+		switch policy {
+		case model.AccountPurgeDelete:
+			err = s.commentRepository.DeleteByAccountID(ctx, account.ID)
+			if err == nil {
+				err = s.postRepository.DeleteByAccountID(ctx, account.ID)
+			}
+			if err == nil {
+				err = s.accountRepository.Purge(ctx, account.ID)
+			}
+		default:
+			err = s.accountRepository.Anonymize(ctx, account.ID, time.Now())
+		}
+		if err != nil {
+			logger.Log().Err(err).Int64("account_id", account.ID).Str("policy", policy).Msg("failed to purge account")
+			return err
+		}
+
+		return recordAudit(ctx, s.auditRepository, model.AuditActionAccountPurge, model.AuditTargetAccount, account.ID,
+			nil, auditSnapshot(map[string]string{"policy": policy}))
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.fileStorage.DeleteAll(ctx, exportPrefix(account.ID))
+	if err != nil {
+		logger.Log().Err(err).Int64("account_id", account.ID).Msg("failed to remove export archives")
+		return err
+	}
+
+	err = s.fileStorage.DeleteAll(ctx, avatarPrefix(account.ID))
+	if err != nil {
+		logger.Log().Err(err).Int64("account_id", account.ID).Msg("failed to delete avatar")
+		return err
+	}
+
+	if policy == model.AccountPurgeDelete {
+		err = s.searchIndex.RemoveByAccountID(ctx, account.ID)
+		if err != nil {
+			logger.Log().Err(err).Int64("account_id", account.ID).Msg("failed to remove search documents")
+			return err
+		}
+	}
+	return nil
+}
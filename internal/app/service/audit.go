@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/app/repository"
+	"github.com/osamaesmail/go-post-api/internal/constant"
+	"github.com/osamaesmail/go-post-api/internal/logger"
+	"github.com/osamaesmail/go-post-api/internal/security/middleware"
+)
+
+type AuditService interface {
+	List(ctx context.Context, req model.AuditListRequest) (*model.AuditListResponse, error)
+}
+
+func NewAuditService(auditRepository repository.AuditRepository) AuditService {
+	return &auditService{auditRepository}
+}
+
+type auditService struct {
+	auditRepository repository.AuditRepository
+}
+
+// List pages through the events newest first, the cursor is the id of the
+// last event of the previous page.
+func (s *auditService) List(ctx context.Context, req model.AuditListRequest) (*model.AuditListResponse, error) {
+	if !middleware.HasRole(ctx, model.RoleAdmin) {
+		return nil, constant.ErrForbidden
+	}
+
+	filter := model.AuditFilter{
+		ActorID:    req.ActorID,
+		Action:     req.Action,
+		TargetType: req.TargetType,
+		TargetID:   req.TargetID,
+		Limit:      req.Limit + 1,
+	}
+	if req.Cursor != "" {
+		beforeID, err := decodeAuditCursor(req.Cursor)
+		if err != nil {
+			return nil, constant.ErrUrlQueryParameter
+		}
+		filter.BeforeID = beforeID
+	}
+
+	events, err := s.auditRepository.List(ctx, filter)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to list audit events")
+		return nil, constant.ErrServer
+	}
+
+	var nextCursor string
+	if len(events) > req.Limit {
+		events = events[:req.Limit]
+		nextCursor = encodeAuditCursor(events[len(events)-1].ID)
+	}
+
+	return model.NewAuditListResponse(events, nextCursor), nil
+}
+
+func encodeAuditCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+func decodeAuditCursor(cursor string) (int64, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(string(b), 10, 64)
+}
+
+type auditActorKey struct{}
+
+// withAuditActor names the actor for requests without claims, like a login or
+// a password reset, where the account is only known after the fact.
+func withAuditActor(ctx context.Context, accountID int64) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, accountID)
+}
+
+// auditSnapshot is the state of a target as it goes in the diff, it has to be
+// taken before the target changes. Pass the response model so secrets such as
+// password hashes stay out of the log.
+func auditSnapshot(v interface{}) json.RawMessage {
+	b, err := json.Marshal(v)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to marshal audit snapshot")
+		return nil
+	}
+	return b
+}
+
+// recordAudit stores who performed action on the target along with what
+// changed between the before and after snapshots, an impersonating admin is
+// the actor rather than the impersonated account. Call it in the transaction
+// of the change so the log cannot drift from the data.
+func recordAudit(ctx context.Context, auditRepository repository.AuditRepository, action, targetType string, targetID int64, before, after json.RawMessage) error {
+	diff, err := model.NewAuditDiff(before, after)
+	if err != nil {
+		logger.Log().Err(err).Str("action", action).Int64("target_id", targetID).Msg("failed to diff audit snapshots")
+		return err
+	}
+
+	event := &model.AuditEvent{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Diff:       diff,
+		CreatedAt:  time.Now(),
+	}
+
+	if claimsActorID, valid := middleware.GetClaimsActorID(ctx); valid {
+		event.ActorID = sql.NullInt64{Int64: claimsActorID, Valid: true}
+	} else if claimsID, valid := middleware.GetClaimsID(ctx); valid {
+		event.ActorID = sql.NullInt64{Int64: claimsID, Valid: true}
+	} else if actorID, valid := ctx.Value(auditActorKey{}).(int64); valid {
+		event.ActorID = sql.NullInt64{Int64: actorID, Valid: true}
+	}
+	if requestIP, valid := middleware.GetRequestIP(ctx); valid {
+		event.IP = sql.NullString{String: requestIP, Valid: true}
+	}
+	if requestID, valid := middleware.GetRequestID(ctx); valid {
+		event.RequestID = sql.NullString{String: requestID, Valid: true}
+	}
+
+	err = auditRepository.Create(ctx, event)
+	if err != nil {
+		logger.Log().Err(err).Str("action", action).Int64("target_id", targetID).Msg("failed to record audit event")
+	}
+	return err
+}
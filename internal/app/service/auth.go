@@ -3,49 +3,433 @@ package service
 import (
 	"context"
 	"database/sql"
+	"strings"
+	"time"
 
+	redis "github.com/go-redis/redis/v8"
 	"github.com/osamaesmail/go-post-api/internal/app/model"
 	"github.com/osamaesmail/go-post-api/internal/app/repository"
+	"github.com/osamaesmail/go-post-api/internal/config"
 	"github.com/osamaesmail/go-post-api/internal/constant"
 	"github.com/osamaesmail/go-post-api/internal/logger"
+	"github.com/osamaesmail/go-post-api/internal/security/middleware"
+	"github.com/osamaesmail/go-post-api/internal/security/oidc"
+	"github.com/osamaesmail/go-post-api/internal/security/password"
 	"github.com/osamaesmail/go-post-api/internal/security/token"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/osamaesmail/go-post-api/internal/security/totp"
 )
 
 type AuthService interface {
 	Login(ctx context.Context, req model.AuthRequest) (*model.AuthResponse, error)
+	LoginTOTP(ctx context.Context, req model.AuthTOTPRequest) (*model.AuthResponse, error)
+	Refresh(ctx context.Context, req model.AuthRefreshRequest) (*model.AuthResponse, error)
+	Logout(ctx context.Context, req model.AuthLogoutRequest) error
+	JWKS(ctx context.Context) (*model.JWKSResponse, error)
+	Impersonate(ctx context.Context, req model.ImpersonateRequest) (*model.ImpersonateResponse, error)
+	OauthStart(ctx context.Context, req model.OauthStartRequest) (string, error)
+	OauthCallback(ctx context.Context, req model.OauthCallbackRequest) (*model.AuthResponse, error)
 }
 
-func NewAuthService(accountRepository repository.AccountRepository) AuthService {
-	return &authService{accountRepository}
+func NewAuthService(accountRepository repository.AccountRepository, tokenRepository repository.TokenRepository, sessionRepository repository.SessionRepository, recoveryCodeRepository repository.RecoveryCodeRepository, loginAttemptRepository repository.LoginAttemptRepository, identityRepository repository.IdentityRepository, auditRepository repository.AuditRepository, transactor repository.Transactor, passwordHasher password.PasswordHasher, oauthProviders oidc.Providers) AuthService {
+	// compared against when the email is not registered, so the response time
+	// does not tell registered emails apart
+	dummyPasswordHash, err := passwordHasher.Hash("dummy password")
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to hash dummy password")
+	}
+
+	return &authService{accountRepository, tokenRepository, sessionRepository, recoveryCodeRepository, loginAttemptRepository, identityRepository, auditRepository, transactor, passwordHasher, oauthProviders, dummyPasswordHash}
 }
 
+// maxUserAgentLength is the size of the session.user_agent column, in
+// characters.
+const maxUserAgentLength = 512
+
 type authService struct {
-	accountRepository repository.AccountRepository
+	accountRepository      repository.AccountRepository
+	tokenRepository        repository.TokenRepository
+	sessionRepository      repository.SessionRepository
+	recoveryCodeRepository repository.RecoveryCodeRepository
+	loginAttemptRepository repository.LoginAttemptRepository
+	identityRepository     repository.IdentityRepository
+	auditRepository        repository.AuditRepository
+	transactor             repository.Transactor
+	passwordHasher         password.PasswordHasher
+	oauthProviders         oidc.Providers
+	dummyPasswordHash      string
 }
 
+// Login answers ErrInvalidCredentials for unknown emails and wrong passwords
+// alike, failures count towards a lockout of the email and of the ip.
 func (s *authService) Login(ctx context.Context, req model.AuthRequest) (*model.AuthResponse, error) {
-	account, err := s.accountRepository.GetByEmail(ctx, req.Email)
+	err := s.checkLockout(ctx, req.Email, req.IP)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.accountRepository.GetByEmailWithDeleted(ctx, req.Email, restorableSince())
+	if err != nil && err != sql.ErrNoRows {
+		logger.Log().Err(err).Msg("failed to get account by email")
+		return nil, constant.ErrServer
+	} else if err == sql.ErrNoRows {
+		s.passwordHasher.Verify(req.Password, s.dummyPasswordHash)
+		err = s.recordLoginFailure(ctx, nil, req.Email, req.IP)
+		if err != nil {
+			return nil, err
+		}
+		return nil, constant.ErrInvalidCredentials
+	}
+
+	err = s.passwordHasher.Verify(req.Password, account.Password)
+	if err != nil {
+		err = s.recordLoginFailure(ctx, account, req.Email, req.IP)
+		if err != nil {
+			return nil, err
+		}
+		return nil, constant.ErrInvalidCredentials
+	}
+
+	s.rehashPassword(ctx, account, req.Password)
+
+	if account.TOTPEnabledAt.Valid {
+		return s.challenge(ctx, account)
+	}
+
+	err = s.resetLoginFailures(ctx, account.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.startSession(ctx, account, req.IP, req.UserAgent)
+}
+
+// LoginTOTP completes a login challenged for a second factor. The challenge is
+// consumed whether the code is right or not, so each password check buys a
+// single guess, and a wrong code counts as a failed login.
+func (s *authService) LoginTOTP(ctx context.Context, req model.AuthTOTPRequest) (*model.AuthResponse, error) {
+	var challenge model.LoginChallenge
+	err := s.tokenRepository.ConsumeOneTimeToken(ctx, model.OneTimeTokenLoginChallenge, req.ChallengeToken, &challenge)
+	if err != nil {
+		switch err {
+		case redis.Nil:
+			return nil, constant.ErrInvalidChallenge
+		default:
+			logger.Log().Err(err).Msg("failed to consume login challenge")
+			return nil, constant.ErrServer
+		}
+	}
+
+	account, err := s.accountRepository.GetWithDeleted(ctx, challenge.AccountID, restorableSince())
 	if err != nil {
 		switch err {
 		case sql.ErrNoRows:
-			return nil, constant.ErrEmailNotRegistered
+			return nil, constant.ErrInvalidChallenge
 		default:
-			logger.Log().Err(err).Msg("failed to get account by email")
+			logger.Log().Err(err).Msg("failed to get account")
 			return nil, constant.ErrServer
 		}
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(account.Password), []byte(req.Password))
+	if !account.TOTPEnabledAt.Valid {
+		return nil, constant.ErrInvalidChallenge
+	}
+
+	err = s.checkLockout(ctx, account.Email, req.IP)
+	if err != nil {
+		return nil, err
+	}
+
+	err = verifySecondFactor(ctx, s.tokenRepository, s.recoveryCodeRepository, account, req.Code)
+	if err == constant.ErrInvalidTOTPCode {
+		lockErr := s.recordLoginFailure(ctx, account, account.Email, req.IP)
+		if lockErr != nil {
+			return nil, lockErr
+		}
+		return nil, err
+	} else if err != nil {
+		return nil, err
+	}
+
+	err = s.resetLoginFailures(ctx, account.Email)
 	if err != nil {
-		return nil, constant.ErrWrongPassword
+		return nil, err
 	}
 
-	accessToken, err := token.GenerateToken(account)
+	return s.startSession(ctx, account, req.IP, req.UserAgent)
+}
+
+func (s *authService) Refresh(ctx context.Context, req model.AuthRefreshRequest) (*model.AuthResponse, error) {
+	refreshToken, err := s.tokenRepository.GetRefreshToken(ctx, req.RefreshToken)
+	if err != nil {
+		switch err {
+		case redis.Nil:
+			return nil, constant.ErrInvalidRefreshToken
+		default:
+			logger.Log().Err(err).Msg("failed to get refresh token")
+			return nil, constant.ErrServer
+		}
+	}
+
+	revokedBefore, err := s.tokenRepository.GetRevokedBefore(ctx, refreshToken.AccountID)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to get token revocation watermark")
+		return nil, constant.ErrServer
+	} else if refreshToken.CreatedAt.Before(revokedBefore) {
+		return nil, constant.ErrInvalidRefreshToken
+	}
+
+	active, err := s.tokenRepository.IsRefreshTokenFamilyActive(ctx, refreshToken.FamilyID)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to check refresh token family")
+		return nil, constant.ErrServer
+	} else if !active {
+		return nil, constant.ErrInvalidRefreshToken
+	}
+
+	rotated, err := s.tokenRepository.RotateRefreshToken(ctx, req.RefreshToken)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to rotate refresh token")
+		return nil, constant.ErrServer
+	} else if !rotated {
+		// the token was already exchanged once, whoever holds it now may not
+		// be its owner so nobody in the family gets to keep refreshing
+		logger.Log().Warn().Int64("account_id", refreshToken.AccountID).Msg("refresh token reused, revoking family")
+		err = s.tokenRepository.RevokeRefreshTokenFamily(ctx, refreshToken.FamilyID)
+		if err != nil {
+			logger.Log().Err(err).Msg("failed to revoke refresh token family")
+			return nil, constant.ErrServer
+		}
+		return nil, constant.ErrInvalidRefreshToken
+	}
+
+	account, err := s.accountRepository.Get(ctx, refreshToken.AccountID)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, constant.ErrInvalidRefreshToken
+		default:
+			logger.Log().Err(err).Msg("failed to get account")
+			return nil, constant.ErrServer
+		}
+	}
+
+	return s.issueTokens(ctx, account, refreshToken.FamilyID, refreshToken.SessionID)
+}
+
+func (s *authService) Logout(ctx context.Context, req model.AuthLogoutRequest) error {
+	claimsID, valid := middleware.GetClaimsID(ctx)
+	if !valid {
+		return constant.ErrUnauthorized
+	}
+
+	claimsTokenID, valid := middleware.GetClaimsTokenID(ctx)
+	if valid {
+		err := s.tokenRepository.RevokeToken(ctx, claimsTokenID)
+		if err != nil {
+			logger.Log().Err(err).Msg("failed to revoke token")
+			return constant.ErrServer
+		}
+	}
+
+	claimsSessionID, valid := middleware.GetClaimsSessionID(ctx)
+	if valid {
+		err := s.revokeSession(ctx, claimsSessionID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if req.RefreshToken == "" {
+		return nil
+	}
+
+	refreshToken, err := s.tokenRepository.GetRefreshToken(ctx, req.RefreshToken)
+	if err != nil {
+		switch err {
+		case redis.Nil:
+			return nil
+		default:
+			logger.Log().Err(err).Msg("failed to get refresh token")
+			return constant.ErrServer
+		}
+	}
+
+	if refreshToken.AccountID != claimsID {
+		return constant.ErrUnauthorized
+	}
+
+	err = s.tokenRepository.RevokeRefreshTokenFamily(ctx, refreshToken.FamilyID)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to revoke refresh token family")
+		return constant.ErrServer
+	}
+
+	return nil
+}
+
+func (s *authService) JWKS(ctx context.Context) (*model.JWKSResponse, error) {
+	keys, err := token.LoadKeys()
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to load keys")
+		return nil, constant.ErrServer
+	}
+
+	res := &model.JWKSResponse{Keys: []model.JSONWebKey{}}
+	for _, key := range keys.PublicKeys() {
+		res.Keys = append(res.Keys, model.NewJSONWebKey(key.ID, key.Method.Alg(), key.Public))
+	}
+	return res, nil
+}
+
+// rehashPassword upgrades a hash made with another algorithm or parameters
+// than the configured ones, the login goes on when it fails.
+func (s *authService) rehashPassword(ctx context.Context, account *model.Account, plain string) {
+	if !s.passwordHasher.NeedsRehash(account.Password) {
+		return
+	}
+
+	hash, err := s.passwordHasher.Hash(plain)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to rehash password")
+		return
+	}
+
+	account.Password = hash
+	account.UpdatedAt.Time = time.Now()
+
+	err = s.accountRepository.Update(ctx, account)
+	if err != nil {
+		logger.Log().Err(err).Int64("account_id", account.ID).Msg("failed to update rehashed password")
+	}
+}
+
+func (s *authService) challenge(ctx context.Context, account *model.Account) (*model.AuthResponse, error) {
+	challengeToken, err := token.GenerateOpaqueToken()
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to generate login challenge")
+		return nil, constant.ErrServer
+	}
+
+	err = s.tokenRepository.CreateOneTimeToken(ctx, model.OneTimeTokenLoginChallenge, challengeToken,
+		&model.LoginChallenge{AccountID: account.ID}, config.Cfg().TotpChallengeTTL)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to create login challenge")
+		return nil, constant.ErrServer
+	}
+
+	return &model.AuthResponse{ChallengeToken: challengeToken}, nil
+}
+
+func (s *authService) revokeSession(ctx context.Context, sessionID int64) error {
+	session, err := s.sessionRepository.Get(ctx, sessionID)
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		logger.Log().Err(err).Msg("failed to get session")
+		return constant.ErrServer
+	}
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := revokeSession(ctx, s.sessionRepository, s.tokenRepository, session)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, s.auditRepository, model.AuditActionAuthLogout, model.AuditTargetSession, session.ID, nil, nil)
+	})
+	if err != nil && err != sql.ErrNoRows {
+		logger.Log().Err(err).Msg("failed to revoke session")
+		return constant.ErrServer
+	}
+	return nil
+}
+
+// startSession records the device of a login, the session lives as long as the
+// refresh tokens rotated from it. Logging in restores a deleted account.
+func (s *authService) startSession(ctx context.Context, account *model.Account, ip, userAgent string) (*model.AuthResponse, error) {
+	familyID, err := token.GenerateOpaqueToken()
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to generate refresh token family")
+		return nil, constant.ErrServer
+	}
+
+	// the column counts characters, a header that is not UTF-8 or is cut
+	// inside a rune would fail the insert
+	userAgent = strings.ToValidUTF8(userAgent, "\uFFFD")
+	if runes := []rune(userAgent); len(runes) > maxUserAgentLength {
+		userAgent = string(runes[:maxUserAgentLength])
+	}
+
+	session := &model.Session{
+		FamilyID:  familyID,
+		UserAgent: userAgent,
+		IP:        ip,
+		CreatedAt: time.Now(),
+		AccountID: account.ID,
+	}
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if account.DeletedAt.Valid {
+			err := s.accountRepository.Restore(ctx, account.ID)
+			if err != nil {
+				logger.Log().Err(err).Msg("failed to restore account")
+				return err
+			}
+
+			err = recordAudit(withAuditActor(ctx, account.ID), s.auditRepository, model.AuditActionAccountRestore, model.AuditTargetAccount, account.ID,
+				nil, nil)
+			if err != nil {
+				return err
+			}
+		}
+
+		err := s.sessionRepository.Create(ctx, session)
+		if err != nil {
+			logger.Log().Err(err).Msg("failed to create session")
+			return err
+		}
+
+		return recordAudit(withAuditActor(ctx, account.ID), s.auditRepository, model.AuditActionAuthLogin, model.AuditTargetSession, session.ID,
+			nil, auditSnapshot(model.NewSessionResponse(session, 0)))
+	})
+	if err != nil {
+		return nil, constant.ErrServer
+	}
+
+	return s.issueTokens(ctx, account, familyID, session.ID)
+}
+
+// issueTokens only grants a role that requires a second factor once the
+// account has one, until then the account acts as a regular user and can
+// enroll.
+func (s *authService) issueTokens(ctx context.Context, account *model.Account, familyID string, sessionID int64) (*model.AuthResponse, error) {
+	claimsAccount := *account
+	if totp.RequiredFor(account.Role) && !account.TOTPEnabledAt.Valid {
+		claimsAccount.Role = model.RoleUser
+	}
+
+	accessToken, err := token.GenerateToken(&model.SessionClaims{Account: &claimsAccount, SessionID: sessionID})
 	if err != nil {
 		logger.Log().Err(err).Msg("failed to generate token")
 		return nil, constant.ErrServer
 	}
 
-	return &model.AuthResponse{Token: accessToken}, nil
+	refreshToken, err := token.GenerateOpaqueToken()
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to generate refresh token")
+		return nil, constant.ErrServer
+	}
+
+	err = s.tokenRepository.CreateRefreshToken(ctx, refreshToken, &model.RefreshToken{
+		AccountID: account.ID,
+		FamilyID:  familyID,
+		SessionID: sessionID,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to create refresh token")
+		return nil, constant.ErrServer
+	}
+
+	return &model.AuthResponse{Token: accessToken, RefreshToken: refreshToken}, nil
 }
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/app/repository"
+	"github.com/osamaesmail/go-post-api/internal/config"
+	"github.com/osamaesmail/go-post-api/internal/constant"
+	"github.com/osamaesmail/go-post-api/internal/imaging"
+	"github.com/osamaesmail/go-post-api/internal/logger"
+	"github.com/osamaesmail/go-post-api/internal/security/middleware"
+	"github.com/osamaesmail/go-post-api/internal/storage"
+)
+
+type AvatarService interface {
+	Update(ctx context.Context, req model.AccountAvatarUpdateRequest) (*model.AccountResponse, error)
+	Get(ctx context.Context, req model.AccountAvatarGetRequest) (io.ReadSeekCloser, error)
+}
+
+func NewAvatarService(accountRepository repository.AccountRepository, auditRepository repository.AuditRepository, transactor repository.Transactor, fileStorage storage.Storage, avatarProcessor *imaging.AvatarProcessor) AvatarService {
+	return &avatarService{accountRepository, auditRepository, transactor, fileStorage, avatarProcessor}
+}
+
+type avatarService struct {
+	accountRepository repository.AccountRepository
+	auditRepository   repository.AuditRepository
+	transactor        repository.Transactor
+	fileStorage       storage.Storage
+	avatarProcessor   *imaging.AvatarProcessor
+}
+
+// Update stores every variant of the upload and points the avatar url of the
+// account at them, the url stays the same for later uploads.
+func (s *avatarService) Update(ctx context.Context, req model.AccountAvatarUpdateRequest) (*model.AccountResponse, error) {
+	account, err := s.accountRepository.Get(ctx, req.ID)
+	if err != nil {
+		return nil, s.switchErrAccountNotFoundOrErrServer(err)
+	}
+
+	override := !middleware.IsMe(ctx, req.ID)
+	if override && !canManage(ctx, account) {
+		return nil, constant.ErrUnauthorized
+	}
+
+	variants, err := s.avatarProcessor.Process(req.File)
+	if err != nil {
+		switch err {
+		case imaging.ErrUnsupportedFormat:
+			return nil, constant.ErrInvalidAvatar
+		case imaging.ErrTooManyPixels:
+			return nil, constant.ErrAvatarTooLarge
+		default:
+			logger.Log().Err(err).Msg("failed to process avatar")
+			return nil, constant.ErrServer
+		}
+	}
+
+	for _, variant := range variants {
+		err = s.fileStorage.Put(ctx, avatarKey(account.ID, variant.Size), bytes.NewReader(variant.Data))
+		if err != nil {
+			logger.Log().Err(err).Int64("account_id", account.ID).Msg("failed to store avatar")
+			return nil, constant.ErrServer
+		}
+	}
+
+	before := auditSnapshot(model.NewAccountResponse(account))
+
+	account.AvatarURL = avatarURL(account.ID)
+	account.UpdatedAt.Time = time.Now()
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := s.accountRepository.Update(ctx, account)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, s.auditRepository, model.AuditActionAccountAvatarUpdate, model.AuditTargetAccount, account.ID,
+			before, auditSnapshot(model.NewAccountResponse(account)))
+	})
+	if err != nil {
+		return nil, s.switchErrAccountNotFoundOrErrServer(err)
+	}
+
+	return accountResponse(ctx, account), nil
+}
+
+// Get opens the uploaded avatar, an account pointing its avatar url somewhere
+// else has none here.
+func (s *avatarService) Get(ctx context.Context, req model.AccountAvatarGetRequest) (io.ReadSeekCloser, error) {
+	sizes := s.avatarProcessor.Sizes()
+	size := req.Size
+	if size == 0 {
+		size = sizes[len(sizes)-1]
+	} else if !s.avatarProcessor.HasSize(size) {
+		return nil, constant.ErrUrlQueryParameter
+	}
+
+	account, err := s.accountRepository.Get(ctx, req.ID)
+	if err != nil {
+		return nil, s.switchErrAccountNotFoundOrErrServer(err)
+	} else if account.AvatarURL != avatarURL(account.ID) {
+		return nil, constant.ErrAvatarNotFound
+	}
+
+	avatar, err := s.fileStorage.Open(ctx, avatarKey(account.ID, size))
+	if err != nil {
+		switch err {
+		case storage.ErrNotFound:
+			// uploaded before the size was added to AVATAR_SIZES
+			return nil, constant.ErrAvatarNotFound
+		default:
+			logger.Log().Err(err).Int64("account_id", account.ID).Msg("failed to open avatar")
+			return nil, constant.ErrServer
+		}
+	}
+	return avatar, nil
+}
+
+func (s *avatarService) switchErrAccountNotFoundOrErrServer(err error) error {
+	switch err {
+	case sql.ErrNoRows:
+		return constant.ErrAccountNotFound
+	default:
+		logger.Log().Err(err).Msg("failed to execute operation account repository")
+		return constant.ErrServer
+	}
+}
+
+func avatarPrefix(accountID int64) string {
+	return fmt.Sprintf("avatars/%d/", accountID)
+}
+
+func avatarKey(accountID int64, size int) string {
+	return fmt.Sprintf("%s%d", avatarPrefix(accountID), size)
+}
+
+func avatarURL(accountID int64) string {
+	return fmt.Sprintf("%s/v1/accounts/%d/avatar", strings.TrimSuffix(config.Cfg().AppBaseURL, "/"), accountID)
+}
+
+// isOtherAvatarURL reports whether rawURL points at the account routes of this
+// api other than the avatar of accountID, an account could show the avatar of
+// another as its own otherwise.
+func isOtherAvatarURL(rawURL string, accountID int64) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || rawURL == "" {
+		return false
+	}
+	base, err := url.Parse(config.Cfg().AppBaseURL)
+	if err != nil || !strings.EqualFold(u.Host, base.Host) {
+		return false
+	}
+
+	p := path.Clean("/" + strings.TrimPrefix(u.Path, strings.TrimSuffix(base.Path, "/")))
+	return strings.HasPrefix(p, "/v1/accounts/") && p != fmt.Sprintf("/v1/accounts/%d/avatar", accountID)
+}
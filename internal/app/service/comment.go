@@ -20,12 +20,17 @@ type CommentService interface {
 	Delete(ctx context.Context, req model.CommentDeleteRequest) error
 }
 
-func NewCommentService(commentRepository repository.CommentRepository) CommentService {
-	return &commentService{commentRepository}
+func NewCommentService(commentRepository repository.CommentRepository, postRepository repository.PostRepository, accountRepository repository.AccountRepository, auditRepository repository.AuditRepository, transactor repository.Transactor, searchIndex repository.SearchIndex) CommentService {
+	return &commentService{commentRepository, postRepository, accountRepository, auditRepository, transactor, searchIndex}
 }
 
 type commentService struct {
 	commentRepository repository.CommentRepository
+	postRepository    repository.PostRepository
+	accountRepository repository.AccountRepository
+	auditRepository   repository.AuditRepository
+	transactor        repository.Transactor
+	searchIndex       repository.SearchIndex
 }
 
 func (s *commentService) Create(ctx context.Context, req model.CommentCreateRequest) (*model.CommentResponse, error) {
@@ -34,24 +39,39 @@ func (s *commentService) Create(ctx context.Context, req model.CommentCreateRequ
 		return nil, constant.ErrUnauthorized
 	}
 
+	err := requireVerifiedEmail(ctx, s.accountRepository, claimsID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.getVisiblePost(ctx, req.PostID)
+	if err != nil {
+		return nil, err
+	}
 
 	comment := &model.Comment{
-		Body:      	req.Body,
-		CreatedAt: 	time.Now(),
-		AccountID: 	claimsID,
-		PostID: 	req.PostID,
+		Body:      req.Body,
+		CreatedAt: time.Now(),
+		AccountID: claimsID,
+		PostID:    req.PostID,
 	}
 
-	err := s.commentRepository.Create(ctx, comment)
+	err = s.commentRepository.Create(ctx, comment)
 	if err != nil {
 		logger.Log().Err(err).Msg("failed to create comment")
 		return nil, constant.ErrServer
 	}
 
+	s.indexSearch(ctx, comment)
 	return model.NewCommentResponse(comment), nil
 }
 
 func (s *commentService) List(ctx context.Context, req model.CommentListRequest) ([]*model.CommentResponse, error) {
+	_, err := s.getVisiblePost(ctx, int64(req.PostID))
+	if err != nil {
+		return nil, err
+	}
+
 	comments, err := s.commentRepository.List(ctx, req.Limit, req.Offset, req.PostID)
 	if err != nil {
 		logger.Log().Err(err).Msg("failed to list comments")
@@ -67,6 +87,11 @@ func (s *commentService) Get(ctx context.Context, req model.CommentGetRequest) (
 		return nil, s.switchErrCommentNotFoundOrErrServer(err)
 	}
 
+	_, err = s.getVisiblePost(ctx, comment.PostID)
+	if err != nil {
+		return nil, err
+	}
+
 	return model.NewCommentResponse(comment), nil
 }
 
@@ -76,18 +101,30 @@ func (s *commentService) Update(ctx context.Context, req model.CommentUpdateRequ
 		return nil, s.switchErrCommentNotFoundOrErrServer(err)
 	}
 
-	if !middleware.IsMe(ctx, comment.AccountID) {
+	override := !middleware.IsMe(ctx, comment.AccountID)
+	if override && !middleware.CanModerate(ctx) {
 		return nil, constant.ErrUnauthorized
 	}
 
+	before := auditSnapshot(model.NewCommentResponse(comment))
+
 	comment.Body = req.Body
 	comment.UpdatedAt.Time = time.Now()
 
-	err = s.commentRepository.Update(ctx, comment)
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := s.commentRepository.Update(ctx, comment)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, s.auditRepository, model.AuditActionCommentUpdate, model.AuditTargetComment, comment.ID,
+			before, auditSnapshot(model.NewCommentResponse(comment)))
+	})
 	if err != nil {
 		return nil, s.switchErrCommentNotFoundOrErrServer(err)
 	}
 
+	s.indexSearch(ctx, comment)
 	return model.NewCommentResponse(comment), nil
 }
 
@@ -97,18 +134,52 @@ func (s *commentService) Delete(ctx context.Context, req model.CommentDeleteRequ
 		return s.switchErrCommentNotFoundOrErrServer(err)
 	}
 
-	if !middleware.IsMe(ctx, comment.AccountID) {
+	override := !middleware.IsMe(ctx, comment.AccountID)
+	if override && !middleware.CanModerate(ctx) {
 		return constant.ErrUnauthorized
 	}
 
-	err = s.commentRepository.Delete(ctx, req.ID)
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := s.commentRepository.Delete(ctx, req.ID)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, s.auditRepository, model.AuditActionCommentDelete, model.AuditTargetComment, req.ID,
+			auditSnapshot(model.NewCommentResponse(comment)), nil)
+	})
 	if err != nil {
 		return s.switchErrCommentNotFoundOrErrServer(err)
 	}
 
+	removeSearch(ctx, s.searchIndex, model.SearchTypeComment, req.ID)
 	return nil
 }
 
+// getVisiblePost returns the post comments are on, as ErrPostNotFound when
+// the caller may not see it so the comments do not give away an unpublished
+// post.
+func (s *commentService) getVisiblePost(ctx context.Context, postID int64) (*model.Post, error) {
+	post, err := s.postRepository.Get(ctx, postID)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Log().Err(err).Int64("post_id", postID).Msg("failed to get post of comments")
+		return nil, constant.ErrServer
+	} else if err == sql.ErrNoRows || !canSeePost(ctx, post) {
+		return nil, constant.ErrPostNotFound
+	}
+	return post, nil
+}
+
+// indexSearch indexes the comment along with the title and status of its post.
+func (s *commentService) indexSearch(ctx context.Context, comment *model.Comment) {
+	post, err := s.postRepository.Get(ctx, comment.PostID)
+	if err != nil {
+		logger.Log().Err(err).Int64("comment_id", comment.ID).Msg("failed to get post of comment")
+		return
+	}
+	indexSearch(ctx, s.searchIndex, model.NewCommentSearchDocument(comment, post))
+}
+
 func (s *commentService) switchErrCommentNotFoundOrErrServer(err error) error {
 	switch err {
 	case sql.ErrNoRows:
@@ -0,0 +1,353 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/app/repository"
+	"github.com/osamaesmail/go-post-api/internal/config"
+	"github.com/osamaesmail/go-post-api/internal/constant"
+	"github.com/osamaesmail/go-post-api/internal/logger"
+	"github.com/osamaesmail/go-post-api/internal/security/middleware"
+	"github.com/osamaesmail/go-post-api/internal/security/signature"
+	"github.com/osamaesmail/go-post-api/internal/storage"
+)
+
+// exportBatch is how many rows an export reads per query.
+const exportBatch = 100
+
+type ExportService interface {
+	Create(ctx context.Context, req model.ExportCreateRequest) (*model.ExportJobResponse, error)
+	Get(ctx context.Context, req model.ExportGetRequest) (*model.ExportJobResponse, error)
+	Download(ctx context.Context, req model.ExportDownloadRequest) (io.ReadCloser, error)
+	RunPending(ctx context.Context) (int, error)
+	Expire(ctx context.Context) (int, error)
+}
+
+func NewExportService(exportJobRepository repository.ExportJobRepository, accountRepository repository.AccountRepository, postRepository repository.PostRepository, commentRepository repository.CommentRepository, auditRepository repository.AuditRepository, transactor repository.Transactor, fileStorage storage.Storage) ExportService {
+	return &exportService{exportJobRepository, accountRepository, postRepository, commentRepository, auditRepository, transactor, fileStorage}
+}
+
+type exportService struct {
+	exportJobRepository repository.ExportJobRepository
+	accountRepository   repository.AccountRepository
+	postRepository      repository.PostRepository
+	commentRepository   repository.CommentRepository
+	auditRepository     repository.AuditRepository
+	transactor          repository.Transactor
+	fileStorage         storage.Storage
+}
+
+// Create queues an export of the account, while one is still pending or
+// running it is returned instead of queueing another.
+func (s *exportService) Create(ctx context.Context, req model.ExportCreateRequest) (*model.ExportJobResponse, error) {
+	if !middleware.IsMe(ctx, req.AccountID) && !middleware.HasRole(ctx, model.RoleAdmin) {
+		return nil, constant.ErrUnauthorized
+	}
+
+	_, err := s.accountRepository.Get(ctx, req.AccountID)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, constant.ErrAccountNotFound
+		default:
+			logger.Log().Err(err).Msg("failed to get account")
+			return nil, constant.ErrServer
+		}
+	}
+
+	job, err := s.exportJobRepository.GetActiveByAccountID(ctx, req.AccountID)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Log().Err(err).Msg("failed to get active export job")
+		return nil, constant.ErrServer
+	} else if err == nil && job.CreatedAt.After(time.Now().Add(-config.Cfg().ExportRetention)) {
+		// older ones were left behind by an instance that stopped mid export
+		return model.NewExportJobResponse(job), nil
+	}
+
+	job = &model.ExportJob{
+		Status:    model.ExportJobPending,
+		CreatedAt: time.Now(),
+		AccountID: req.AccountID,
+	}
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := s.exportJobRepository.Create(ctx, job)
+		if err != nil {
+			logger.Log().Err(err).Msg("failed to create export job")
+			return err
+		}
+
+		return recordAudit(ctx, s.auditRepository, model.AuditActionAccountExport, model.AuditTargetAccount, req.AccountID,
+			nil, auditSnapshot(map[string]int64{"export_job_id": job.ID}))
+	})
+	if err != nil {
+		return nil, constant.ErrServer
+	}
+
+	return model.NewExportJobResponse(job), nil
+}
+
+// Get returns the job, once it is done with a freshly signed download link.
+func (s *exportService) Get(ctx context.Context, req model.ExportGetRequest) (*model.ExportJobResponse, error) {
+	if !middleware.IsMe(ctx, req.AccountID) && !middleware.HasRole(ctx, model.RoleAdmin) {
+		return nil, constant.ErrUnauthorized
+	}
+
+	job, err := s.getJob(ctx, req.ID, req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := model.NewExportJobResponse(job)
+	if job.Status == model.ExportJobDone {
+		expiresAt := time.Now().Add(config.Cfg().ExportLinkTTL)
+		res.DownloadURL = exportDownloadURL(job, expiresAt.Unix())
+		res.ExpiresAt = &expiresAt
+	}
+	return res, nil
+}
+
+// Download opens the archive of a done job, the signed link stands in for the
+// claims so it works from anywhere until it expires.
+func (s *exportService) Download(ctx context.Context, req model.ExportDownloadRequest) (io.ReadCloser, error) {
+	if !signature.Verify(config.Cfg().ExportSigningKey, exportSignedMessage(req.AccountID, req.ID), req.Expires, req.Signature, time.Now()) {
+		return nil, constant.ErrInvalidExportLink
+	}
+
+	job, err := s.getJob(ctx, req.ID, req.AccountID)
+	if err != nil {
+		return nil, err
+	} else if job.Status != model.ExportJobDone {
+		return nil, constant.ErrExportNotFound
+	}
+
+	archive, err := s.fileStorage.Open(ctx, exportArchiveKey(job.AccountID, job.ID))
+	if err != nil {
+		if err == storage.ErrNotFound {
+			return nil, constant.ErrExportNotFound
+		}
+		logger.Log().Err(err).Int64("export_job_id", job.ID).Msg("failed to open export archive")
+		return nil, constant.ErrServer
+	}
+	return archive, nil
+}
+
+func (s *exportService) getJob(ctx context.Context, id, accountID int64) (*model.ExportJob, error) {
+	job, err := s.exportJobRepository.Get(ctx, id)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, constant.ErrExportNotFound
+		default:
+			logger.Log().Err(err).Msg("failed to get export job")
+			return nil, constant.ErrServer
+		}
+	} else if job.AccountID != accountID {
+		return nil, constant.ErrExportNotFound
+	}
+	return job, nil
+}
+
+// RunPending builds the archives of the pending jobs one after another and
+// returns how many it finished.
+func (s *exportService) RunPending(ctx context.Context) (int, error) {
+	finished := 0
+	for {
+		job, err := s.exportJobRepository.ClaimPending(ctx)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return finished, nil
+			}
+			logger.Log().Err(err).Msg("failed to claim export job")
+			return finished, err
+		}
+
+		status := model.ExportJobDone
+		err = s.build(ctx, job)
+		if err != nil {
+			logger.Log().Err(err).Int64("export_job_id", job.ID).Msg("failed to build export archive")
+			status = model.ExportJobFailed
+		}
+
+		// the job has to be finished even when ctx was canceled mid build
+		err = s.exportJobRepository.Finish(context.Background(), job.ID, status, time.Now())
+		if err != nil {
+			logger.Log().Err(err).Int64("export_job_id", job.ID).Msg("failed to finish export job")
+			return finished, err
+		}
+		finished++
+	}
+}
+
+// build streams the archive into the storage, which only shows it to a
+// download once it is complete.
+func (s *exportService) build(ctx context.Context, job *model.ExportJob) error {
+	r, w := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		archive := zip.NewWriter(w)
+		err := s.writeArchive(ctx, archive, job.AccountID)
+		if err == nil {
+			err = archive.Close()
+		}
+		w.CloseWithError(err)
+		done <- err
+	}()
+
+	err := s.fileStorage.Put(ctx, exportArchiveKey(job.AccountID, job.ID), r)
+	// a Put that gave up stops the writer too
+	r.CloseWithError(err)
+	if writeErr := <-done; writeErr != nil {
+		return writeErr
+	}
+	return err
+}
+
+// writeArchive pages through the posts and comments of the account so only a
+// batch of them is held at once.
+func (s *exportService) writeArchive(ctx context.Context, archive *zip.Writer, accountID int64) error {
+	account, err := s.accountRepository.Get(ctx, accountID)
+	if err != nil {
+		return err
+	}
+
+	err = writeExportFiles(archive, "account", model.NewAccountResponse(account), accountMarkdown(account))
+	if err != nil {
+		return err
+	}
+
+	var afterID int64
+	for {
+		posts, err := s.postRepository.ListByAccountID(ctx, accountID, afterID, exportBatch)
+		if err != nil {
+			return err
+		}
+
+		for _, post := range posts {
+			err = writeExportFiles(archive, fmt.Sprintf("posts/%d", post.ID), model.NewPostResponse(post), postMarkdown(post))
+			if err != nil {
+				return err
+			}
+			afterID = post.ID
+		}
+
+		if len(posts) < exportBatch {
+			break
+		}
+	}
+
+	afterID = 0
+	for {
+		comments, err := s.commentRepository.ListByAccountID(ctx, accountID, afterID, exportBatch)
+		if err != nil {
+			return err
+		}
+
+		for _, comment := range comments {
+			err = writeExportFiles(archive, fmt.Sprintf("comments/%d", comment.ID), model.NewCommentResponse(comment), commentMarkdown(comment))
+			if err != nil {
+				return err
+			}
+			afterID = comment.ID
+		}
+
+		if len(comments) < exportBatch {
+			return nil
+		}
+	}
+}
+
+// Expire removes the archives kept longer than EXPORT_RETENTION and returns
+// how many jobs it expired.
+func (s *exportService) Expire(ctx context.Context) (int, error) {
+	expired := 0
+	for {
+		jobs, err := s.exportJobRepository.ListFinishedBefore(ctx, time.Now().Add(-config.Cfg().ExportRetention), exportBatch)
+		if err != nil {
+			logger.Log().Err(err).Msg("failed to list finished export jobs")
+			return expired, err
+		}
+
+		for _, job := range jobs {
+			err = s.fileStorage.DeleteAll(ctx, exportArchiveKey(job.AccountID, job.ID))
+			if err != nil {
+				logger.Log().Err(err).Int64("export_job_id", job.ID).Msg("failed to remove export archive")
+				return expired, err
+			}
+
+			err = s.exportJobRepository.Expire(ctx, job.ID)
+			if err != nil {
+				logger.Log().Err(err).Int64("export_job_id", job.ID).Msg("failed to expire export job")
+				return expired, err
+			}
+			expired++
+		}
+
+		if len(jobs) < exportBatch {
+			return expired, nil
+		}
+	}
+}
+
+func exportPrefix(accountID int64) string {
+	return fmt.Sprintf("exports/%d/", accountID)
+}
+
+func exportArchiveKey(accountID, jobID int64) string {
+	return fmt.Sprintf("%s%d.zip", exportPrefix(accountID), jobID)
+}
+
+func exportSignedMessage(accountID, jobID int64) string {
+	return fmt.Sprintf("export:%d:%d", accountID, jobID)
+}
+
+func exportDownloadURL(job *model.ExportJob, expires int64) string {
+	return fmt.Sprintf("%s/v1/accounts/%d/export/%d?expires=%d&signature=%s",
+		strings.TrimSuffix(config.Cfg().AppBaseURL, "/"), job.AccountID, job.ID, expires,
+		signature.Sign(config.Cfg().ExportSigningKey, exportSignedMessage(job.AccountID, job.ID), expires))
+}
+
+// writeExportFiles adds name.json and name.md to the archive.
+func writeExportFiles(archive *zip.Writer, name string, payload interface{}, markdown string) error {
+	w, err := archive.Create(name + ".json")
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(payload)
+	if err != nil {
+		return err
+	}
+
+	w, err = archive.Create(name + ".md")
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, markdown)
+	return err
+}
+
+func accountMarkdown(account *model.Account) string {
+	return fmt.Sprintf("# %s\n\n- Email: %s\n- Role: %s\n- Created: %s\n",
+		account.Name, account.Email, account.Role, account.CreatedAt.Format(time.RFC3339))
+}
+
+func postMarkdown(post *model.Post) string {
+	return fmt.Sprintf("# %s\n\n_Posted %s_\n\n%s\n",
+		post.Title, post.CreatedAt.Format(time.RFC3339), post.Body)
+}
+
+func commentMarkdown(comment *model.Comment) string {
+	return fmt.Sprintf("_Commented on post %d at %s_\n\n%s\n",
+		comment.PostID, comment.CreatedAt.Format(time.RFC3339), comment.Body)
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/config"
+	"github.com/osamaesmail/go-post-api/internal/constant"
+	"github.com/osamaesmail/go-post-api/internal/logger"
+	"github.com/osamaesmail/go-post-api/internal/security/middleware"
+	"github.com/osamaesmail/go-post-api/internal/security/token"
+	"github.com/osamaesmail/go-post-api/internal/security/totp"
+)
+
+// Impersonate lets an admin act as another account for JWT_IMPERSONATE_TTL.
+// Admins can not be impersonated, and neither can an impersonation or a
+// personal access token be used to start one.
+func (s *authService) Impersonate(ctx context.Context, req model.ImpersonateRequest) (*model.ImpersonateResponse, error) {
+	if !middleware.HasRole(ctx, model.RoleAdmin) || middleware.IsAccessToken(ctx) || middleware.IsImpersonating(ctx) {
+		return nil, constant.ErrForbidden
+	}
+
+	claimsID, _ := middleware.GetClaimsID(ctx)
+	account, err := s.accountRepository.Get(ctx, req.AccountID)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, constant.ErrAccountNotFound
+		default:
+			logger.Log().Err(err).Msg("failed to get account")
+			return nil, constant.ErrServer
+		}
+	}
+
+	if account.ID == claimsID || account.Role == model.RoleAdmin {
+		return nil, constant.ErrForbidden
+	}
+
+	claimsAccount := *account
+	if totp.RequiredFor(account.Role) && !account.TOTPEnabledAt.Valid {
+		claimsAccount.Role = model.RoleUser
+	}
+
+	ttl := config.Cfg().JwtImpersonateTTL
+	accessToken, err := token.GenerateTokenWithTTL(&model.ImpersonationClaims{Account: &claimsAccount, ActorID: claimsID}, ttl)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to generate token")
+		return nil, constant.ErrServer
+	}
+
+	err = recordAudit(ctx, s.auditRepository, model.AuditActionAuthImpersonate, model.AuditTargetAccount, account.ID, nil, nil)
+	if err != nil {
+		return nil, constant.ErrServer
+	}
+
+	return &model.ImpersonateResponse{Token: accessToken, ExpiresAt: time.Now().Add(ttl)}, nil
+}
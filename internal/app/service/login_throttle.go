@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/config"
+	"github.com/osamaesmail/go-post-api/internal/constant"
+	"github.com/osamaesmail/go-post-api/internal/logger"
+)
+
+func loginEmailKey(email string) string {
+	return "email_" + strings.ToLower(email)
+}
+
+func loginIPKey(ip string) string {
+	return "ip_" + ip
+}
+
+// checkLockout fails while the email or the ip of a login is locked out.
+func (s *authService) checkLockout(ctx context.Context, email, ip string) error {
+	for _, key := range []string{loginEmailKey(email), loginIPKey(ip)} {
+		locked, err := s.loginAttemptRepository.IsLocked(ctx, key)
+		if err != nil {
+			logger.Log().Err(err).Msg("failed to check login lockout")
+			return constant.ErrServer
+		} else if locked {
+			return constant.ErrTooManyAttempts
+		}
+	}
+	return nil
+}
+
+// recordLoginFailure counts a failed attempt for the email and the ip and
+// locks either out once it reached its limit, account is nil when the email is
+// not registered.
+func (s *authService) recordLoginFailure(ctx context.Context, account *model.Account, email, ip string) error {
+	limits := []struct {
+		key         string
+		maxAttempts int64
+	}{
+		{loginEmailKey(email), config.Cfg().LoginMaxAttempts},
+		{loginIPKey(ip), config.Cfg().LoginIPMaxAttempts},
+	}
+
+	for _, limit := range limits {
+		failures, err := s.loginAttemptRepository.RecordFailure(ctx, limit.key, config.Cfg().LoginAttemptWindow)
+		if err != nil {
+			logger.Log().Err(err).Msg("failed to record login failure")
+			return constant.ErrServer
+		}
+
+		lockout := lockoutDuration(failures, limit.maxAttempts, config.Cfg().LoginLockout, config.Cfg().LoginMaxLockout)
+		if lockout == 0 {
+			continue
+		}
+
+		err = s.loginAttemptRepository.Lock(ctx, limit.key, lockout)
+		if err != nil {
+			logger.Log().Err(err).Msg("failed to lock login")
+			return constant.ErrServer
+		}
+
+		if failures > limit.maxAttempts {
+			continue
+		}
+
+		logger.Log().Warn().Str("key", limit.key).Dur("lockout", lockout).Msg("login locked out")
+		if account != nil && limit.key == loginEmailKey(email) {
+			err = recordAudit(ctx, s.auditRepository, model.AuditActionAccountLockout, model.AuditTargetAccount, account.ID, nil, nil)
+			if err != nil {
+				return constant.ErrServer
+			}
+		}
+	}
+
+	return nil
+}
+
+// resetLoginFailures forgets the failures of the email once it logged in, the
+// ip keeps its count so one known password can not clear it.
+func (s *authService) resetLoginFailures(ctx context.Context, email string) error {
+	err := s.loginAttemptRepository.Reset(ctx, loginEmailKey(email))
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to reset login failures")
+		return constant.ErrServer
+	}
+	return nil
+}
+
+// lockoutDuration doubles the lockout with every failure past maxAttempts, up
+// to maxLockout.
+func lockoutDuration(failures, maxAttempts int64, lockout, maxLockout time.Duration) time.Duration {
+	if failures < maxAttempts {
+		return 0
+	}
+
+	for i := maxAttempts; i < failures && lockout < maxLockout; i++ {
+		lockout *= 2
+	}
+	if lockout > maxLockout {
+		return maxLockout
+	}
+	return lockout
+}
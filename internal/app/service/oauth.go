@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	redis "github.com/go-redis/redis/v8"
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/config"
+	"github.com/osamaesmail/go-post-api/internal/constant"
+	"github.com/osamaesmail/go-post-api/internal/logger"
+	"github.com/osamaesmail/go-post-api/internal/security/oidc"
+	"github.com/osamaesmail/go-post-api/internal/security/token"
+)
+
+// OauthStart returns where to send the user agent to sign in with the
+// provider, the state remembers the nonce and the PKCE verifier meanwhile.
+func (s *authService) OauthStart(ctx context.Context, req model.OauthStartRequest) (string, error) {
+	provider, err := s.oauthProviders.Get(req.Provider)
+	if err != nil {
+		return "", constant.ErrOauthProviderNotFound
+	}
+
+	state := model.OauthState{Provider: provider.Name}
+	for _, v := range []*string{&state.Nonce, &state.CodeVerifier} {
+		*v, err = token.GenerateOpaqueToken()
+		if err != nil {
+			logger.Log().Err(err).Msg("failed to generate oauth state")
+			return "", constant.ErrServer
+		}
+	}
+
+	stateToken, err := token.GenerateOpaqueToken()
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to generate oauth state")
+		return "", constant.ErrServer
+	}
+
+	err = s.tokenRepository.CreateOneTimeToken(ctx, model.OneTimeTokenOauthState, stateToken, &state, config.Cfg().OauthStateTTL)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to create oauth state")
+		return "", constant.ErrServer
+	}
+
+	authCodeURL, err := provider.AuthCodeURL(ctx, stateToken, state.Nonce, state.CodeVerifier)
+	if err != nil {
+		logger.Log().Err(err).Str("provider", provider.Name).Msg("failed to build oauth authorization url")
+		return "", constant.ErrServer
+	}
+	return authCodeURL, nil
+}
+
+// OauthCallback finishes the login the provider redirected back from, it
+// answers like Login including the two-factor challenge.
+func (s *authService) OauthCallback(ctx context.Context, req model.OauthCallbackRequest) (*model.AuthResponse, error) {
+	provider, err := s.oauthProviders.Get(req.Provider)
+	if err != nil {
+		return nil, constant.ErrOauthProviderNotFound
+	}
+
+	var state model.OauthState
+	err = s.tokenRepository.ConsumeOneTimeToken(ctx, model.OneTimeTokenOauthState, req.State, &state)
+	if err != nil {
+		switch err {
+		case redis.Nil:
+			return nil, constant.ErrInvalidOauthState
+		default:
+			logger.Log().Err(err).Msg("failed to consume oauth state")
+			return nil, constant.ErrServer
+		}
+	} else if state.Provider != provider.Name {
+		return nil, constant.ErrInvalidOauthState
+	}
+
+	if req.Error != "" {
+		return nil, constant.ErrOauthDenied
+	}
+
+	identity, err := provider.Exchange(ctx, req.Code, state.CodeVerifier, state.Nonce)
+	if err != nil {
+		logger.Log().Warn().Err(err).Str("provider", provider.Name).Msg("failed to exchange oauth code")
+		return nil, constant.ErrOauthDenied
+	}
+
+	account, err := s.oauthAccount(ctx, provider.Name, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.TOTPEnabledAt.Valid {
+		return s.challenge(ctx, account)
+	}
+	return s.startSession(ctx, account, req.IP, req.UserAgent)
+}
+
+// oauthAccount returns the account linked to the identity. An identity seen
+// for the first time is linked to the account with the same email, or to a
+// new account without a password, as long as the provider verified the email.
+func (s *authService) oauthAccount(ctx context.Context, providerName string, identity *oidc.Identity) (*model.Account, error) {
+	linked, err := s.identityRepository.GetByProviderSubject(ctx, providerName, identity.Subject)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Log().Err(err).Msg("failed to get account identity")
+		return nil, constant.ErrServer
+	} else if err == nil {
+		account, err := s.accountRepository.GetWithDeleted(ctx, linked.AccountID, restorableSince())
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				// deleted past its grace period and waiting to be purged
+				return nil, constant.ErrOauthDenied
+			default:
+				logger.Log().Err(err).Int64("account_id", linked.AccountID).Msg("failed to get account of identity")
+				return nil, constant.ErrServer
+			}
+		}
+		return account, nil
+	}
+
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, constant.ErrOauthEmailNotVerified
+	}
+
+	account, err := s.accountRepository.GetByEmailWithDeleted(ctx, identity.Email, restorableSince())
+	if err != nil && err != sql.ErrNoRows {
+		logger.Log().Err(err).Msg("failed to get account by email")
+		return nil, constant.ErrServer
+	} else if err == nil && !account.EmailVerifiedAt.Valid {
+		// whoever registered the email never proved to own it
+		return nil, constant.ErrOauthAccountUnverified
+	}
+
+	create := err == sql.ErrNoRows
+	if create {
+		name := identity.Name
+		if name == "" {
+			name = strings.SplitN(identity.Email, "@", 2)[0]
+		}
+
+		account = &model.Account{
+			Name:            name,
+			Email:           identity.Email,
+			Role:            model.RoleUser,
+			EmailVerifiedAt: sql.NullTime{Time: time.Now(), Valid: true},
+			CreatedAt:       time.Now(),
+		}
+	}
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if create {
+			err := s.accountRepository.Create(ctx, account)
+			if err != nil {
+				logger.Log().Err(err).Msg("failed to create account")
+				return err
+			}
+
+			err = recordAudit(withAuditActor(ctx, account.ID), s.auditRepository, model.AuditActionAccountCreate, model.AuditTargetAccount, account.ID,
+				nil, auditSnapshot(model.NewAccountResponse(account)))
+			if err != nil {
+				return err
+			}
+		}
+
+		err := s.identityRepository.Create(ctx, &model.AccountIdentity{
+			Provider:  providerName,
+			Subject:   identity.Subject,
+			Email:     identity.Email,
+			CreatedAt: time.Now(),
+			AccountID: account.ID,
+		})
+		if err != nil {
+			logger.Log().Err(err).Msg("failed to create account identity")
+			return err
+		}
+
+		return recordAudit(withAuditActor(ctx, account.ID), s.auditRepository, model.AuditActionAccountIdentityLink, model.AuditTargetAccount, account.ID,
+			nil, auditSnapshot(map[string]string{"provider": providerName, "subject": identity.Subject}))
+	})
+	if err != nil {
+		return nil, constant.ErrServer
+	}
+
+	return account, nil
+}
@@ -3,29 +3,53 @@ package service
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/osamaesmail/go-post-api/internal/app/model"
 	"github.com/osamaesmail/go-post-api/internal/app/repository"
 	"github.com/osamaesmail/go-post-api/internal/constant"
 	"github.com/osamaesmail/go-post-api/internal/logger"
+	"github.com/osamaesmail/go-post-api/internal/render"
 	"github.com/osamaesmail/go-post-api/internal/security/middleware"
+	"github.com/osamaesmail/go-post-api/internal/slug"
+	"github.com/osamaesmail/go-post-api/internal/validation"
 )
 
+// postPublishBatch is how many scheduled posts are published per query.
+const postPublishBatch = 100
+
+// postSlugAttempts is how many numbered suffixes are tried before a slug falls
+// back to a time based one.
+const postSlugAttempts = 20
+
+// postExcerptSize is about how many characters of its text a post excerpt
+// shows.
+const postExcerptSize = 200
+
 type PostService interface {
 	Create(ctx context.Context, req model.PostCreateRequest) (*model.PostResponse, error)
 	List(ctx context.Context, req model.PostListRequest) ([]*model.PostResponse, error)
 	Get(ctx context.Context, req model.PostGetRequest) (*model.PostResponse, error)
+	GetBySlug(ctx context.Context, req model.PostSlugGetRequest) (*model.PostResponse, error)
 	Update(ctx context.Context, req model.PostUpdateRequest) (*model.PostResponse, error)
 	Delete(ctx context.Context, req model.PostDeleteRequest) error
+	PublishScheduled(ctx context.Context) (int, error)
 }
 
-func NewPostService(postRepository repository.PostRepository) PostService {
-	return &postService{postRepository}
+func NewPostService(postRepository repository.PostRepository, postSlugRepository repository.PostSlugRepository, postRenderRepository repository.PostRenderRepository, accountRepository repository.AccountRepository, auditRepository repository.AuditRepository, transactor repository.Transactor, searchIndex repository.SearchIndex) PostService {
+	return &postService{postRepository, postSlugRepository, postRenderRepository, accountRepository, auditRepository, transactor, searchIndex}
 }
 
 type postService struct {
-	postRepository repository.PostRepository
+	postRepository       repository.PostRepository
+	postSlugRepository   repository.PostSlugRepository
+	postRenderRepository repository.PostRenderRepository
+	accountRepository    repository.AccountRepository
+	auditRepository      repository.AuditRepository
+	transactor           repository.Transactor
+	searchIndex          repository.SearchIndex
 }
 
 func (s *postService) Create(ctx context.Context, req model.PostCreateRequest) (*model.PostResponse, error) {
@@ -34,39 +58,132 @@ func (s *postService) Create(ctx context.Context, req model.PostCreateRequest) (
 		return nil, constant.ErrUnauthorized
 	}
 
+	err := requireVerifiedEmail(ctx, s.accountRepository, claimsID)
+	if err != nil {
+		return nil, err
+	}
+
 	post := &model.Post{
-		Title:     req.Title,
-		Body:      req.Body,
-		CreatedAt: time.Now(),
-		AccountID: claimsID,
+		Title:      req.Title,
+		Body:       req.Body,
+		BodyFormat: req.BodyFormat,
+		Tags:       normalizeTags(req.Tags),
+		CreatedAt:  time.Now(),
+		AccountID:  claimsID,
+	}
+	if post.BodyFormat == "" {
+		post.BodyFormat = model.PostBodyFormatPlain
+	}
+
+	status := req.Status
+	if status == "" {
+		status = model.PostStatusPublished
+	}
+	err = setPostStatus(post, status, req.PublishAt, post.CreatedAt)
+	if err != nil {
+		return nil, err
 	}
 
-	err := s.postRepository.Create(ctx, post)
+	// the slug is checked outside the insert, one taken in between is retried
+	// with the next suffix
+	conflicts := make(map[string]bool)
+	for {
+		post.Slug, err = s.uniqueSlug(ctx, post.Title, 0, conflicts)
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+			return s.postRepository.Create(ctx, post)
+		})
+		if err != repository.ErrSlugTaken || len(conflicts) >= postSlugAttempts {
+			break
+		}
+		conflicts[post.Slug] = true
+	}
 	if err != nil {
 		logger.Log().Err(err).Msg("failed to create post")
 		return nil, constant.ErrServer
 	}
 
-	return model.NewPostResponse(post), nil
+	indexSearch(ctx, s.searchIndex, model.NewPostSearchDocument(post))
+	return s.postResponse(ctx, post), nil
 }
 
+// List shows everyone the published posts, the other statuses only list the
+// posts of the caller.
 func (s *postService) List(ctx context.Context, req model.PostListRequest) ([]*model.PostResponse, error) {
-	posts, err := s.postRepository.List(ctx, req.Limit, req.Offset, req.Title)
+	filter := model.PostFilter{
+		Title:  req.Title,
+		Status: model.PostStatusPublished,
+		Tags:   normalizeTags(req.Tags),
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	}
+
+	switch req.TagMode {
+	case "", "all":
+	case "any":
+		filter.AnyTag = true
+	default:
+		return nil, constant.ErrUrlQueryParameter
+	}
+
+	switch req.Status {
+	case "", model.PostStatusPublished:
+	case model.PostStatusDraft, model.PostStatusScheduled, model.PostStatusArchived:
+		claimsID, valid := middleware.GetClaimsID(ctx)
+		if !valid {
+			return nil, constant.ErrUnauthorized
+		}
+		filter.Status = req.Status
+		filter.AccountID = claimsID
+	default:
+		return nil, constant.ErrUrlQueryParameter
+	}
+
+	posts, err := s.postRepository.List(ctx, filter)
 	if err != nil {
 		logger.Log().Err(err).Msg("failed to list posts")
 		return nil, constant.ErrServer
 	}
 
-	return model.NewPostListResponse(posts), nil
+	res := make([]*model.PostResponse, len(posts))
+	for i, post := range posts {
+		res[i] = s.postResponse(ctx, post)
+	}
+	return res, nil
 }
 
 func (s *postService) Get(ctx context.Context, req model.PostGetRequest) (*model.PostResponse, error) {
 	post, err := s.postRepository.Get(ctx, req.ID)
 	if err != nil {
 		return nil, s.switchErrPostNotFoundOrErrServer(err)
+	} else if !canSeePost(ctx, post) {
+		return nil, constant.ErrPostNotFound
+	}
+
+	return s.postResponse(ctx, post), nil
+}
+
+// GetBySlug also finds the post by a slug it had before, the response then
+// carries the current slug to redirect to.
+func (s *postService) GetBySlug(ctx context.Context, req model.PostSlugGetRequest) (*model.PostResponse, error) {
+	post, err := s.postRepository.GetBySlug(ctx, req.Slug)
+	if err == sql.ErrNoRows {
+		var previous *model.PostSlug
+		previous, err = s.postSlugRepository.GetBySlug(ctx, req.Slug)
+		if err == nil {
+			post, err = s.postRepository.Get(ctx, previous.PostID)
+		}
+	}
+	if err != nil {
+		return nil, s.switchErrPostNotFoundOrErrServer(err)
+	} else if !canSeePost(ctx, post) {
+		return nil, constant.ErrPostNotFound
 	}
 
-	return model.NewPostResponse(post), nil
+	return s.postResponse(ctx, post), nil
 }
 
 func (s *postService) Update(ctx context.Context, req model.PostUpdateRequest) (*model.PostResponse, error) {
@@ -75,20 +192,90 @@ func (s *postService) Update(ctx context.Context, req model.PostUpdateRequest) (
 		return nil, s.switchErrPostNotFoundOrErrServer(err)
 	}
 
-	if !middleware.IsMe(ctx, post.AccountID) {
+	override := !middleware.IsMe(ctx, post.AccountID)
+	if override && !middleware.CanModerate(ctx) {
 		return nil, constant.ErrUnauthorized
 	}
 
+	before := auditSnapshot(model.NewPostResponse(post))
+	previous := *post
+	previousSlug := post.Slug
+	conflicts := make(map[string]bool)
+	if slug.Make(req.Title) != slug.Make(post.Title) {
+		post.Slug, err = s.uniqueSlug(ctx, req.Title, post.ID, conflicts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	post.Title = req.Title
 	post.Body = req.Body
+	if req.BodyFormat != "" {
+		post.BodyFormat = req.BodyFormat
+	}
 	post.UpdatedAt.Time = time.Now()
+	if req.Tags != nil {
+		post.Tags = normalizeTags(req.Tags)
+	}
+
+	if req.Status != "" || req.PublishAt != nil {
+		status := req.Status
+		if status == "" {
+			status = post.Status
+		}
+		err = setPostStatus(post, status, req.PublishAt, post.UpdatedAt.Time)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	err = s.postRepository.Update(ctx, post)
+	update := func(ctx context.Context) error {
+		if post.Slug != previousSlug {
+			err := s.postSlugRepository.DeleteBySlug(ctx, post.Slug)
+			if err != nil {
+				return err
+			}
+
+			err = s.postSlugRepository.Create(ctx, &model.PostSlug{
+				Slug:      previousSlug,
+				CreatedAt: post.UpdatedAt.Time,
+				PostID:    post.ID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		err := s.postRepository.Update(ctx, post)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, s.auditRepository, model.AuditActionPostUpdate, model.AuditTargetPost, post.ID,
+			before, auditSnapshot(model.NewPostResponse(post)))
+	}
+	// like in Create, a slug another post took since it was checked moves on
+	// to the next suffix
+	for {
+		err = s.transactor.WithinTransaction(ctx, update)
+		if err != repository.ErrSlugTaken || post.Slug == previousSlug || len(conflicts) >= postSlugAttempts {
+			break
+		}
+		conflicts[post.Slug] = true
+		post.Slug, err = s.uniqueSlug(ctx, post.Title, post.ID, conflicts)
+		if err != nil {
+			return nil, err
+		}
+	}
 	if err != nil {
 		return nil, s.switchErrPostNotFoundOrErrServer(err)
 	}
 
-	return model.NewPostResponse(post), nil
+	indexSearch(ctx, s.searchIndex, model.NewPostSearchDocument(post))
+	if post.Body != previous.Body || post.BodyFormat != previous.BodyFormat {
+		s.deleteRender(ctx, &previous)
+	}
+	return s.postResponse(ctx, post), nil
 }
 
 func (s *postService) Delete(ctx context.Context, req model.PostDeleteRequest) error {
@@ -97,18 +284,53 @@ func (s *postService) Delete(ctx context.Context, req model.PostDeleteRequest) e
 		return s.switchErrPostNotFoundOrErrServer(err)
 	}
 
-	if !middleware.IsMe(ctx, post.AccountID) {
+	override := !middleware.IsMe(ctx, post.AccountID)
+	if override && !middleware.CanModerate(ctx) {
 		return constant.ErrUnauthorized
 	}
 
-	err = s.postRepository.Delete(ctx, req.ID)
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := s.postRepository.Delete(ctx, req.ID)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, s.auditRepository, model.AuditActionPostDelete, model.AuditTargetPost, req.ID,
+			auditSnapshot(model.NewPostResponse(post)), nil)
+	})
 	if err != nil {
 		return s.switchErrPostNotFoundOrErrServer(err)
 	}
 
+	removeSearch(ctx, s.searchIndex, model.SearchTypePost, req.ID)
+	s.deleteRender(ctx, post)
 	return nil
 }
 
+// PublishScheduled publishes the scheduled posts that are due and returns how
+// many it published.
+func (s *postService) PublishScheduled(ctx context.Context) (int, error) {
+	published := 0
+	for {
+		ids, err := s.postRepository.PublishDue(ctx, time.Now(), postPublishBatch)
+		published += len(ids)
+		for _, id := range ids {
+			post, err := s.postRepository.Get(ctx, id)
+			if err != nil {
+				logger.Log().Err(err).Int64("post_id", id).Msg("failed to get published post")
+				continue
+			}
+			indexSearch(ctx, s.searchIndex, model.NewPostSearchDocument(post))
+		}
+		if err != nil {
+			logger.Log().Err(err).Msg("failed to publish scheduled posts")
+			return published, err
+		} else if len(ids) < postPublishBatch {
+			return published, nil
+		}
+	}
+}
+
 func (s *postService) switchErrPostNotFoundOrErrServer(err error) error {
 	switch err {
 	case sql.ErrNoRows:
@@ -118,3 +340,131 @@ func (s *postService) switchErrPostNotFoundOrErrServer(err error) error {
 		return constant.ErrServer
 	}
 }
+
+// postResponse adds the rendered body of the post, it is only rendered again
+// once the body changes. A failing cache does not fail the request.
+func (s *postService) postResponse(ctx context.Context, post *model.Post) *model.PostResponse {
+	rendered, err := s.postRenderRepository.Get(ctx, post, func() *model.PostRender {
+		return renderPost(post)
+	})
+	if err != nil {
+		logger.Log().Err(err).Int64("post_id", post.ID).Msg("failed to get rendered post")
+		rendered = renderPost(post)
+	}
+
+	res := model.NewPostResponse(post)
+	res.SetRender(rendered)
+	return res
+}
+
+// deleteRender drops the rendering of a body the post no longer has, it would
+// only expire otherwise.
+func (s *postService) deleteRender(ctx context.Context, post *model.Post) {
+	err := s.postRenderRepository.Delete(ctx, post)
+	if err != nil {
+		logger.Log().Err(err).Int64("post_id", post.ID).Msg("failed to delete rendered post")
+	}
+}
+
+func renderPost(post *model.Post) *model.PostRender {
+	bodyHTML := render.Plain(post.Body)
+	if post.BodyFormat == model.PostBodyFormatMarkdown {
+		bodyHTML = render.Markdown(post.Body)
+	}
+
+	text := render.Text(bodyHTML)
+	return &model.PostRender{
+		BodyHTML:    bodyHTML,
+		Excerpt:     render.Excerpt(text, postExcerptSize),
+		ReadingTime: render.ReadingTime(text),
+	}
+}
+
+// uniqueSlug makes the slug of title and suffixes it with -2, -3 and so on
+// while another post has it or had it before. postID is the post taking it, 0
+// for a new one, it may take back a slug it had before. conflicts are slugs a
+// write lost to another post since they were checked.
+func (s *postService) uniqueSlug(ctx context.Context, title string, postID int64, conflicts map[string]bool) (string, error) {
+	base := slug.Make(title)
+	candidate := base
+	for n := 2; ; n++ {
+		taken, err := s.slugTaken(ctx, candidate, postID, conflicts)
+		if err != nil {
+			logger.Log().Err(err).Msg("failed to check post slug")
+			return "", constant.ErrServer
+		} else if !taken {
+			return candidate, nil
+		}
+
+		if n <= postSlugAttempts {
+			candidate = fmt.Sprintf("%s-%d", base, n)
+		} else {
+			candidate = base + "-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+		}
+	}
+}
+
+func (s *postService) slugTaken(ctx context.Context, candidate string, postID int64, conflicts map[string]bool) (bool, error) {
+	if conflicts[candidate] {
+		return true, nil
+	}
+
+	post, err := s.postRepository.GetBySlug(ctx, candidate)
+	if err != nil && err != sql.ErrNoRows {
+		return false, err
+	} else if err == nil && post.ID != postID {
+		return true, nil
+	}
+
+	previous, err := s.postSlugRepository.GetBySlug(ctx, candidate)
+	if err != nil && err != sql.ErrNoRows {
+		return false, err
+	}
+	return err == nil && previous.PostID != postID, nil
+}
+
+// canSeePost reports whether the caller may read the post, only published
+// posts are public and the others only show to their author.
+func canSeePost(ctx context.Context, post *model.Post) bool {
+	return post.Status == model.PostStatusPublished || middleware.IsMe(ctx, post.AccountID)
+}
+
+// setPostStatus moves the post to status. A scheduled post needs a publishAt
+// in the future, it keeps its own when it is only edited. A published post
+// keeps the time it was first published at.
+func setPostStatus(post *model.Post, status string, publishAt *time.Time, now time.Time) error {
+	switch status {
+	case model.PostStatusScheduled:
+		if publishAt == nil && post.Status == model.PostStatusScheduled {
+			publishAt = &post.PublishAt.Time
+		}
+		if publishAt == nil || !publishAt.After(now) {
+			return constant.ErrInvalidPublishAt
+		}
+		post.PublishAt = sql.NullTime{Time: *publishAt, Valid: true}
+	case model.PostStatusPublished:
+		if post.Status == model.PostStatusScheduled || !post.PublishAt.Valid {
+			post.PublishAt = sql.NullTime{Time: now, Valid: true}
+		}
+	case model.PostStatusDraft:
+		post.PublishAt = sql.NullTime{}
+	}
+
+	post.Status = status
+	return nil
+}
+
+// normalizeTags normalizes the tags and drops the ones that turn out the same.
+func normalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = validation.NormalizeTag(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/app/repository"
+	"github.com/osamaesmail/go-post-api/internal/constant"
+	"github.com/osamaesmail/go-post-api/internal/security/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePostRepository struct {
+	repository.PostRepository
+	posts map[int64]*model.Post
+}
+
+func (r *fakePostRepository) Get(ctx context.Context, id int64) (*model.Post, error) {
+	post, found := r.posts[id]
+	if !found {
+		return nil, sql.ErrNoRows
+	}
+	temp := *post
+	return &temp, nil
+}
+
+type fakePostRenderRepository struct {
+	repository.PostRenderRepository
+}
+
+func (r *fakePostRenderRepository) Get(ctx context.Context, post *model.Post, render func() *model.PostRender) (*model.PostRender, error) {
+	return render(), nil
+}
+
+func TestPostServiceGet(t *testing.T) {
+	const authorID, otherID = 1, 2
+	postRepository := &fakePostRepository{posts: map[int64]*model.Post{
+		10: {ID: 10, Title: "Draft", Body: "draft", Status: model.PostStatusDraft, AccountID: authorID},
+		11: {ID: 11, Title: "Scheduled", Body: "scheduled", Status: model.PostStatusScheduled, AccountID: authorID},
+		12: {ID: 12, Title: "Published", Body: "published", Status: model.PostStatusPublished, AccountID: authorID},
+	}}
+	s := NewPostService(postRepository, nil, &fakePostRenderRepository{}, nil, nil, nil, nil)
+
+	author := middleware.WithClaims(context.Background(), authorID, model.RoleUser)
+	moderator := middleware.WithClaims(context.Background(), otherID, model.RoleModerator)
+	admin := middleware.WithClaims(context.Background(), otherID, model.RoleAdmin)
+
+	t.Run("author sees their draft", func(t *testing.T) {
+		res, err := s.Get(author, model.PostGetRequest{ID: 10})
+		require.NoError(t, err)
+		assert.Equal(t, "Draft", res.Title)
+	})
+
+	t.Run("moderator is refused someone else's draft", func(t *testing.T) {
+		_, err := s.Get(moderator, model.PostGetRequest{ID: 10})
+		assert.Equal(t, constant.ErrPostNotFound, err)
+	})
+
+	t.Run("admin is refused someone else's scheduled post", func(t *testing.T) {
+		_, err := s.Get(admin, model.PostGetRequest{ID: 11})
+		assert.Equal(t, constant.ErrPostNotFound, err)
+	})
+
+	t.Run("anyone sees a published post", func(t *testing.T) {
+		_, err := s.Get(context.Background(), model.PostGetRequest{ID: 12})
+		assert.NoError(t, err)
+		_, err = s.Get(moderator, model.PostGetRequest{ID: 12})
+		assert.NoError(t, err)
+	})
+}
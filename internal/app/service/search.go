@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/app/repository"
+	"github.com/osamaesmail/go-post-api/internal/config"
+	"github.com/osamaesmail/go-post-api/internal/constant"
+	"github.com/osamaesmail/go-post-api/internal/logger"
+	"github.com/osamaesmail/go-post-api/internal/search"
+)
+
+type SearchService interface {
+	Search(ctx context.Context, req model.SearchRequest) ([]*model.SearchResultResponse, error)
+}
+
+func NewSearchService(searchIndex repository.SearchIndex) SearchService {
+	return &searchService{searchIndex}
+}
+
+type searchService struct {
+	searchIndex repository.SearchIndex
+}
+
+// Search returns the best matches first, each with a snippet of its body that
+// marks the words searched for.
+func (s *searchService) Search(ctx context.Context, req model.SearchRequest) ([]*model.SearchResultResponse, error) {
+	terms := search.Terms(req.Query)
+	if len(terms) == 0 {
+		return nil, constant.ErrUrlQueryParameter
+	}
+
+	switch req.Type {
+	case "", model.SearchTypePost, model.SearchTypeComment:
+	default:
+		return nil, constant.ErrUrlQueryParameter
+	}
+
+	results, err := s.searchIndex.Search(ctx, req)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to search")
+		return nil, constant.ErrServer
+	}
+
+	res := make([]*model.SearchResultResponse, len(results))
+	for i, result := range results {
+		res[i] = model.NewSearchResultResponse(result)
+		res[i].Snippet = search.Snippet(result.Body, terms, config.Cfg().SearchSnippetSize)
+	}
+	return res, nil
+}
+
+// indexSearch keeps the search index up to date, the index can be rebuilt so a
+// failure is only logged.
+func indexSearch(ctx context.Context, searchIndex repository.SearchIndex, doc *model.SearchDocument) {
+	err := searchIndex.Index(ctx, doc)
+	if err != nil {
+		logger.Log().Err(err).Str("type", doc.Type).Int64("id", doc.ID).Msg("failed to index search document")
+	}
+}
+
+func removeSearch(ctx context.Context, searchIndex repository.SearchIndex, docType string, id int64) {
+	err := searchIndex.Remove(ctx, docType, id)
+	if err != nil {
+		logger.Log().Err(err).Str("type", docType).Int64("id", id).Msg("failed to remove search document")
+	}
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/app/repository"
+	"github.com/osamaesmail/go-post-api/internal/config"
+	"github.com/osamaesmail/go-post-api/internal/constant"
+	"github.com/osamaesmail/go-post-api/internal/logger"
+	"github.com/osamaesmail/go-post-api/internal/security/middleware"
+)
+
+type SessionService interface {
+	List(ctx context.Context, req model.SessionListRequest) ([]*model.SessionResponse, error)
+	Delete(ctx context.Context, req model.SessionDeleteRequest) error
+}
+
+func NewSessionService(sessionRepository repository.SessionRepository, tokenRepository repository.TokenRepository, auditRepository repository.AuditRepository, transactor repository.Transactor) SessionService {
+	return &sessionService{sessionRepository, tokenRepository, auditRepository, transactor}
+}
+
+type sessionService struct {
+	sessionRepository repository.SessionRepository
+	tokenRepository   repository.TokenRepository
+	auditRepository   repository.AuditRepository
+	transactor        repository.Transactor
+}
+
+// List leaves out sessions whose refresh tokens expired or were revoked with
+// every other token of the account, last seen times lag behind by up to
+// SESSION_FLUSH_INTERVAL.
+func (s *sessionService) List(ctx context.Context, req model.SessionListRequest) ([]*model.SessionResponse, error) {
+	if !middleware.IsMe(ctx, req.AccountID) {
+		return nil, constant.ErrUnauthorized
+	}
+
+	sessions, err := s.sessionRepository.List(ctx, req.AccountID, time.Now().Add(-config.Cfg().JwtRefreshTTL))
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to list sessions")
+		return nil, constant.ErrServer
+	}
+
+	revokedBefore, err := s.tokenRepository.GetRevokedBefore(ctx, req.AccountID)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to get token revocation watermark")
+		return nil, constant.ErrServer
+	}
+
+	active := sessions[:0]
+	for _, session := range sessions {
+		if !session.CreatedAt.Before(revokedBefore) {
+			active = append(active, session)
+		}
+	}
+
+	currentID, _ := middleware.GetClaimsSessionID(ctx)
+	return model.NewSessionListResponse(active, currentID), nil
+}
+
+func (s *sessionService) Delete(ctx context.Context, req model.SessionDeleteRequest) error {
+	if !middleware.IsMe(ctx, req.AccountID) {
+		return constant.ErrUnauthorized
+	}
+
+	session, err := s.sessionRepository.Get(ctx, req.ID)
+	if err != nil {
+		return s.switchErrSessionNotFoundOrErrServer(err)
+	} else if session.AccountID != req.AccountID {
+		return constant.ErrSessionNotFound
+	}
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := revokeSession(ctx, s.sessionRepository, s.tokenRepository, session)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, s.auditRepository, model.AuditActionSessionRevoke, model.AuditTargetSession, session.ID, nil, nil)
+	})
+	if err != nil {
+		return s.switchErrSessionNotFoundOrErrServer(err)
+	}
+
+	return nil
+}
+
+func (s *sessionService) switchErrSessionNotFoundOrErrServer(err error) error {
+	switch err {
+	case sql.ErrNoRows:
+		return constant.ErrSessionNotFound
+	default:
+		logger.Log().Err(err).Msg("failed to execute operation session repository")
+		return constant.ErrServer
+	}
+}
+
+// revokeSession stops both the access tokens and the refresh tokens of the
+// session, it returns sql.ErrNoRows when the session was already revoked.
+func revokeSession(ctx context.Context, sessionRepository repository.SessionRepository, tokenRepository repository.TokenRepository, session *model.Session) error {
+	err := sessionRepository.Revoke(ctx, session.ID, time.Now())
+	if err != nil {
+		return err
+	}
+	return tokenRepository.RevokeRefreshTokenFamily(ctx, session.FamilyID)
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/app/repository"
+	"github.com/osamaesmail/go-post-api/internal/constant"
+	"github.com/osamaesmail/go-post-api/internal/logger"
+	"github.com/osamaesmail/go-post-api/internal/security/middleware"
+	"github.com/osamaesmail/go-post-api/internal/validation"
+)
+
+type TagService interface {
+	List(ctx context.Context, req model.TagListRequest) ([]*model.TagResponse, error)
+	Update(ctx context.Context, req model.TagUpdateRequest) (*model.TagResponse, error)
+}
+
+func NewTagService(tagRepository repository.TagRepository, auditRepository repository.AuditRepository, transactor repository.Transactor) TagService {
+	return &tagService{tagRepository, auditRepository, transactor}
+}
+
+type tagService struct {
+	tagRepository   repository.TagRepository
+	auditRepository repository.AuditRepository
+	transactor      repository.Transactor
+}
+
+func (s *tagService) List(ctx context.Context, req model.TagListRequest) ([]*model.TagResponse, error) {
+	tags, err := s.tagRepository.List(ctx, req.Limit, req.Offset)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to list tags")
+		return nil, constant.ErrServer
+	}
+
+	return model.NewTagListResponse(tags), nil
+}
+
+// Update renames the tag on every post using it. When another tag already has
+// the new name the two are merged into that one.
+func (s *tagService) Update(ctx context.Context, req model.TagUpdateRequest) (*model.TagResponse, error) {
+	if !middleware.HasRole(ctx, model.RoleAdmin) {
+		return nil, constant.ErrForbidden
+	}
+
+	tag, err := s.tagRepository.GetByName(ctx, validation.NormalizeTag(req.Tag))
+	if err != nil {
+		return nil, s.switchErrTagNotFoundOrErrServer(err)
+	}
+
+	name := validation.NormalizeTag(req.Name)
+	if tag.Name == name {
+		return model.NewTagResponse(tag), nil
+	}
+
+	into, err := s.tagRepository.GetByName(ctx, name)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, s.switchErrTagNotFoundOrErrServer(err)
+	}
+
+	before := auditSnapshot(model.NewTagResponse(tag))
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if into != nil {
+			err := s.tagRepository.Merge(ctx, tag.ID, into.ID)
+			if err != nil {
+				return err
+			}
+
+			return recordAudit(ctx, s.auditRepository, model.AuditActionTagMerge, model.AuditTargetTag, tag.ID,
+				before, auditSnapshot(model.NewTagResponse(into)))
+		}
+
+		err := s.tagRepository.Rename(ctx, tag.ID, name)
+		if err != nil {
+			return err
+		}
+
+		renamed := *tag
+		renamed.Name = name
+		return recordAudit(ctx, s.auditRepository, model.AuditActionTagRename, model.AuditTargetTag, tag.ID,
+			before, auditSnapshot(model.NewTagResponse(&renamed)))
+	})
+	if err != nil {
+		return nil, s.switchErrTagNotFoundOrErrServer(err)
+	}
+
+	tag, err = s.tagRepository.GetByName(ctx, name)
+	if err != nil {
+		return nil, s.switchErrTagNotFoundOrErrServer(err)
+	}
+	return model.NewTagResponse(tag), nil
+}
+
+func (s *tagService) switchErrTagNotFoundOrErrServer(err error) error {
+	switch err {
+	case sql.ErrNoRows:
+		return constant.ErrTagNotFound
+	default:
+		logger.Log().Err(err).Msg("failed to execute operation tag repository")
+		return constant.ErrServer
+	}
+}
@@ -0,0 +1,308 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/app/repository"
+	"github.com/osamaesmail/go-post-api/internal/config"
+	"github.com/osamaesmail/go-post-api/internal/constant"
+	"github.com/osamaesmail/go-post-api/internal/logger"
+	"github.com/osamaesmail/go-post-api/internal/security/middleware"
+	"github.com/osamaesmail/go-post-api/internal/security/password"
+	"github.com/osamaesmail/go-post-api/internal/security/token"
+	"github.com/osamaesmail/go-post-api/internal/security/totp"
+)
+
+type TOTPService interface {
+	Enroll(ctx context.Context, req model.TOTPEnrollRequest) (*model.TOTPEnrollResponse, error)
+	Confirm(ctx context.Context, req model.TOTPConfirmRequest) (*model.TOTPRecoveryCodesResponse, error)
+	Disable(ctx context.Context, req model.TOTPDisableRequest) error
+	RegenerateRecoveryCodes(ctx context.Context, req model.TOTPRecoveryCodesRequest) (*model.TOTPRecoveryCodesResponse, error)
+}
+
+func NewTOTPService(accountRepository repository.AccountRepository, tokenRepository repository.TokenRepository, recoveryCodeRepository repository.RecoveryCodeRepository, auditRepository repository.AuditRepository, transactor repository.Transactor, passwordHasher password.PasswordHasher) TOTPService {
+	return &totpService{accountRepository, tokenRepository, recoveryCodeRepository, auditRepository, transactor, passwordHasher}
+}
+
+type totpService struct {
+	accountRepository      repository.AccountRepository
+	tokenRepository        repository.TokenRepository
+	recoveryCodeRepository repository.RecoveryCodeRepository
+	auditRepository        repository.AuditRepository
+	transactor             repository.Transactor
+	passwordHasher         password.PasswordHasher
+}
+
+// Enroll starts over with a new secret until the enrollment is confirmed.
+func (s *totpService) Enroll(ctx context.Context, req model.TOTPEnrollRequest) (*model.TOTPEnrollResponse, error) {
+	account, err := s.getOwnAccount(ctx, req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.TOTPEnabledAt.Valid {
+		return nil, constant.ErrTOTPEnabled
+	}
+
+	err = s.passwordHasher.Verify(req.Password, account.Password)
+	if err != nil {
+		return nil, constant.ErrWrongPassword
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to generate totp secret")
+		return nil, constant.ErrServer
+	}
+
+	account.TOTPSecret = sql.NullString{String: secret, Valid: true}
+	account.UpdatedAt.Time = time.Now()
+
+	err = s.accountRepository.Update(ctx, account)
+	if err != nil {
+		return nil, s.switchErrAccountNotFoundOrErrServer(err)
+	}
+
+	return &model.TOTPEnrollResponse{
+		Secret: secret,
+		URI:    totp.URI(config.Cfg().TotpIssuer, account.Email, secret),
+	}, nil
+}
+
+// Confirm enables two factor authentication once the authenticator proved to
+// produce valid codes. Every session issued before is revoked, they did not
+// go through the second factor.
+func (s *totpService) Confirm(ctx context.Context, req model.TOTPConfirmRequest) (*model.TOTPRecoveryCodesResponse, error) {
+	account, err := s.getOwnAccount(ctx, req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.TOTPEnabledAt.Valid {
+		return nil, constant.ErrTOTPEnabled
+	} else if !account.TOTPSecret.Valid {
+		return nil, constant.ErrTOTPNotEnrolled
+	}
+
+	err = verifyTOTPCode(ctx, s.tokenRepository, account, req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	before := auditSnapshot(model.NewAccountResponse(account))
+
+	account.TOTPEnabledAt = sql.NullTime{Time: time.Now(), Valid: true}
+	account.UpdatedAt.Time = time.Now()
+
+	var res *model.TOTPRecoveryCodesResponse
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := s.accountRepository.Update(ctx, account)
+		if err != nil {
+			return s.switchErrAccountNotFoundOrErrServer(err)
+		}
+
+		res, err = s.replaceRecoveryCodes(ctx, account)
+		if err != nil {
+			return err
+		}
+
+		err = recordAudit(ctx, s.auditRepository, model.AuditActionAccountTOTPEnable, model.AuditTargetAccount, account.ID,
+			before, auditSnapshot(model.NewAccountResponse(account)))
+		if err != nil {
+			return constant.ErrServer
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.tokenRepository.SetRevokedBefore(ctx, account.ID, time.Now())
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to revoke account tokens")
+		return nil, constant.ErrServer
+	}
+
+	return res, nil
+}
+
+func (s *totpService) Disable(ctx context.Context, req model.TOTPDisableRequest) error {
+	account, err := s.getOwnAccount(ctx, req.AccountID)
+	if err != nil {
+		return err
+	}
+
+	err = s.reauthenticate(ctx, account, req.Password, req.Code)
+	if err != nil {
+		return err
+	}
+
+	before := auditSnapshot(model.NewAccountResponse(account))
+
+	account.TOTPSecret = sql.NullString{}
+	account.TOTPEnabledAt = sql.NullTime{}
+	account.UpdatedAt.Time = time.Now()
+
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := s.accountRepository.Update(ctx, account)
+		if err != nil {
+			return s.switchErrAccountNotFoundOrErrServer(err)
+		}
+
+		err = s.recoveryCodeRepository.DeleteByAccountID(ctx, account.ID)
+		if err != nil {
+			logger.Log().Err(err).Msg("failed to delete recovery codes")
+			return constant.ErrServer
+		}
+
+		err = recordAudit(ctx, s.auditRepository, model.AuditActionAccountTOTPDisable, model.AuditTargetAccount, account.ID,
+			before, auditSnapshot(model.NewAccountResponse(account)))
+		if err != nil {
+			return constant.ErrServer
+		}
+		return nil
+	})
+}
+
+func (s *totpService) RegenerateRecoveryCodes(ctx context.Context, req model.TOTPRecoveryCodesRequest) (*model.TOTPRecoveryCodesResponse, error) {
+	account, err := s.getOwnAccount(ctx, req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.reauthenticate(ctx, account, req.Password, req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	var res *model.TOTPRecoveryCodesResponse
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		res, err = s.replaceRecoveryCodes(ctx, account)
+		if err != nil {
+			return err
+		}
+
+		err = recordAudit(ctx, s.auditRepository, model.AuditActionAccountRecoveryCodes, model.AuditTargetAccount, account.ID, nil, nil)
+		if err != nil {
+			return constant.ErrServer
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// getOwnAccount loads the account of the caller, a personal access token can
+// not manage the factors of its account.
+func (s *totpService) getOwnAccount(ctx context.Context, accountID int64) (*model.Account, error) {
+	if !middleware.IsMe(ctx, accountID) || middleware.IsAccessToken(ctx) {
+		return nil, constant.ErrUnauthorized
+	}
+
+	account, err := s.accountRepository.Get(ctx, accountID)
+	if err != nil {
+		return nil, s.switchErrAccountNotFoundOrErrServer(err)
+	}
+	return account, nil
+}
+
+func (s *totpService) reauthenticate(ctx context.Context, account *model.Account, password, code string) error {
+	if !account.TOTPEnabledAt.Valid {
+		return constant.ErrTOTPNotEnabled
+	}
+
+	err := s.passwordHasher.Verify(password, account.Password)
+	if err != nil {
+		return constant.ErrWrongPassword
+	}
+
+	return verifySecondFactor(ctx, s.tokenRepository, s.recoveryCodeRepository, account, code)
+}
+
+func (s *totpService) replaceRecoveryCodes(ctx context.Context, account *model.Account) (*model.TOTPRecoveryCodesResponse, error) {
+	codes, err := totp.GenerateRecoveryCodes(model.RecoveryCodeCount)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to generate recovery codes")
+		return nil, constant.ErrServer
+	}
+
+	codeHashes := make([]string, len(codes))
+	for i, code := range codes {
+		codeHashes[i] = token.HashOpaqueToken(totp.NormalizeRecoveryCode(code))
+	}
+
+	err = s.recoveryCodeRepository.Replace(ctx, account.ID, codeHashes)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to replace recovery codes")
+		return nil, constant.ErrServer
+	}
+
+	return &model.TOTPRecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+func (s *totpService) switchErrAccountNotFoundOrErrServer(err error) error {
+	switch err {
+	case sql.ErrNoRows:
+		return constant.ErrAccountNotFound
+	default:
+		logger.Log().Err(err).Msg("failed to execute operation account repository")
+		return constant.ErrServer
+	}
+}
+
+// verifySecondFactor accepts either a TOTP code or one of the unused recovery
+// codes of the account, a recovery code is used up by it.
+func verifySecondFactor(ctx context.Context, tokenRepository repository.TokenRepository, recoveryCodeRepository repository.RecoveryCodeRepository, account *model.Account, code string) error {
+	if isTOTPCode(code) {
+		return verifyTOTPCode(ctx, tokenRepository, account, code)
+	}
+
+	err := recoveryCodeRepository.Use(ctx, account.ID, token.HashOpaqueToken(totp.NormalizeRecoveryCode(code)), time.Now())
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return constant.ErrInvalidTOTPCode
+		default:
+			logger.Log().Err(err).Msg("failed to use recovery code")
+			return constant.ErrServer
+		}
+	}
+	return nil
+}
+
+// verifyTOTPCode refuses a code of a time step the account already used, so an
+// observed code can not be replayed within its window.
+func verifyTOTPCode(ctx context.Context, tokenRepository repository.TokenRepository, account *model.Account, code string) error {
+	step, valid := totp.Validate(account.TOTPSecret.String, code, time.Now())
+	if !valid {
+		return constant.ErrInvalidTOTPCode
+	}
+
+	fresh, err := tokenRepository.UseTOTPStep(ctx, account.ID, step)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to use totp step")
+		return constant.ErrServer
+	} else if !fresh {
+		return constant.ErrInvalidTOTPCode
+	}
+	return nil
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != totp.Digits {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
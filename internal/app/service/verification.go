@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+
+	"github.com/osamaesmail/go-post-api/internal/app/repository"
+	"github.com/osamaesmail/go-post-api/internal/config"
+	"github.com/osamaesmail/go-post-api/internal/constant"
+	"github.com/osamaesmail/go-post-api/internal/logger"
+)
+
+// requireVerifiedEmail rejects callers whose email is not verified yet, it
+// lets everyone through when REQUIRE_VERIFIED_EMAIL is off.
+func requireVerifiedEmail(ctx context.Context, accountRepository repository.AccountRepository, accountID int64) error {
+	if !config.Cfg().RequireVerifiedEmail {
+		return nil
+	}
+
+	account, err := accountRepository.Get(ctx, accountID)
+	if err != nil {
+		logger.Log().Err(err).Int64("account_id", accountID).Msg("failed to get account")
+		return constant.ErrServer
+	}
+
+	if !account.EmailVerifiedAt.Valid {
+		return constant.ErrEmailNotVerified
+	}
+	return nil
+}
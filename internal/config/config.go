@@ -1,23 +1,93 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	AppPort int
+	AppPort    int
+	AppBaseURL string
 
 	HttpRateLimitRequest int
 	HttpRateLimitTime    time.Duration
 
-	JwtSecretKey string
-	JwtTTL       time.Duration
+	JwtSigningMethod    string
+	JwtSecretKey        string
+	JwtHS256VerifyUntil time.Time
+	JwtKeyID            string
+	JwtPrivateKeyFile   string
+	JwtPublicKeyFiles   string
+	JwtTTL              time.Duration
+	JwtRefreshTTL       time.Duration
+	JwtImpersonateTTL   time.Duration
+
+	SessionFlushInterval time.Duration
+
+	AccountDeleteGrace   time.Duration
+	AccountPurgePolicy   string
+	AccountPurgeInterval time.Duration
+
+	StorageDriver string
+	StorageDir    string
+
+	AvatarSizes     string
+	AvatarMaxSize   int64
+	AvatarMaxPixels int
+
+	ExportSigningKey   string
+	ExportLinkTTL      time.Duration
+	ExportRetention    time.Duration
+	ExportPollInterval time.Duration
+
+	PostPublishInterval time.Duration
+
+	SearchDriver      string
+	SearchSnippetSize int
+
+	LoginMaxAttempts   int64
+	LoginIPMaxAttempts int64
+	LoginAttemptWindow time.Duration
+	LoginLockout       time.Duration
+	LoginMaxLockout    time.Duration
+
+	OauthProviders map[string]OauthProvider
+	OauthStateTTL  time.Duration
+
+	TotpIssuer        string
+	TotpRequiredRoles string
+	TotpChallengeTTL  time.Duration
 
 	PaginationLimit int
 
+	PasswordHasher            string
+	PasswordBcryptCost        int
+	PasswordArgon2Memory      int
+	PasswordArgon2Time        int
+	PasswordArgon2Parallelism int
+
+	PasswordMinLength      int
+	PasswordRequireClasses string
+	PasswordCheckBreached  bool
+	PasswordBreachedDir    string
+
+	PasswordResetTTL time.Duration
+	PasswordResetURL string
+
+	EmailVerificationTTL time.Duration
+	RequireVerifiedEmail bool
+
+	MailerDriver string
+	MailFrom     string
+	SmtpHost     string
+	SmtpPort     int
+	SmtpUsername string
+	SmtpPassword string
+
 	MysqlUser            string
 	MysqlPassword        string
 	MysqlHost            string
@@ -49,27 +119,110 @@ func load() Config {
 	fang.ReadInConfig()
 
 	return Config{
-		AppPort:              fang.GetInt("APP_PORT"),
-		HttpRateLimitRequest: fang.GetInt("HTTP_RATE_LIMIT_REQUEST"),
-		HttpRateLimitTime:    fang.GetDuration("HTTP_RATE_LIMIT_TIME"),
-		JwtSecretKey:         fang.GetString("JWT_SECRET_KEY"),
-		JwtTTL:               fang.GetDuration("JWT_TTL"),
-		PaginationLimit:      fang.GetInt("PAGINATION_LIMIT"),
-		MysqlUser:            fang.GetString("MYSQL_USER"),
-		MysqlPassword:        fang.GetString("MYSQL_PASSWORD"),
-		MysqlHost:            fang.GetString("MYSQL_HOST"),
-		MysqlPort:            fang.GetInt("MYSQL_PORT"),
-		MysqlDatabase:        fang.GetString("MYSQL_DATABASE"),
-		MysqlMaxIdleConns:    fang.GetInt("MYSQL_MAX_IDLE_CONNS"),
-		MysqlMaxOpenConns:    fang.GetInt("MYSQL_MAX_OPEN_CONNS"),
-		MysqlConnMaxLifetime: fang.GetDuration("MYSQL_CONN_MAX_LIFETIME"),
-		RedisPassword:        fang.GetString("REDIS_PASSWORD"),
-		RedisHost:            fang.GetString("REDIS_HOST"),
-		RedisPort:            fang.GetInt("REDIS_PORT"),
-		RedisDatabase:        fang.GetInt("REDIS_DATABASE"),
-		RedisPoolSize:        fang.GetInt("REDIS_POOL_SIZE"),
-		RedisTTL:             fang.GetDuration("REDIS_TTL"),
+		AppPort:                   fang.GetInt("APP_PORT"),
+		AppBaseURL:                fang.GetString("APP_BASE_URL"),
+		HttpRateLimitRequest:      fang.GetInt("HTTP_RATE_LIMIT_REQUEST"),
+		HttpRateLimitTime:         fang.GetDuration("HTTP_RATE_LIMIT_TIME"),
+		JwtSigningMethod:          fang.GetString("JWT_SIGNING_METHOD"),
+		JwtSecretKey:              fang.GetString("JWT_SECRET_KEY"),
+		JwtHS256VerifyUntil:       fang.GetTime("JWT_HS256_VERIFY_UNTIL"),
+		JwtKeyID:                  fang.GetString("JWT_KEY_ID"),
+		JwtPrivateKeyFile:         fang.GetString("JWT_PRIVATE_KEY_FILE"),
+		JwtPublicKeyFiles:         fang.GetString("JWT_PUBLIC_KEY_FILES"),
+		JwtTTL:                    fang.GetDuration("JWT_TTL"),
+		JwtRefreshTTL:             fang.GetDuration("JWT_REFRESH_TTL"),
+		JwtImpersonateTTL:         fang.GetDuration("JWT_IMPERSONATE_TTL"),
+		SessionFlushInterval:      fang.GetDuration("SESSION_FLUSH_INTERVAL"),
+		AccountDeleteGrace:        fang.GetDuration("ACCOUNT_DELETE_GRACE"),
+		AccountPurgePolicy:        fang.GetString("ACCOUNT_PURGE_POLICY"),
+		AccountPurgeInterval:      fang.GetDuration("ACCOUNT_PURGE_INTERVAL"),
+		StorageDriver:             fang.GetString("STORAGE_DRIVER"),
+		StorageDir:                fang.GetString("STORAGE_DIR"),
+		AvatarSizes:               fang.GetString("AVATAR_SIZES"),
+		AvatarMaxSize:             fang.GetInt64("AVATAR_MAX_SIZE"),
+		AvatarMaxPixels:           fang.GetInt("AVATAR_MAX_PIXELS"),
+		ExportSigningKey:          fang.GetString("EXPORT_SIGNING_KEY"),
+		ExportLinkTTL:             fang.GetDuration("EXPORT_LINK_TTL"),
+		ExportRetention:           fang.GetDuration("EXPORT_RETENTION"),
+		ExportPollInterval:        fang.GetDuration("EXPORT_POLL_INTERVAL"),
+		PostPublishInterval:       fang.GetDuration("POST_PUBLISH_INTERVAL"),
+		SearchDriver:              fang.GetString("SEARCH_DRIVER"),
+		SearchSnippetSize:         fang.GetInt("SEARCH_SNIPPET_SIZE"),
+		LoginMaxAttempts:          fang.GetInt64("LOGIN_MAX_ATTEMPTS"),
+		LoginIPMaxAttempts:        fang.GetInt64("LOGIN_IP_MAX_ATTEMPTS"),
+		LoginAttemptWindow:        fang.GetDuration("LOGIN_ATTEMPT_WINDOW"),
+		LoginLockout:              fang.GetDuration("LOGIN_LOCKOUT"),
+		LoginMaxLockout:           fang.GetDuration("LOGIN_MAX_LOCKOUT"),
+		OauthProviders:            loadOauthProviders(fang),
+		OauthStateTTL:             fang.GetDuration("OAUTH_STATE_TTL"),
+		TotpIssuer:                fang.GetString("TOTP_ISSUER"),
+		TotpRequiredRoles:         fang.GetString("TOTP_REQUIRED_ROLES"),
+		TotpChallengeTTL:          fang.GetDuration("TOTP_CHALLENGE_TTL"),
+		PaginationLimit:           fang.GetInt("PAGINATION_LIMIT"),
+		PasswordHasher:            fang.GetString("PASSWORD_HASHER"),
+		PasswordBcryptCost:        fang.GetInt("PASSWORD_BCRYPT_COST"),
+		PasswordArgon2Memory:      fang.GetInt("PASSWORD_ARGON2_MEMORY"),
+		PasswordArgon2Time:        fang.GetInt("PASSWORD_ARGON2_TIME"),
+		PasswordArgon2Parallelism: fang.GetInt("PASSWORD_ARGON2_PARALLELISM"),
+		PasswordMinLength:         fang.GetInt("PASSWORD_MIN_LENGTH"),
+		PasswordRequireClasses:    fang.GetString("PASSWORD_REQUIRE_CLASSES"),
+		PasswordCheckBreached:     fang.GetBool("PASSWORD_CHECK_BREACHED"),
+		PasswordBreachedDir:       fang.GetString("PASSWORD_BREACHED_DIR"),
+		PasswordResetTTL:          fang.GetDuration("PASSWORD_RESET_TTL"),
+		PasswordResetURL:          fang.GetString("PASSWORD_RESET_URL"),
+		EmailVerificationTTL:      fang.GetDuration("EMAIL_VERIFICATION_TTL"),
+		RequireVerifiedEmail:      fang.GetBool("REQUIRE_VERIFIED_EMAIL"),
+		MailerDriver:              fang.GetString("MAILER_DRIVER"),
+		MailFrom:                  fang.GetString("MAIL_FROM"),
+		SmtpHost:                  fang.GetString("SMTP_HOST"),
+		SmtpPort:                  fang.GetInt("SMTP_PORT"),
+		SmtpUsername:              fang.GetString("SMTP_USERNAME"),
+		SmtpPassword:              fang.GetString("SMTP_PASSWORD"),
+		MysqlUser:                 fang.GetString("MYSQL_USER"),
+		MysqlPassword:             fang.GetString("MYSQL_PASSWORD"),
+		MysqlHost:                 fang.GetString("MYSQL_HOST"),
+		MysqlPort:                 fang.GetInt("MYSQL_PORT"),
+		MysqlDatabase:             fang.GetString("MYSQL_DATABASE"),
+		MysqlMaxIdleConns:         fang.GetInt("MYSQL_MAX_IDLE_CONNS"),
+		MysqlMaxOpenConns:         fang.GetInt("MYSQL_MAX_OPEN_CONNS"),
+		MysqlConnMaxLifetime:      fang.GetDuration("MYSQL_CONN_MAX_LIFETIME"),
+		RedisPassword:             fang.GetString("REDIS_PASSWORD"),
+		RedisHost:                 fang.GetString("REDIS_HOST"),
+		RedisPort:                 fang.GetInt("REDIS_PORT"),
+		RedisDatabase:             fang.GetInt("REDIS_DATABASE"),
+		RedisPoolSize:             fang.GetInt("REDIS_POOL_SIZE"),
+		RedisTTL:                  fang.GetDuration("REDIS_TTL"),
+	}
+}
+
+// OauthProvider is an OpenID Connect provider, Scopes is comma separated and
+// openid is always requested.
+type OauthProvider struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       string
+}
+
+// loadOauthProviders reads OAUTH_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET and
+// _SCOPES for every name in the comma separated OAUTH_PROVIDERS.
+func loadOauthProviders(fang *viper.Viper) map[string]OauthProvider {
+	providers := map[string]OauthProvider{}
+	for _, name := range strings.Split(fang.GetString("OAUTH_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := fmt.Sprintf("OAUTH_%s_", strings.ToUpper(name))
+		providers[name] = OauthProvider{
+			Issuer:       fang.GetString(prefix + "ISSUER"),
+			ClientID:     fang.GetString(prefix + "CLIENT_ID"),
+			ClientSecret: fang.GetString(prefix + "CLIENT_SECRET"),
+			Scopes:       fang.GetString(prefix + "SCOPES"),
+		}
 	}
+	return providers
 }
 
 var config = load()
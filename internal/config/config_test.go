@@ -12,7 +12,41 @@ func TestConfig(t *testing.T) {
 	assert.NotEmpty(t, Cfg().HttpRateLimitTime, "HTTP_RATE_LIMIT_TIME")
 	assert.NotEmpty(t, Cfg().JwtSecretKey, "JWT_SECRET_KEY")
 	assert.NotEmpty(t, Cfg().JwtTTL, "JWT_TTL")
+	assert.NotEmpty(t, Cfg().JwtRefreshTTL, "JWT_REFRESH_TTL")
+	assert.NotEmpty(t, Cfg().JwtImpersonateTTL, "JWT_IMPERSONATE_TTL")
+	assert.NotEmpty(t, Cfg().SessionFlushInterval, "SESSION_FLUSH_INTERVAL")
+	assert.NotEmpty(t, Cfg().AccountDeleteGrace, "ACCOUNT_DELETE_GRACE")
+	assert.NotEmpty(t, Cfg().AccountPurgePolicy, "ACCOUNT_PURGE_POLICY")
+	assert.NotEmpty(t, Cfg().AccountPurgeInterval, "ACCOUNT_PURGE_INTERVAL")
+	assert.NotEmpty(t, Cfg().StorageDriver, "STORAGE_DRIVER")
+	assert.NotEmpty(t, Cfg().StorageDir, "STORAGE_DIR")
+	assert.NotEmpty(t, Cfg().AvatarSizes, "AVATAR_SIZES")
+	assert.NotEmpty(t, Cfg().AvatarMaxSize, "AVATAR_MAX_SIZE")
+	assert.NotEmpty(t, Cfg().AvatarMaxPixels, "AVATAR_MAX_PIXELS")
+	assert.NotEmpty(t, Cfg().ExportSigningKey, "EXPORT_SIGNING_KEY")
+	assert.NotEmpty(t, Cfg().ExportLinkTTL, "EXPORT_LINK_TTL")
+	assert.NotEmpty(t, Cfg().ExportRetention, "EXPORT_RETENTION")
+	assert.NotEmpty(t, Cfg().ExportPollInterval, "EXPORT_POLL_INTERVAL")
+	assert.NotEmpty(t, Cfg().PostPublishInterval, "POST_PUBLISH_INTERVAL")
+	assert.NotEmpty(t, Cfg().SearchDriver, "SEARCH_DRIVER")
+	assert.NotEmpty(t, Cfg().SearchSnippetSize, "SEARCH_SNIPPET_SIZE")
+	assert.NotZero(t, Cfg().LoginMaxAttempts, "LOGIN_MAX_ATTEMPTS")
+	assert.NotZero(t, Cfg().LoginIPMaxAttempts, "LOGIN_IP_MAX_ATTEMPTS")
+	assert.NotEmpty(t, Cfg().LoginAttemptWindow, "LOGIN_ATTEMPT_WINDOW")
+	assert.NotEmpty(t, Cfg().LoginLockout, "LOGIN_LOCKOUT")
+	assert.NotEmpty(t, Cfg().LoginMaxLockout, "LOGIN_MAX_LOCKOUT")
+	assert.NotEmpty(t, Cfg().OauthStateTTL, "OAUTH_STATE_TTL")
+	assert.NotEmpty(t, Cfg().TotpIssuer, "TOTP_ISSUER")
+	assert.NotEmpty(t, Cfg().TotpChallengeTTL, "TOTP_CHALLENGE_TTL")
 	assert.NotZero(t, Cfg().PaginationLimit, "PAGINATION_LIMIT")
+	assert.NotEmpty(t, Cfg().PasswordHasher, "PASSWORD_HASHER")
+	assert.NotZero(t, Cfg().PasswordArgon2Memory, "PASSWORD_ARGON2_MEMORY")
+	assert.NotZero(t, Cfg().PasswordArgon2Time, "PASSWORD_ARGON2_TIME")
+	assert.NotZero(t, Cfg().PasswordArgon2Parallelism, "PASSWORD_ARGON2_PARALLELISM")
+	assert.NotZero(t, Cfg().PasswordMinLength, "PASSWORD_MIN_LENGTH")
+	assert.NotEmpty(t, Cfg().PasswordResetTTL, "PASSWORD_RESET_TTL")
+	assert.NotEmpty(t, Cfg().EmailVerificationTTL, "EMAIL_VERIFICATION_TTL")
+	assert.NotEmpty(t, Cfg().MailFrom, "MAIL_FROM")
 	assert.NotEmpty(t, Cfg().MysqlUser, "MYSQL_USER")
 	assert.NotEmpty(t, Cfg().MysqlPassword, "MYSQL_PASSWORD")
 	assert.NotEmpty(t, Cfg().MysqlHost, "MYSQL_HOST")
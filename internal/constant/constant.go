@@ -1,5 +1,6 @@
 package constant
 
 const (
-	API_KEY_HEADER = "X-API-Key"
+	API_KEY_HEADER      = "X-API-Key"
+	ACCESS_TOKEN_PREFIX = "gpa_"
 )
@@ -14,16 +14,52 @@ var (
 	ErrUrlQueryParameter = errors.New("Invalid url query parameter")
 	ErrRequestBody       = errors.New("Invalid request body")
 	ErrUnauthorized      = errors.New("You are not authorized to perform this action")
+	ErrForbidden         = errors.New("Your role does not allow this action")
 	ErrFieldValidation   = errors.New("Field is not valid")
+	ErrImpersonating     = errors.New("This action is not allowed while impersonating")
 
 	ErrAccountNotFound    = errors.New("Account not found")
 	ErrEmailRegistered    = errors.New("Email already in use")
-	ErrEmailNotRegistered = errors.New("Email not registered")
+	ErrHandleTaken        = errors.New("Handle already in use")
+	ErrInvalidAvatar      = errors.New("Avatar has to be a JPEG, PNG or GIF image")
+	ErrAvatarTooLarge     = errors.New("Avatar exceeds the file size or pixel limit")
+	ErrAvatarNotFound     = errors.New("Avatar not found")
+	ErrAvatarURLNotOwn    = errors.New("Avatar url points at the avatar of another account")
 	ErrWrongPassword      = errors.New("Password incorrect")
+	ErrPasswordPolicy     = errors.New("Password does not meet the password policy")
+	ErrInvalidCredentials = errors.New("Email or password incorrect")
+	ErrTooManyAttempts    = errors.New("Too many failed login attempts, try again later")
 
-	ErrPostNotFound = errors.New("Post not found")
+	ErrInvalidRefreshToken = errors.New("Refresh token is invalid or expired")
+	ErrInvalidResetToken   = errors.New("Password reset token is invalid or expired")
+	ErrInvalidVerifyToken  = errors.New("Verification token is invalid or expired")
+	ErrEmailNotVerified    = errors.New("Email has to be verified first")
+	ErrEmailVerified       = errors.New("Email is already verified")
+	ErrInvalidChallenge    = errors.New("Login challenge is invalid or expired")
+	ErrInvalidTOTPCode     = errors.New("Two-factor code is invalid")
+	ErrTOTPEnabled         = errors.New("Two-factor authentication is already enabled")
+	ErrTOTPNotEnabled      = errors.New("Two-factor authentication is not enabled")
+	ErrTOTPNotEnrolled     = errors.New("Two-factor authentication enrollment was not started")
+
+	ErrOauthProviderNotFound  = errors.New("OAuth provider not found")
+	ErrInvalidOauthState      = errors.New("OAuth state is invalid or expired")
+	ErrOauthDenied            = errors.New("Signing in with the provider failed")
+	ErrOauthEmailNotVerified  = errors.New("The provider did not share a verified email")
+	ErrOauthAccountUnverified = errors.New("An account with this email exists, verify its email before signing in with a provider")
+
+	ErrAccessTokenNotFound = errors.New("Access token not found")
+	ErrSessionNotFound     = errors.New("Session not found")
+	ErrInsufficientScope   = errors.New("Access token scopes do not allow this action")
+
+	ErrPostNotFound     = errors.New("Post not found")
+	ErrInvalidPublishAt = errors.New("Scheduled posts need a publish_at in the future")
 
 	ErrCommentNotFound = errors.New("Comment not found")
+
+	ErrTagNotFound = errors.New("Tag not found")
+
+	ErrExportNotFound    = errors.New("Export not found")
+	ErrInvalidExportLink = errors.New("Export link is invalid or expired")
 )
 
 func NewErrFieldValidation(err validator.FieldError) error {
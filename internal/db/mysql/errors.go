@@ -0,0 +1,23 @@
+package mysql
+
+import (
+	"errors"
+	"strings"
+
+	driver "github.com/go-sql-driver/mysql"
+)
+
+// errDupEntry is the number of the error MySQL returns when a write would
+// break a unique index.
+const errDupEntry = 1062
+
+// IsDuplicate reports whether err is a write breaking the unique index named
+// index. MySQL 8 names the index along with its table.
+func IsDuplicate(err error, index string) bool {
+	var mysqlErr *driver.MySQLError
+	if !errors.As(err, &mysqlErr) || mysqlErr.Number != errDupEntry {
+		return false
+	}
+	return strings.HasSuffix(mysqlErr.Message, "key '"+index+"'") ||
+		strings.HasSuffix(mysqlErr.Message, "."+index+"'")
+}
@@ -0,0 +1,29 @@
+package mysql
+
+import (
+	"errors"
+	"testing"
+
+	driver "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsDuplicate(t *testing.T) {
+	t.Run("index", func(t *testing.T) {
+		err := &driver.MySQLError{Number: 1062, Message: "Duplicate entry 'hello' for key 'post_slug'"}
+		assert.True(t, IsDuplicate(err, "post_slug"))
+		assert.False(t, IsDuplicate(err, "slug"))
+	})
+
+	t.Run("index with its table", func(t *testing.T) {
+		err := &driver.MySQLError{Number: 1062, Message: "Duplicate entry 'hello' for key 'post_slug_history.slug'"}
+		assert.True(t, IsDuplicate(err, "slug"))
+		assert.False(t, IsDuplicate(err, "post_slug"))
+	})
+
+	t.Run("other error", func(t *testing.T) {
+		assert.False(t, IsDuplicate(&driver.MySQLError{Number: 1452, Message: "Cannot add or update a child row"}, "slug"))
+		assert.False(t, IsDuplicate(errors.New("Duplicate entry 'hello' for key 'slug'"), "slug"))
+		assert.False(t, IsDuplicate(nil, "slug"))
+	})
+}
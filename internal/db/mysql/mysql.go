@@ -1,6 +1,7 @@
 package mysql
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
@@ -10,6 +11,9 @@ import (
 
 type Client interface {
 	Conn() *sql.DB
+	Executor(ctx context.Context) Executor
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+	AfterCommit(ctx context.Context, fn func(ctx context.Context) error) error
 	Close() error
 }
 
@@ -0,0 +1,86 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/osamaesmail/go-post-api/internal/logger"
+)
+
+// Executor is what *sql.DB and *sql.Tx have in common.
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type txKey struct{}
+
+// transaction is what a transaction carries in ctx.
+type transaction struct {
+	tx          *sql.Tx
+	afterCommit []func(ctx context.Context) error
+}
+
+// Executor returns the transaction ctx carries, or the pool outside of one.
+func (c *client) Executor(ctx context.Context) Executor {
+	t, valid := ctx.Value(txKey{}).(*transaction)
+	if valid {
+		return t.tx
+	}
+	return c.db
+}
+
+// Transaction runs fn in a transaction that repositories pick up through
+// Executor, it is committed when fn returns nil. Nested calls join the
+// transaction already in ctx.
+func (c *client) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, valid := ctx.Value(txKey{}).(*transaction); valid {
+		return fn(ctx)
+	}
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	t := &transaction{tx: tx}
+	err = fn(context.WithValue(ctx, txKey{}, t))
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	// the writes are done, a failing hook can only be logged
+	for _, hook := range t.afterCommit {
+		err := hook(ctx)
+		if err != nil {
+			logger.Log().Err(err).Msg("failed to run after commit")
+		}
+	}
+	return nil
+}
+
+// InTransaction reports whether ctx carries a transaction.
+func InTransaction(ctx context.Context) bool {
+	_, valid := ctx.Value(txKey{}).(*transaction)
+	return valid
+}
+
+// AfterCommit runs fn once the transaction in ctx is committed, not at all
+// when it is rolled back. Outside of a transaction fn runs right away and its
+// error is returned.
+func (c *client) AfterCommit(ctx context.Context, fn func(ctx context.Context) error) error {
+	t, valid := ctx.Value(txKey{}).(*transaction)
+	if !valid {
+		return fn(ctx)
+	}
+
+	t.afterCommit = append(t.afterCommit, fn)
+	return nil
+}
@@ -0,0 +1,173 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/osamaesmail/go-post-api/internal/config"
+	"golang.org/x/image/draw"
+)
+
+// maxAvatarSize keeps AVATAR_SIZES from asking for variants larger than any
+// avatar is shown.
+const maxAvatarSize = 2048
+
+var (
+	ErrUnsupportedFormat = errors.New("image format is not supported")
+	ErrTooManyPixels     = errors.New("image has too many pixels")
+)
+
+// Variant is an avatar scaled to Size by Size pixels and encoded.
+type Variant struct {
+	Size int
+	Data []byte
+}
+
+// AvatarProcessor turns uploads into square avatars of every size in
+// AVATAR_SIZES. The variants are encoded from the pixels alone so EXIF and any
+// other metadata of the upload is left behind.
+type AvatarProcessor struct {
+	sizes     []int
+	maxPixels int
+}
+
+func NewAvatarProcessor(cfg *config.Config) (*AvatarProcessor, error) {
+	p := &AvatarProcessor{maxPixels: cfg.AvatarMaxPixels}
+	for _, s := range strings.Split(cfg.AvatarSizes, ",") {
+		size, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || size <= 0 || size > maxAvatarSize {
+			return nil, fmt.Errorf("invalid avatar size %q", s)
+		}
+		p.sizes = append(p.sizes, size)
+	}
+	sort.Ints(p.sizes)
+
+	if p.maxPixels <= 0 {
+		return nil, fmt.Errorf("invalid avatar max pixels %d", p.maxPixels)
+	}
+	return p, nil
+}
+
+// Sizes returns the sizes of the variants, the smallest first.
+func (p *AvatarProcessor) Sizes() []int {
+	return p.sizes
+}
+
+func (p *AvatarProcessor) HasSize(size int) bool {
+	for _, s := range p.sizes {
+		if s == size {
+			return true
+		}
+	}
+	return false
+}
+
+// Process decodes the upload by what its bytes say it is, a JPEG, PNG or GIF,
+// and returns its variants. An image larger than AVATAR_MAX_PIXELS is refused
+// before its pixels are decoded.
+func (p *AvatarProcessor) Process(r io.ReadSeeker) ([]Variant, error) {
+	format, err := sniff(r)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := format.decodeConfig(r)
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	} else if cfg.Width*cfg.Height > p.maxPixels || cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, ErrTooManyPixels
+	}
+
+	_, err = r.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	img, err := format.decode(r)
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+
+	orientation := 1
+	if format.name == "jpeg" {
+		_, err = r.Seek(0, io.SeekStart)
+		if err != nil {
+			return nil, err
+		}
+		orientation = readOrientation(r)
+	}
+
+	variants := make([]Variant, 0, len(p.sizes))
+	for _, size := range p.sizes {
+		var buf bytes.Buffer
+		err = format.encode(&buf, orient(square(img, size), orientation))
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, Variant{Size: size, Data: buf.Bytes()})
+	}
+	return variants, nil
+}
+
+type imageFormat struct {
+	name         string
+	decodeConfig func(r io.Reader) (image.Config, error)
+	decode       func(r io.Reader) (image.Image, error)
+	encode       func(w io.Writer, img image.Image) error
+}
+
+func encodePNG(w io.Writer, img image.Image) error {
+	return png.Encode(w, img)
+}
+
+func encodeJPEG(w io.Writer, img image.Image) error {
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: 90})
+}
+
+// formats by the content type http.DetectContentType sniffs, GIFs lose their
+// animation and become PNGs.
+var formats = map[string]imageFormat{
+	"image/jpeg": {"jpeg", jpeg.DecodeConfig, jpeg.Decode, encodeJPEG},
+	"image/png":  {"png", png.DecodeConfig, png.Decode, encodePNG},
+	"image/gif":  {"gif", gif.DecodeConfig, gif.Decode, encodePNG},
+}
+
+func sniff(r io.ReadSeeker) (imageFormat, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return imageFormat{}, ErrUnsupportedFormat
+	}
+
+	format, ok := formats[http.DetectContentType(head[:n])]
+	if !ok {
+		return imageFormat{}, ErrUnsupportedFormat
+	}
+
+	_, err = r.Seek(0, io.SeekStart)
+	return format, err
+}
+
+// square crops the middle of img to a square and scales it to size.
+func square(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	side := b.Dx()
+	if b.Dy() < side {
+		side = b.Dy()
+	}
+	x := b.Min.X + (b.Dx()-side)/2
+	y := b.Min.Y + (b.Dy()-side)/2
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, image.Rect(x, y, x+side, y+side), draw.Src, nil)
+	return dst
+}
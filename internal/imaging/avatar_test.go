@@ -0,0 +1,93 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/osamaesmail/go-post-api/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 0, 255})
+		}
+	}
+	return img
+}
+
+func TestAvatarProcessor(t *testing.T) {
+	p, err := NewAvatarProcessor(&config.Config{AvatarSizes: "128, 64", AvatarMaxPixels: 300 * 200})
+	require.NoError(t, err)
+	assert.Equal(t, []int{64, 128}, p.Sizes())
+	assert.True(t, p.HasSize(64))
+	assert.False(t, p.HasSize(512))
+
+	var upload bytes.Buffer
+	require.NoError(t, png.Encode(&upload, testImage(300, 200)))
+	pngUpload := upload.Bytes()
+
+	variants, err := p.Process(bytes.NewReader(pngUpload))
+	require.NoError(t, err)
+	require.Len(t, variants, 2)
+	for i, size := range []int{64, 128} {
+		assert.Equal(t, size, variants[i].Size)
+		img, format, err := image.Decode(bytes.NewReader(variants[i].Data))
+		require.NoError(t, err)
+		assert.Equal(t, "png", format)
+		assert.Equal(t, image.Rect(0, 0, size, size), img.Bounds())
+	}
+
+	upload = bytes.Buffer{}
+	require.NoError(t, jpeg.Encode(&upload, testImage(301, 200), nil))
+	_, err = p.Process(bytes.NewReader(upload.Bytes()))
+	assert.Equal(t, ErrTooManyPixels, err)
+
+	_, err = p.Process(bytes.NewReader([]byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>")))
+	assert.Equal(t, ErrUnsupportedFormat, err)
+
+	// a PNG header with a broken body
+	_, err = p.Process(bytes.NewReader(pngUpload[:100]))
+	assert.Equal(t, ErrUnsupportedFormat, err)
+
+	_, err = NewAvatarProcessor(&config.Config{AvatarSizes: "64,big", AvatarMaxPixels: 1})
+	assert.Error(t, err)
+}
+
+func TestOrientation(t *testing.T) {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08\x00\x01")
+	entry := make([]byte, 12)
+	binary.BigEndian.PutUint16(entry, exifOrientationTag)
+	binary.BigEndian.PutUint16(entry[2:], 3)
+	binary.BigEndian.PutUint32(entry[4:], 1)
+	binary.BigEndian.PutUint16(entry[8:], 6)
+	tiff = append(tiff, entry...)
+	assert.Equal(t, 6, exifOrientation(tiff))
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	length := make([]byte, 2)
+	binary.BigEndian.PutUint16(length, uint16(len(segment)+2))
+	jpg := append([]byte{0xFF, 0xD8, 0xFF, 0xE1}, length...)
+	jpg = append(jpg, segment...)
+	jpg = append(jpg, 0xFF, 0xDA)
+	assert.Equal(t, 6, readOrientation(bytes.NewReader(jpg)))
+
+	assert.Equal(t, 1, readOrientation(bytes.NewReader([]byte{0xFF, 0xD8, 0xFF, 0xDA})))
+	assert.Equal(t, 1, exifOrientation(tiff[:12]))
+
+	// rotating 90 degrees clockwise moves the top left pixel to the top right
+	img := testImage(3, 2)
+	rotated := orient(img, 6)
+	assert.Equal(t, image.Rect(0, 0, 2, 3), rotated.Bounds())
+	assert.Equal(t, img.At(0, 0), rotated.At(1, 0))
+	assert.Equal(t, img.At(0, 1), rotated.At(0, 0))
+	assert.Equal(t, img, orient(img, 1))
+}
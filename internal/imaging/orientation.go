@@ -0,0 +1,128 @@
+package imaging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"image"
+	"io"
+)
+
+// exifOrientationTag tells how a camera held sideways stored the pixels, the
+// encoded variants drop it so it has to be applied to them instead.
+const exifOrientationTag = 0x0112
+
+// readOrientation returns the EXIF orientation of a JPEG, 1 when it has none.
+func readOrientation(r io.Reader) int {
+	br := bufio.NewReader(r)
+
+	var marker [2]byte
+	_, err := io.ReadFull(br, marker[:])
+	if err != nil || marker != [2]byte{0xFF, 0xD8} {
+		return 1
+	}
+
+	for {
+		_, err = io.ReadFull(br, marker[:])
+		if err != nil || marker[0] != 0xFF || marker[1] == 0xDA || marker[1] == 0xD9 {
+			// the metadata segments all come before the start of scan
+			return 1
+		}
+
+		var length uint16
+		err = binary.Read(br, binary.BigEndian, &length)
+		if err != nil || length < 2 {
+			return 1
+		}
+
+		segment := make([]byte, length-2)
+		_, err = io.ReadFull(br, segment)
+		if err != nil {
+			return 1
+		}
+
+		if marker[1] == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+	}
+}
+
+// exifOrientation looks the orientation up in the first IFD of the TIFF
+// structure an EXIF segment holds.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	offset := int64(order.Uint32(tiff[4:8]))
+	if offset+2 > int64(len(tiff)) {
+		return 1
+	}
+
+	count := int64(order.Uint16(tiff[offset:]))
+	for i := int64(0); i < count; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > int64(len(tiff)) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) != exifOrientationTag {
+			continue
+		}
+
+		orientation := int(order.Uint16(tiff[entry+8:]))
+		if orientation < 1 || orientation > 8 {
+			return 1
+		}
+		return orientation
+	}
+	return 1
+}
+
+// orient flips and rotates img the way the EXIF orientation says to display
+// it.
+func orient(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if orientation >= 5 {
+		w, h = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			dx, dy := x, y
+			switch orientation {
+			case 2:
+				dx = w - 1 - x
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dy = h - 1 - y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = w-1-y, x
+			case 7:
+				dx, dy = w-1-y, h-1-x
+			case 8:
+				dx, dy = y, h-1-x
+			}
+			dst.Set(dx, dy, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
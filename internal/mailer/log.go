@@ -0,0 +1,20 @@
+package mailer
+
+import (
+	"context"
+
+	"github.com/osamaesmail/go-post-api/internal/logger"
+)
+
+// NewLogMailer writes every message to the log instead of sending it, meant
+// for local development.
+func NewLogMailer() Mailer {
+	return &logMailer{}
+}
+
+type logMailer struct{}
+
+func (m *logMailer) Send(ctx context.Context, msg Message) error {
+	logger.Log().Info().Str("to", msg.To).Str("subject", msg.Subject).Msg(msg.Body)
+	return nil
+}
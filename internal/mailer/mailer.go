@@ -0,0 +1,38 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/osamaesmail/go-post-api/internal/config"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewMailer picks the implementation named by MAILER_DRIVER.
+func NewMailer() (Mailer, error) {
+	switch config.Cfg().MailerDriver {
+	case "smtp":
+		return NewSMTPMailer(
+			config.Cfg().SmtpHost,
+			config.Cfg().SmtpPort,
+			config.Cfg().SmtpUsername,
+			config.Cfg().SmtpPassword,
+			config.Cfg().MailFrom,
+		), nil
+	case "", "log":
+		return NewLogMailer(), nil
+	case "memory":
+		return NewMemoryMailer(), nil
+	default:
+		return nil, fmt.Errorf("unsupported mailer driver %s", config.Cfg().MailerDriver)
+	}
+}
@@ -0,0 +1,40 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/smtp"
+	"time"
+)
+
+func NewSMTPMailer(host string, port int, username, password, from string) Mailer {
+	return &smtpMailer{host, port, username, password, from}
+}
+
+type smtpMailer struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+}
+
+func (m *smtpMailer) Send(ctx context.Context, msg Message) error {
+	// servers like mailhog accept mail without authentication
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "From: %s\r\n", m.from)
+	fmt.Fprintf(&body, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&body, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&body, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&body, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&body, "Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	body.WriteString(msg.Body)
+
+	return smtp.SendMail(fmt.Sprintf("%s:%d", m.host, m.port), auth, m.from, []string{msg.To}, body.Bytes())
+}
@@ -0,0 +1,174 @@
+package render
+
+import (
+	"html"
+	"strings"
+)
+
+// The classes Highlight wraps tokens in, Sanitize keeps no others on a span.
+const (
+	ClassKeyword = "hl-keyword"
+	ClassString  = "hl-string"
+	ClassComment = "hl-comment"
+	ClassNumber  = "hl-number"
+)
+
+// language is what Highlight needs to know to tokenize code, it does not parse
+// it.
+type language struct {
+	keywords        map[string]bool
+	caseInsensitive bool
+	lineComment     string
+	blockComment    [2]string
+	quotes          string
+}
+
+func newLanguage(keywords, lineComment, blockStart, blockEnd, quotes string) *language {
+	l := &language{
+		keywords:     make(map[string]bool),
+		lineComment:  lineComment,
+		blockComment: [2]string{blockStart, blockEnd},
+		quotes:       quotes,
+	}
+	for _, keyword := range strings.Fields(keywords) {
+		l.keywords[keyword] = true
+	}
+	return l
+}
+
+var (
+	goLanguage = newLanguage(`break case chan const continue default defer else fallthrough for func go goto
+		if import interface map package range return select struct switch type var true false nil iota`,
+		"//", "/*", "*/", "\"'`")
+	javascriptLanguage = newLanguage(`async await break case catch class const continue debugger default delete do
+		else enum export extends finally for function if implements import in instanceof interface let new of
+		return super switch this throw try type typeof var void while with yield true false null undefined`,
+		"//", "/*", "*/", "\"'`")
+	pythonLanguage = newLanguage(`and as assert async await break class continue def del elif else except finally
+		for from global if import in is lambda nonlocal not or pass raise return try while with yield True False None`,
+		"#", "", "", `"'`)
+	shellLanguage = newLanguage(`case do done elif else esac export fi for function if in local return then until while`,
+		"#", "", "", `"'`)
+	sqlLanguage = func() *language {
+		l := newLanguage(`select from where and or not insert into values update set delete create table alter drop
+			add column index unique primary key foreign references join left right inner outer on group by order
+			having limit offset as distinct union all null is in like between case when then else end exists default`,
+			"--", "/*", "*/", `'"`)
+		l.caseInsensitive = true
+		return l
+	}()
+	jsonLanguage = newLanguage(`true false null`, "", "", "", `"`)
+)
+
+// languages are the code fence languages Highlight knows, by their names and
+// aliases.
+var languages = map[string]*language{
+	"go":         goLanguage,
+	"golang":     goLanguage,
+	"javascript": javascriptLanguage,
+	"js":         javascriptLanguage,
+	"typescript": javascriptLanguage,
+	"ts":         javascriptLanguage,
+	"python":     pythonLanguage,
+	"py":         pythonLanguage,
+	"bash":       shellLanguage,
+	"sh":         shellLanguage,
+	"shell":      shellLanguage,
+	"sql":        sqlLanguage,
+	"mysql":      sqlLanguage,
+	"json":       jsonLanguage,
+}
+
+// Highlight escapes code and wraps its keywords, strings, comments and numbers
+// in spans with the Class constants, code in a language it does not know is
+// only escaped.
+func Highlight(lang, code string) string {
+	l := languages[lang]
+	if l == nil {
+		return html.EscapeString(code)
+	}
+
+	var b strings.Builder
+	plain := 0
+	for i := 0; i < len(code); {
+		class, end := l.token(code, i)
+		if class == "" {
+			i = end
+			continue
+		}
+
+		b.WriteString(html.EscapeString(code[plain:i]))
+		b.WriteString(`<span class="` + class + `">`)
+		b.WriteString(html.EscapeString(code[i:end]))
+		b.WriteString(`</span>`)
+		i, plain = end, end
+	}
+	b.WriteString(html.EscapeString(code[plain:]))
+	return b.String()
+}
+
+// token returns the class of the token code holds at i and where the token
+// ends, no class for text left as it is.
+func (l *language) token(code string, i int) (string, int) {
+	rest := code[i:]
+	switch {
+	case l.lineComment != "" && strings.HasPrefix(rest, l.lineComment):
+		if end := strings.IndexByte(rest, '\n'); end >= 0 {
+			return ClassComment, i + end
+		}
+		return ClassComment, len(code)
+	case l.blockComment[0] != "" && strings.HasPrefix(rest, l.blockComment[0]):
+		if end := strings.Index(rest[len(l.blockComment[0]):], l.blockComment[1]); end >= 0 {
+			return ClassComment, i + len(l.blockComment[0]) + end + len(l.blockComment[1])
+		}
+		return ClassComment, len(code)
+	case strings.IndexByte(l.quotes, code[i]) >= 0:
+		return ClassString, stringEnd(code, i)
+	case isDigit(code[i]) && (i == 0 || !isWordByte(code[i-1])):
+		end := i + 1
+		for end < len(code) && (isWordByte(code[end]) || code[end] == '.') {
+			end++
+		}
+		return ClassNumber, end
+	case isWordByte(code[i]):
+		end := i + 1
+		for end < len(code) && isWordByte(code[end]) {
+			end++
+		}
+		word := code[i:end]
+		if l.caseInsensitive {
+			word = strings.ToLower(word)
+		}
+		if l.keywords[word] && (i == 0 || !isWordByte(code[i-1])) {
+			return ClassKeyword, end
+		}
+		return "", end
+	default:
+		return "", i + 1
+	}
+}
+
+// stringEnd returns where the string quoted at i ends, strings other than
+// backquoted ones end with their line when left open.
+func stringEnd(code string, i int) int {
+	quote := code[i]
+	for j := i + 1; j < len(code); j++ {
+		switch {
+		case code[j] == '\\' && quote != '`':
+			j++
+		case code[j] == quote:
+			return j + 1
+		case code[j] == '\n' && quote != '`':
+			return j
+		}
+	}
+	return len(code)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || isDigit(c) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
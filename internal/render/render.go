@@ -0,0 +1,166 @@
+// Package render turns post bodies into HTML that is safe to embed in a page,
+// along with the plain text it reads as.
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/russross/blackfriday/v2"
+	nethtml "golang.org/x/net/html"
+)
+
+// WordsPerMinute is the reading speed ReadingTime assumes.
+const WordsPerMinute = 200
+
+const ellipsis = "…"
+
+const markdownExtensions = blackfriday.NoIntraEmphasis | blackfriday.Tables | blackfriday.FencedCode |
+	blackfriday.Autolink | blackfriday.Strikethrough | blackfriday.SpaceHeadings |
+	blackfriday.BackslashLineBreak | blackfriday.AutoHeadingIDs
+
+// languagePattern is what a code fence may name as its language.
+var languagePattern = regexp.MustCompile(`^[a-z0-9+#-]+$`)
+
+// Markdown renders source with tables, fenced code, strikethrough and
+// autolinks. Headings get an id and an anchor linking to it, fenced code
+// naming a language is highlighted and the result is sanitized, raw HTML in
+// source included.
+func Markdown(source string) string {
+	ast := blackfriday.New(blackfriday.WithExtensions(markdownExtensions)).Parse([]byte(source))
+	uniqueHeadingIDs(ast)
+
+	renderer := &htmlRenderer{blackfriday.NewHTMLRenderer(blackfriday.HTMLRendererParameters{})}
+	var b bytes.Buffer
+	renderer.RenderHeader(&b, ast)
+	ast.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		return renderer.RenderNode(&b, node, entering)
+	})
+	renderer.RenderFooter(&b, ast)
+	return Sanitize(b.String())
+}
+
+// Plain escapes text and keeps its layout, blank lines separate paragraphs and
+// the other line breaks are kept.
+func Plain(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	var b strings.Builder
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		b.WriteString("<p>")
+		b.WriteString(strings.ReplaceAll(html.EscapeString(paragraph), "\n", "<br>\n"))
+		b.WriteString("</p>\n")
+	}
+	return b.String()
+}
+
+// Text returns the text of rendered HTML, with the whitespace between words
+// and blocks collapsed into single spaces.
+func Text(rendered string) string {
+	var b strings.Builder
+	z := nethtml.NewTokenizer(strings.NewReader(rendered))
+	for {
+		switch z.Next() {
+		case nethtml.ErrorToken:
+			return strings.Join(strings.Fields(b.String()), " ")
+		case nethtml.TextToken:
+			b.Write(z.Text())
+		case nethtml.StartTagToken, nethtml.EndTagToken, nethtml.SelfClosingTagToken:
+			// words in neighbouring blocks are not run together
+			b.WriteByte(' ')
+		}
+	}
+}
+
+// Excerpt cuts text down to about size characters, ending on a whole word.
+func Excerpt(text string, size int) string {
+	runes := []rune(text)
+	if len(runes) <= size {
+		return text
+	}
+
+	end := size
+	for i := size; i > size/2; i-- {
+		if runes[i] == ' ' {
+			end = i
+			break
+		}
+	}
+	return strings.TrimRight(string(runes[:end]), " ,.;:") + ellipsis
+}
+
+// ReadingTime returns how many minutes text takes to read, at least one.
+func ReadingTime(text string) int {
+	minutes := int(math.Ceil(float64(len(strings.Fields(text))) / WordsPerMinute))
+	if minutes < 1 {
+		return 1
+	}
+	return minutes
+}
+
+// uniqueHeadingIDs suffixes the ids of headings sharing one, so every anchor
+// links to its own heading.
+func uniqueHeadingIDs(ast *blackfriday.Node) {
+	seen := make(map[string]bool)
+	ast.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if !entering || node.Type != blackfriday.Heading || node.HeadingID == "" {
+			return blackfriday.GoToNext
+		}
+
+		id := node.HeadingID
+		for n := 2; seen[id]; n++ {
+			id = fmt.Sprintf("%s-%d", node.HeadingID, n)
+		}
+		seen[id] = true
+		node.HeadingID = id
+		return blackfriday.GoToNext
+	})
+}
+
+// htmlRenderer highlights fenced code and links headings to themselves, the
+// rest is left to blackfriday.
+type htmlRenderer struct {
+	*blackfriday.HTMLRenderer
+}
+
+func (r *htmlRenderer) RenderNode(w io.Writer, node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+	switch node.Type {
+	case blackfriday.CodeBlock:
+		lang := codeLanguage(node.Info)
+		if lang == "" {
+			io.WriteString(w, "<pre><code>")
+		} else {
+			fmt.Fprintf(w, `<pre><code class="language-%s">`, lang)
+		}
+		io.WriteString(w, Highlight(lang, string(node.Literal)))
+		io.WriteString(w, "</code></pre>\n")
+		return blackfriday.GoToNext
+	case blackfriday.Heading:
+		status := r.HTMLRenderer.RenderNode(w, node, entering)
+		if entering && node.HeadingID != "" {
+			fmt.Fprintf(w, `<a class="anchor" href="#%s"></a>`, html.EscapeString(node.HeadingID))
+		}
+		return status
+	default:
+		return r.HTMLRenderer.RenderNode(w, node, entering)
+	}
+}
+
+// codeLanguage returns the language a code fence names, if it names one that
+// can go in a class.
+func codeLanguage(info []byte) string {
+	fields := strings.Fields(strings.ToLower(string(info)))
+	if len(fields) == 0 || !languagePattern.MatchString(fields[0]) {
+		return ""
+	}
+	return fields[0]
+}
@@ -0,0 +1,53 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkdown(t *testing.T) {
+	out := Markdown("# Intro\n\nSome *text* and [a link](https://example.com).\n\n## Intro\n")
+	assert.Contains(t, out, `<h1 id="intro"><a class="anchor" href="#intro"></a>Intro</h1>`)
+	assert.Contains(t, out, `<h2 id="intro-2"><a class="anchor" href="#intro-2"></a>Intro</h2>`)
+	assert.Contains(t, out, `<em>text</em>`)
+	assert.Contains(t, out, `<a href="https://example.com" rel="nofollow">a link</a>`)
+
+	out = Markdown("```go\nfunc main() { // start\n\treturn \"<b>\"\n}\n```\n")
+	assert.Contains(t, out, `<pre><code class="language-go">`)
+	assert.Contains(t, out, `<span class="hl-keyword">func</span> main() { <span class="hl-comment">// start</span>`)
+	assert.Contains(t, out, `<span class="hl-string">&#34;&lt;b&gt;&#34;</span>`)
+
+	out = Markdown("Hi <script>alert(1)</script><img src=x onerror=alert(1)> [x](javascript:alert(1))")
+	assert.NotContains(t, out, "script")
+	assert.NotContains(t, out, "onerror")
+	assert.NotContains(t, out, "javascript")
+}
+
+func TestPlain(t *testing.T) {
+	assert.Equal(t, "<p>one<br>\ntwo</p>\n<p>&lt;three&gt;</p>\n", Plain("one\r\ntwo\n\n\n<three>\n"))
+}
+
+func TestText(t *testing.T) {
+	assert.Equal(t, "Title First & second", Text("<h1>Title</h1><p>First &amp;<br>second</p>"))
+}
+
+func TestExcerpt(t *testing.T) {
+	assert.Equal(t, "short", Excerpt("short", 10))
+	assert.Equal(t, "one two…", Excerpt("one two, three four", 10))
+	assert.Equal(t, "abcdefghij…", Excerpt("abcdefghijklmnop", 10))
+}
+
+func TestReadingTime(t *testing.T) {
+	assert.Equal(t, 1, ReadingTime(""))
+	assert.Equal(t, 1, ReadingTime(strings.Repeat("word ", WordsPerMinute)))
+	assert.Equal(t, 2, ReadingTime(strings.Repeat("word ", WordsPerMinute+1)))
+}
+
+func TestHighlight(t *testing.T) {
+	assert.Equal(t, `<span class="hl-keyword">SELECT</span> id <span class="hl-keyword">FROM</span> post <span class="hl-keyword">WHERE</span> id = <span class="hl-number">1</span> <span class="hl-comment">-- one</span>`,
+		Highlight("sql", "SELECT id FROM post WHERE id = 1 -- one"))
+	assert.Equal(t, `x1 = <span class="hl-string">&#39;it\&#39;s&#39;</span>`, Highlight("python", `x1 = 'it\'s'`))
+	assert.Equal(t, "if &lt;x&gt;", Highlight("unknown", "if <x>"))
+}
@@ -0,0 +1,176 @@
+package render
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+)
+
+// allowedElements are the elements Sanitize keeps, with the attributes kept on
+// them. Any other element is dropped and its text kept.
+var allowedElements = map[string][]string{
+	"a": {"href", "title", "class"}, "img": {"src", "alt", "title"},
+	"h1": {"id"}, "h2": {"id"}, "h3": {"id"}, "h4": {"id"}, "h5": {"id"}, "h6": {"id"},
+	"p": nil, "br": nil, "hr": nil, "blockquote": nil, "pre": nil, "code": {"class"}, "span": {"class"},
+	"em": nil, "strong": nil, "del": nil, "s": nil, "sup": nil, "sub": nil, "kbd": nil,
+	"ul": nil, "ol": {"start"}, "li": nil, "dl": nil, "dt": nil, "dd": nil,
+	"table": nil, "thead": nil, "tbody": nil, "tr": nil, "th": {"align"}, "td": {"align"},
+}
+
+// droppedElements are dropped along with everything in them.
+var droppedElements = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true, "embed": true, "noscript": true,
+	"template": true, "textarea": true, "select": true, "svg": true, "math": true, "title": true, "head": true,
+}
+
+var voidElements = map[string]bool{"br": true, "hr": true, "img": true}
+
+var (
+	idPattern            = regexp.MustCompile(`^[\pL\pN_-]+$`)
+	languageClassPattern = regexp.MustCompile(`^language-[a-z0-9+#-]+$`)
+	highlightClasses     = map[string]bool{ClassKeyword: true, ClassString: true, ClassComment: true, ClassNumber: true}
+)
+
+// Sanitize keeps only the allowedElements of untrusted HTML and their
+// attributes with a safe value. Links may only go to http, https and mailto
+// URLs or relative ones, those leaving the site get rel="nofollow". Elements
+// left open are closed.
+func Sanitize(untrusted string) string {
+	var b strings.Builder
+	var open []string
+	dropped := 0
+	z := nethtml.NewTokenizer(strings.NewReader(untrusted))
+	for {
+		tokenType := z.Next()
+		if tokenType == nethtml.ErrorToken {
+			break
+		}
+		token := z.Token()
+
+		if droppedElements[token.Data] && tokenType != nethtml.TextToken {
+			switch tokenType {
+			case nethtml.StartTagToken:
+				dropped++
+			case nethtml.EndTagToken:
+				if dropped > 0 {
+					dropped--
+				}
+			}
+			continue
+		} else if dropped > 0 {
+			continue
+		}
+
+		switch tokenType {
+		case nethtml.TextToken:
+			b.WriteString(html.EscapeString(token.Data))
+		case nethtml.StartTagToken, nethtml.SelfClosingTagToken:
+			if _, ok := allowedElements[token.Data]; !ok {
+				continue
+			}
+			writeStartTag(&b, token)
+			if !voidElements[token.Data] && tokenType == nethtml.StartTagToken {
+				open = append(open, token.Data)
+			}
+		case nethtml.EndTagToken:
+			// closes whatever was left open inside, an end tag with nothing
+			// to close is dropped
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != token.Data {
+					continue
+				}
+				for j := len(open) - 1; j >= i; j-- {
+					b.WriteString("</" + open[j] + ">")
+				}
+				open = open[:i]
+				break
+			}
+		}
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + open[i] + ">")
+	}
+	return b.String()
+}
+
+func writeStartTag(b *strings.Builder, token nethtml.Token) {
+	b.WriteString("<" + token.Data)
+	external := false
+	for _, attr := range token.Attr {
+		value, ok := allowedValue(token.Data, attr.Key, attr.Val)
+		if !ok {
+			continue
+		}
+		if attr.Key == "href" {
+			external = isExternal(value)
+		}
+		b.WriteString(" " + attr.Key + `="` + html.EscapeString(value) + `"`)
+	}
+	if external {
+		b.WriteString(` rel="nofollow"`)
+	}
+	b.WriteString(">")
+}
+
+// allowedValue returns the value to keep for the attribute of element, if the
+// attribute is allowed there and the value is safe.
+func allowedValue(element, attr, value string) (string, bool) {
+	allowed := false
+	for _, name := range allowedElements[element] {
+		allowed = allowed || name == attr
+	}
+	if !allowed {
+		return "", false
+	}
+
+	switch attr {
+	case "href":
+		return safeURL(value, "http", "https", "mailto")
+	case "src":
+		return safeURL(value, "http", "https")
+	case "id":
+		return value, idPattern.MatchString(value)
+	case "class":
+		switch element {
+		case "a":
+			return value, value == "anchor"
+		case "code":
+			return value, languageClassPattern.MatchString(value)
+		default:
+			return value, highlightClasses[value]
+		}
+	case "align":
+		return value, value == "left" || value == "center" || value == "right"
+	case "start":
+		return value, value != "" && strings.Trim(value, "0123456789") == ""
+	default:
+		return value, true
+	}
+}
+
+// safeURL accepts a relative URL or one with one of schemes.
+func safeURL(value string, schemes ...string) (string, bool) {
+	value = strings.TrimSpace(value)
+	u, err := url.Parse(value)
+	if err != nil {
+		return "", false
+	} else if u.Scheme == "" {
+		return value, true
+	}
+
+	for _, scheme := range schemes {
+		if strings.EqualFold(u.Scheme, scheme) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func isExternal(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && u.Host != ""
+}
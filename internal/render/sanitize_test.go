@@ -0,0 +1,30 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitize(t *testing.T) {
+	for untrusted, sanitized := range map[string]string{
+		`<p onclick="x()">hi</p>`:                            `<p>hi</p>`,
+		`<div><b>bold</b></div>`:                             `bold`,
+		`<script>alert(1)</script>ok`:                        `ok`,
+		`<svg><g><script>x</script></g></svg>ok`:             `ok`,
+		`<a href="JavaScript:alert(1)">x</a>`:                `<a>x</a>`,
+		`<a href="java&#x09;script:alert(1)">x</a>`:          `<a>x</a>`,
+		`<a href="/posts/1" class="evil">x</a>`:              `<a href="/posts/1">x</a>`,
+		`<a href="mailto:a@example.com">x</a>`:               `<a href="mailto:a@example.com">x</a>`,
+		`<img src="data:image/png;base64,AA" alt="a">`:       `<img alt="a">`,
+		`<code class="language-go">x</code>`:                 `<code class="language-go">x</code>`,
+		`<span class="hl-string" style="color:red">x</span>`: `<span class="hl-string">x</span>`,
+		`<h2 id="a&quot;b">x</h2>`:                           `<h2>x</h2>`,
+		`<td align="center">x</td>`:                          `<td align="center">x</td>`,
+		`<em><strong>x</em>`:                                 `<em><strong>x</strong></em>`,
+		`</p>x<blockquote>y`:                                 `x<blockquote>y</blockquote>`,
+		`<!-- comment -->x &lt;y&gt;`:                        `x &lt;y&gt;`,
+	} {
+		assert.Equal(t, sanitized, Sanitize(untrusted), untrusted)
+	}
+}
@@ -0,0 +1,125 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+const (
+	markOpen  = "<mark>"
+	markClose = "</mark>"
+	ellipsis  = "…"
+)
+
+// Terms splits a query into the lower cased words it searches for.
+func Terms(q string) []string {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, term := range strings.FieldsFunc(strings.ToLower(q), isSeparator) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// Snippet cuts about size characters out of text around the first term it
+// holds and wraps every term in a mark element. The text is HTML escaped so
+// the marks are the only markup in it.
+func Snippet(text string, terms []string, size int) string {
+	runes := []rune(text)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	matches := findMatches(lower, terms)
+
+	start := 0
+	if len(matches) > 0 && matches[0].start > size/4 {
+		// a little context before the first match, starting on a word
+		start = matches[0].start - size/4
+		for i := start; i < matches[0].start; i++ {
+			if isSeparator(runes[i]) {
+				start = i + 1
+				break
+			}
+		}
+	}
+	end := start + size
+	if end > len(runes) {
+		end = len(runes)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString(ellipsis)
+	}
+	pos := start
+	for _, m := range matches {
+		if m.start < start {
+			continue
+		} else if m.end > end {
+			break
+		}
+		b.WriteString(html.EscapeString(string(runes[pos:m.start])))
+		b.WriteString(markOpen)
+		b.WriteString(html.EscapeString(string(runes[m.start:m.end])))
+		b.WriteString(markClose)
+		pos = m.end
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:end])))
+	if end < len(runes) {
+		b.WriteString(ellipsis)
+	}
+	return b.String()
+}
+
+type span struct {
+	start, end int
+}
+
+// findMatches returns where the terms start a word in lower, the longest term
+// wins where several do.
+func findMatches(lower []rune, terms []string) []span {
+	termRunes := make([][]rune, len(terms))
+	for i, term := range terms {
+		termRunes[i] = []rune(term)
+	}
+
+	var matches []span
+	for i := 0; i < len(lower); i++ {
+		if i > 0 && !isSeparator(lower[i-1]) {
+			continue
+		}
+
+		longest := 0
+		for _, term := range termRunes {
+			if len(term) > longest && hasPrefix(lower[i:], term) {
+				longest = len(term)
+			}
+		}
+		if longest > 0 {
+			matches = append(matches, span{i, i + longest})
+			i += longest - 1
+		}
+	}
+	return matches
+}
+
+func hasPrefix(s, prefix []rune) bool {
+	if len(prefix) > len(s) {
+		return false
+	}
+	for i := range prefix {
+		if s[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
@@ -0,0 +1,33 @@
+package search
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerms(t *testing.T) {
+	assert.Equal(t, []string{"go", "mysql", "straße"}, Terms("Go, MySQL & go  Straße"))
+	assert.Empty(t, Terms(" -- "))
+}
+
+func TestSnippet(t *testing.T) {
+	terms := Terms("go mysql")
+
+	assert.Equal(t, "Using <mark>Go</mark> with <mark>MySQL</mark>", Snippet("Using Go with MySQL", terms, 100))
+
+	// only whole words from their start are marked
+	assert.Equal(t, "<mark>go</mark>ing to cargo", Snippet("going to cargo", terms, 100))
+
+	// the text around the marks is escaped
+	assert.Equal(t, "&lt;b&gt;<mark>go</mark>&lt;/b&gt;", Snippet("<b>go</b>", terms, 100))
+
+	text := strings.Repeat("lorem ipsum ", 20) + "now go further " + strings.Repeat("dolor sit ", 20)
+	snippet := Snippet(text, terms, 40)
+	assert.True(t, strings.HasPrefix(snippet, "…"), snippet)
+	assert.True(t, strings.HasSuffix(snippet, "…"), snippet)
+	assert.Contains(t, snippet, "now <mark>go</mark> further")
+
+	assert.Equal(t, "no match…", Snippet("no match here", terms, 8))
+}
@@ -2,43 +2,214 @@ package middleware
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	jwt "github.com/dgrijalva/jwt-go"
-	"github.com/osamaesmail/go-post-api/internal/config"
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/app/repository"
 	"github.com/osamaesmail/go-post-api/internal/constant"
+	"github.com/osamaesmail/go-post-api/internal/logger"
+	"github.com/osamaesmail/go-post-api/internal/security/token"
+	"github.com/osamaesmail/go-post-api/internal/security/totp"
 	"github.com/osamaesmail/go-post-api/internal/web"
 )
 
-func JWTVerifier(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		tokenHeader := r.Header.Get(constant.API_KEY_HEADER)
-		if tokenHeader == "" {
-			web.MarshalError(w, http.StatusUnauthorized, constant.ErrUnauthorized)
-			return
-		}
+// JWTVerifier authenticates the request from the API key header, which holds
+// either a JWT or a personal access token. Requests made while impersonating
+// are audited before they are served.
+func JWTVerifier(tokenRepository repository.TokenRepository, accessTokenRepository repository.AccessTokenRepository, sessionRepository repository.SessionRepository, auditRepository repository.AuditRepository) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokenHeader := r.Header.Get(constant.API_KEY_HEADER)
+			if tokenHeader == "" {
+				web.MarshalError(w, http.StatusUnauthorized, constant.ErrUnauthorized)
+				return
+			}
+
+			var ctx context.Context
+			var err error
+			if strings.HasPrefix(tokenHeader, constant.ACCESS_TOKEN_PREFIX) {
+				ctx, err = verifyAccessToken(r.Context(), accessTokenRepository, tokenHeader)
+			} else {
+				ctx, err = verifyJWT(r.Context(), tokenRepository, sessionRepository, tokenHeader)
+			}
+
+			if err != nil {
+				switch err {
+				case constant.ErrUnauthorized:
+					web.MarshalError(w, http.StatusUnauthorized, err)
+					return
+				default:
+					web.MarshalError(w, http.StatusInternalServerError, err)
+					return
+				}
+			}
+
+			if IsImpersonating(ctx) {
+				err = auditImpersonatedRequest(ctx, auditRepository, r)
+				if err != nil {
+					web.MarshalError(w, http.StatusInternalServerError, constant.ErrServer)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
 
-		tokenParse, err := jwt.Parse(tokenHeader, func(jwtToken *jwt.Token) (interface{}, error) {
-			if jwtToken.Method != jwt.SigningMethodHS256 {
-				return nil, constant.ErrUnauthorized
+// OptionalJWTVerifier lets requests without an API key through anonymously,
+// a key that is sent still has to be valid.
+func OptionalJWTVerifier(tokenRepository repository.TokenRepository, accessTokenRepository repository.AccessTokenRepository, sessionRepository repository.SessionRepository, auditRepository repository.AuditRepository) func(next http.Handler) http.Handler {
+	jwtVerifier := JWTVerifier(tokenRepository, accessTokenRepository, sessionRepository, auditRepository)
+	return func(next http.Handler) http.Handler {
+		verified := jwtVerifier(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get(constant.API_KEY_HEADER) == "" {
+				next.ServeHTTP(w, r)
+				return
 			}
-			return []byte(config.Cfg().JwtSecretKey), nil
+			verified.ServeHTTP(w, r)
 		})
+	}
+}
+
+func verifyJWT(ctx context.Context, tokenRepository repository.TokenRepository, sessionRepository repository.SessionRepository, tokenHeader string) (context.Context, error) {
+	tokenParse, err := token.ParseToken(tokenHeader)
+	if err != nil || !tokenParse.Valid {
+		return nil, constant.ErrUnauthorized
+	}
+
+	claims := tokenParse.Claims.(jwt.MapClaims)
+	claimsID, err := strconv.ParseInt(fmt.Sprint(claims["id"]), 10, 64)
+	if err != nil {
+		return nil, constant.ErrUnauthorized
+	}
 
-		if err != nil || !tokenParse.Valid {
-			web.MarshalError(w, http.StatusUnauthorized, constant.ErrUnauthorized)
-			return
+	claimsTokenID, _ := claims["jti"].(string)
+	if claimsTokenID != "" {
+		revoked, err := tokenRepository.IsTokenRevoked(ctx, claimsTokenID)
+		if err != nil {
+			logger.Log().Err(err).Msg("failed to check token revocation")
+			return nil, constant.ErrServer
+		} else if revoked {
+			return nil, constant.ErrUnauthorized
 		}
+	}
+
+	revokedBefore, err := tokenRepository.GetRevokedBefore(ctx, claimsID)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to get token revocation watermark")
+		return nil, constant.ErrServer
+	}
 
-		claims := tokenParse.Claims.(jwt.MapClaims)
-		claimsID, err := strconv.ParseInt(fmt.Sprint(claims["id"]), 10, 64)
+	if token.IssuedAt(claims).Before(revokedBefore) {
+		return nil, constant.ErrUnauthorized
+	}
+
+	// tokens minted before sessions existed carry no sid
+	claimsSessionID, _ := claims["sid"].(float64)
+	if claimsSessionID != 0 {
+		revoked, err := sessionRepository.IsRevoked(ctx, int64(claimsSessionID))
 		if err != nil {
-			web.MarshalError(w, http.StatusUnauthorized, constant.ErrUnauthorized)
-			return
+			logger.Log().Err(err).Msg("failed to check session revocation")
+			return nil, constant.ErrServer
+		} else if revoked {
+			return nil, constant.ErrUnauthorized
 		}
 
-		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), claimsIDKey, claimsID)))
-	})
+		err = sessionRepository.Touch(ctx, int64(claimsSessionID), time.Now())
+		if err != nil {
+			logger.Log().Err(err).Msg("failed to touch session")
+		}
+	}
+
+	claimsRole, valid := claims["role"].(string)
+	if !valid {
+		claimsRole = model.RoleUser
+	}
+
+	ctx = WithClaims(ctx, claimsID, claimsRole)
+	ctx = context.WithValue(ctx, claimsTokenIDKey, claimsTokenID)
+	ctx = context.WithValue(ctx, claimsSessionIDKey, int64(claimsSessionID))
+
+	if claimsAct, valid := claims["act"].(map[string]interface{}); valid {
+		claimsActorID, err := strconv.ParseInt(fmt.Sprint(claimsAct["sub"]), 10, 64)
+		if err != nil {
+			return nil, constant.ErrUnauthorized
+		}
+		ctx = context.WithValue(ctx, claimsActorIDKey, claimsActorID)
+	}
+	return ctx, nil
+}
+
+// auditImpersonatedRequest records the request against the impersonated
+// account with the admin as actor.
+func auditImpersonatedRequest(ctx context.Context, auditRepository repository.AuditRepository, r *http.Request) error {
+	claimsID, _ := GetClaimsID(ctx)
+	claimsActorID, _ := GetClaimsActorID(ctx)
+
+	request, err := json.Marshal(map[string]string{"method": r.Method, "path": r.URL.Path})
+	if err != nil {
+		return err
+	}
+	diff, err := model.NewAuditDiff(nil, request)
+	if err != nil {
+		return err
+	}
+
+	event := &model.AuditEvent{
+		ActorID:    sql.NullInt64{Int64: claimsActorID, Valid: true},
+		Action:     model.AuditActionImpersonatedRequest,
+		TargetType: model.AuditTargetAccount,
+		TargetID:   claimsID,
+		Diff:       diff,
+		CreatedAt:  time.Now(),
+	}
+	if requestIP, valid := GetRequestIP(ctx); valid {
+		event.IP = sql.NullString{String: requestIP, Valid: true}
+	}
+	if requestID, valid := GetRequestID(ctx); valid {
+		event.RequestID = sql.NullString{String: requestID, Valid: true}
+	}
+
+	err = auditRepository.Create(ctx, event)
+	if err != nil {
+		logger.Log().Err(err).Int64("account_id", claimsID).Msg("failed to audit impersonated request")
+	}
+	return err
+}
+
+func verifyAccessToken(ctx context.Context, accessTokenRepository repository.AccessTokenRepository, tokenHeader string) (context.Context, error) {
+	accessToken, err := accessTokenRepository.GetByHash(ctx, token.HashOpaqueToken(tokenHeader))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, constant.ErrUnauthorized
+		default:
+			logger.Log().Err(err).Msg("failed to get access token by hash")
+			return nil, constant.ErrServer
+		}
+	}
+
+	if !accessToken.IsActive(time.Now()) {
+		return nil, constant.ErrUnauthorized
+	}
+
+	// like a JWT, a privileged role only applies once a second factor
+	// protects the account
+	role := accessToken.Account.Role
+	if totp.RequiredFor(role) && !accessToken.Account.TOTPEnabledAt.Valid {
+		role = model.RoleUser
+	}
+
+	ctx = WithClaims(ctx, accessToken.AccountID, role)
+	ctx = context.WithValue(ctx, claimsScopesKey, accessToken.Scopes)
+	return ctx, nil
 }
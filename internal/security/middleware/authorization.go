@@ -2,18 +2,145 @@ package middleware
 
 import (
 	"context"
+	"net/http"
+
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/constant"
+	"github.com/osamaesmail/go-post-api/internal/web"
 )
 
 type key string
 
-const claimsIDKey = key("id")
+const (
+	claimsIDKey        = key("id")
+	claimsTokenIDKey   = key("jti")
+	claimsSessionIDKey = key("sid")
+	claimsRoleKey      = key("role")
+	claimsScopesKey    = key("scopes")
+	claimsActorIDKey   = key("act")
+)
+
+// WithClaims authenticates ctx as the account acting with role.
+func WithClaims(ctx context.Context, id int64, role string) context.Context {
+	ctx = context.WithValue(ctx, claimsIDKey, id)
+	return context.WithValue(ctx, claimsRoleKey, role)
+}
 
 func GetClaimsID(ctx context.Context) (int64, bool) {
 	claimsID, valid := ctx.Value(claimsIDKey).(int64)
 	return claimsID, valid
 }
 
+// GetClaimsActorID returns the admin behind an impersonation token, GetClaimsID
+// is then the impersonated account.
+func GetClaimsActorID(ctx context.Context) (int64, bool) {
+	claimsActorID, valid := ctx.Value(claimsActorIDKey).(int64)
+	return claimsActorID, valid
+}
+
+func IsImpersonating(ctx context.Context) bool {
+	_, valid := GetClaimsActorID(ctx)
+	return valid
+}
+
+func GetClaimsTokenID(ctx context.Context) (string, bool) {
+	claimsTokenID, valid := ctx.Value(claimsTokenIDKey).(string)
+	return claimsTokenID, valid && claimsTokenID != ""
+}
+
+// GetClaimsSessionID only reports valid for tokens issued for a session.
+func GetClaimsSessionID(ctx context.Context) (int64, bool) {
+	claimsSessionID, valid := ctx.Value(claimsSessionIDKey).(int64)
+	return claimsSessionID, valid && claimsSessionID != 0
+}
+
+func GetClaimsRole(ctx context.Context) (string, bool) {
+	claimsRole, valid := ctx.Value(claimsRoleKey).(string)
+	return claimsRole, valid
+}
+
+// GetClaimsScopes only reports valid for requests made with a personal access
+// token, a session token is not restricted by scopes.
+func GetClaimsScopes(ctx context.Context) ([]string, bool) {
+	claimsScopes, valid := ctx.Value(claimsScopesKey).([]string)
+	return claimsScopes, valid
+}
+
+func IsAccessToken(ctx context.Context) bool {
+	_, valid := GetClaimsScopes(ctx)
+	return valid
+}
+
+func HasScope(ctx context.Context, scope string) bool {
+	claimsScopes, valid := GetClaimsScopes(ctx)
+	if !valid {
+		return true
+	}
+	for _, claimsScope := range claimsScopes {
+		if claimsScope == scope {
+			return true
+		}
+	}
+	return false
+}
+
 func IsMe(ctx context.Context, id int64) bool {
 	claimsID, valid := GetClaimsID(ctx)
 	return valid && claimsID == id
 }
+
+func HasRole(ctx context.Context, roles ...string) bool {
+	claimsRole, valid := GetClaimsRole(ctx)
+	if !valid {
+		return false
+	}
+	for _, role := range roles {
+		if claimsRole == role {
+			return true
+		}
+	}
+	return false
+}
+
+// CanModerate reports whether the caller may change content it does not own.
+func CanModerate(ctx context.Context) bool {
+	return HasRole(ctx, model.RoleModerator, model.RoleAdmin)
+}
+
+// RequireRole must be chained after JWTVerifier.
+func RequireRole(roles ...string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !HasRole(r.Context(), roles...) {
+				web.MarshalError(w, http.StatusForbidden, constant.ErrForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// DenyImpersonation must be chained after JWTVerifier, it keeps impersonation
+// tokens away from actions the account owner has to take themselves.
+func DenyImpersonation(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if IsImpersonating(r.Context()) {
+			web.MarshalError(w, http.StatusForbidden, constant.ErrImpersonating)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireScope must be chained after JWTVerifier.
+func RequireScope(scope string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !HasScope(r.Context(), scope) {
+				web.MarshalError(w, http.StatusForbidden, constant.ErrInsufficientScope)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
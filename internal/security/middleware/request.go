@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	chimiddleware "github.com/go-chi/chi/middleware"
+	"github.com/osamaesmail/go-post-api/internal/web"
+)
+
+const requestIPKey = key("ip")
+
+// RequestMetadata keeps the client IP in the request context, it runs after
+// chi's RequestID so both are available to the services.
+func RequestMetadata(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), requestIPKey, web.GetClientIP(r))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func GetRequestIP(ctx context.Context) (string, bool) {
+	requestIP, valid := ctx.Value(requestIPKey).(string)
+	return requestIP, valid && requestIP != ""
+}
+
+func GetRequestID(ctx context.Context) (string, bool) {
+	requestID := chimiddleware.GetReqID(ctx)
+	return requestID, requestID != ""
+}
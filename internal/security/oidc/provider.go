@@ -0,0 +1,293 @@
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/osamaesmail/go-post-api/internal/config"
+)
+
+var (
+	ErrUnknownProvider = errors.New("unknown oidc provider")
+	ErrInvalidIDToken  = errors.New("invalid oidc id token")
+)
+
+// Identity is what the provider asserts about the user in the ID token.
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+// Provider runs the authorization code flow with PKCE against an OpenID
+// Connect provider. The discovery document and the keys are fetched on first
+// use, the keys again when a token names an unknown one.
+type Provider struct {
+	Name string
+
+	cfg         config.OauthProvider
+	redirectURL string
+	client      *http.Client
+
+	mu        sync.Mutex
+	discovery *discovery
+	keys      map[string]interface{}
+}
+
+func NewProvider(name string, cfg config.OauthProvider, redirectURL string) *Provider {
+	return &Provider{
+		Name:        name,
+		cfg:         cfg,
+		redirectURL: redirectURL,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Providers are the configured providers by name.
+type Providers map[string]*Provider
+
+// NewProviders redirects every provider back to
+// APP_BASE_URL/v1/accounts/oauth/{provider}/callback.
+func NewProviders(cfg *config.Config) (Providers, error) {
+	providers := Providers{}
+	for name, providerCfg := range cfg.OauthProviders {
+		if providerCfg.Issuer == "" || providerCfg.ClientID == "" {
+			return nil, fmt.Errorf("oidc provider %s needs an issuer and a client id", name)
+		}
+		redirectURL := fmt.Sprintf("%s/v1/accounts/oauth/%s/callback", strings.TrimSuffix(cfg.AppBaseURL, "/"), name)
+		providers[name] = NewProvider(name, providerCfg, redirectURL)
+	}
+	return providers, nil
+}
+
+func (p Providers) Get(name string) (*Provider, error) {
+	provider, exists := p[name]
+	if !exists {
+		return nil, ErrUnknownProvider
+	}
+	return provider, nil
+}
+
+// AuthCodeURL is where the user agent is sent to sign in, the state and the
+// nonce come back in the callback and the ID token.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	d, err := p.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	scopes := []string{"openid"}
+	for _, scope := range strings.Split(p.cfg.Scopes, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" && scope != "openid" {
+			scopes = append(scopes, scope)
+		}
+	}
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.redirectURL},
+		"scope":                 {strings.Join(scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {CodeChallenge(codeVerifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return d.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange redeems the authorization code and verifies the ID token that comes
+// with it, nonce is the one passed to AuthCodeURL.
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Identity, error) {
+	d, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.redirectURL},
+		"code_verifier": {codeVerifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+
+	var res struct {
+		IDToken string `json:"id_token"`
+	}
+	err = p.do(req, &res)
+	if err != nil {
+		return nil, fmt.Errorf("oidc token exchange: %w", err)
+	} else if res.IDToken == "" {
+		return nil, ErrInvalidIDToken
+	}
+
+	return p.verify(ctx, d, res.IDToken, nonce)
+}
+
+func (p *Provider) verify(ctx context.Context, d *discovery, idToken, nonce string) (*Identity, error) {
+	parsed, err := jwt.Parse(idToken, func(jwtToken *jwt.Token) (interface{}, error) {
+		kid, _ := jwtToken.Header["kid"].(string)
+		key, err := p.getKey(ctx, d, kid)
+		if err != nil {
+			return nil, err
+		} else if !keyMatchesMethod(key, jwtToken.Method) {
+			return nil, jwt.ErrInvalidKeyType
+		}
+		return key, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidIDToken, err)
+	}
+
+	claims, valid := parsed.Claims.(jwt.MapClaims)
+	if !valid {
+		return nil, ErrInvalidIDToken
+	}
+	if claims["iss"] != d.Issuer || !hasAudience(claims["aud"], p.cfg.ClientID) || claims["nonce"] != nonce {
+		return nil, ErrInvalidIDToken
+	}
+	if _, valid := claims["exp"].(float64); !valid {
+		return nil, ErrInvalidIDToken
+	}
+
+	identity := &Identity{}
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Email, _ = claims["email"].(string)
+	identity.EmailVerified, _ = claims["email_verified"].(bool)
+	identity.Name, _ = claims["name"].(string)
+	if identity.Subject == "" {
+		return nil, ErrInvalidIDToken
+	}
+	return identity, nil
+}
+
+func (p *Provider) getDiscovery(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(p.cfg.Issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	d := new(discovery)
+	err = p.do(req, d)
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if d.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("oidc discovery: issuer %s does not match %s", d.Issuer, p.cfg.Issuer)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JwksURI == "" {
+		return nil, errors.New("oidc discovery: missing endpoints")
+	}
+
+	p.discovery = d
+	return d, nil
+}
+
+// getKey refetches the key set once for an unknown kid, the provider may have
+// rotated its keys.
+func (p *Provider) getKey(ctx context.Context, d *discovery, kid string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, exists := p.keys[kid]; exists {
+		return key, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.JwksURI, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var set jsonWebKeySet
+	err = p.do(req, &set)
+	if err != nil {
+		return nil, fmt.Errorf("oidc jwks: %w", err)
+	}
+
+	p.keys = set.publicKeys()
+	if key, exists := p.keys[kid]; exists {
+		return key, nil
+	}
+	// a single key may be used without naming it
+	if len(p.keys) == 1 && kid == "" {
+		for _, key := range p.keys {
+			return key, nil
+		}
+	}
+	return nil, jwt.ErrInvalidKey
+}
+
+func (p *Provider) do(req *http.Request, v interface{}) error {
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded %d: %s", req.URL.Host, res.StatusCode, body)
+	}
+	return json.Unmarshal(body, v)
+}
+
+// CodeChallenge is the S256 PKCE challenge of the verifier.
+func CodeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func hasAudience(aud interface{}, clientID string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == clientID
+	case []interface{}:
+		for _, a := range aud {
+			if a == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
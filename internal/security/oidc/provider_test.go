@@ -0,0 +1,122 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/osamaesmail/go-post-api/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockProvider issues an ID token for the code "code" when the verifier
+// matches the challenge of the last authorization request.
+func mockProvider(t *testing.T, claims jwt.MapClaims) (*httptest.Server, *string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var challenge string
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(discovery{
+			Issuer:                server.URL,
+			AuthorizationEndpoint: server.URL + "/authorize",
+			TokenEndpoint:         server.URL + "/token",
+			JwksURI:               server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jsonWebKeySet{Keys: []jsonWebKey{{
+			Kid: "test",
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		clientID, _, _ := r.BasicAuth()
+		if r.PostFormValue("code") != "code" || CodeChallenge(r.PostFormValue("code_verifier")) != challenge || clientID != "client" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		jwtToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		jwtToken.Header["kid"] = "test"
+		idToken, err := jwtToken.SignedString(key)
+		assert.NoError(t, err)
+		json.NewEncoder(w).Encode(map[string]string{"id_token": idToken})
+	})
+
+	claims["iss"] = server.URL
+	return server, &challenge
+}
+
+func TestProvider(t *testing.T) {
+	claims := jwt.MapClaims{
+		"sub":            "subject",
+		"aud":            "client",
+		"nonce":          "nonce",
+		"email":          "someone@example.com",
+		"email_verified": true,
+		"exp":            time.Now().Add(time.Minute).Unix(),
+	}
+	server, challenge := mockProvider(t, claims)
+
+	provider := NewProvider("mock", config.OauthProvider{Issuer: server.URL, ClientID: "client", ClientSecret: "secret", Scopes: "email"}, "http://app/callback")
+
+	authCodeURL, err := provider.AuthCodeURL(context.Background(), "state", "nonce", "verifier")
+	require.NoError(t, err)
+	parsed, err := url.Parse(authCodeURL)
+	require.NoError(t, err)
+	assert.Equal(t, "/authorize", parsed.Path)
+	assert.Equal(t, "openid email", parsed.Query().Get("scope"))
+	assert.Equal(t, "state", parsed.Query().Get("state"))
+	assert.Equal(t, "S256", parsed.Query().Get("code_challenge_method"))
+	*challenge = parsed.Query().Get("code_challenge")
+
+	identity, err := provider.Exchange(context.Background(), "code", "verifier", "nonce")
+	require.NoError(t, err)
+	assert.Equal(t, &Identity{Subject: "subject", Email: "someone@example.com", EmailVerified: true}, identity)
+
+	_, err = provider.Exchange(context.Background(), "code", "other verifier", "nonce")
+	assert.Error(t, err)
+
+	_, err = provider.Exchange(context.Background(), "code", "verifier", "other nonce")
+	assert.ErrorIs(t, err, ErrInvalidIDToken)
+
+	claims["aud"] = "other client"
+	_, err = provider.Exchange(context.Background(), "code", "verifier", "nonce")
+	assert.ErrorIs(t, err, ErrInvalidIDToken)
+}
+
+func TestProviders(t *testing.T) {
+	providers, err := NewProviders(&config.Config{
+		AppBaseURL:     "http://localhost:3000/",
+		OauthProviders: map[string]config.OauthProvider{"local": {Issuer: "http://localhost:8090/default", ClientID: "client"}},
+	})
+	require.NoError(t, err)
+
+	provider, err := providers.Get("local")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:3000/v1/accounts/oauth/local/callback", provider.redirectURL)
+
+	_, err = providers.Get("unknown")
+	assert.Equal(t, ErrUnknownProvider, err)
+
+	_, err = NewProviders(&config.Config{OauthProviders: map[string]config.OauthProvider{"local": {}}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,107 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	argon2idSaltLength = 16
+	argon2idKeyLength  = 32
+)
+
+// argon2idParams are the cost parameters, Memory is in KiB.
+type argon2idParams struct {
+	Memory      uint32
+	Time        uint32
+	Parallelism uint8
+}
+
+type argon2idHasher struct {
+	params argon2idParams
+}
+
+// newArgon2idHasher hashes in the PHC string format
+// $argon2id$v=19$m=<memory>,t=<time>,p=<parallelism>$<salt>$<key>.
+func newArgon2idHasher(params argon2idParams) (*argon2idHasher, error) {
+	if params.Memory == 0 || params.Time == 0 || params.Parallelism == 0 {
+		return nil, errors.New("argon2id memory, time and parallelism must be set")
+	}
+	if params.Memory < 8*uint32(params.Parallelism) {
+		return nil, errors.New("argon2id memory must be at least 8 KiB per lane")
+	}
+	return &argon2idHasher{params}, nil
+}
+
+func (h *argon2idHasher) Name() string {
+	return AlgorithmArgon2id
+}
+
+func (h *argon2idHasher) Identify(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+func (h *argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, argon2idSaltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.params.Time, h.params.Memory, h.params.Parallelism, argon2idKeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version,
+		h.params.Memory, h.params.Time, h.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h *argon2idHasher) Verify(password, hash string) error {
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return err
+	}
+
+	other := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return ErrMismatchedPassword
+	}
+	return nil
+}
+
+func (h *argon2idHasher) NeedsRehash(hash string) bool {
+	params, salt, key, err := decodeArgon2id(hash)
+	return err != nil || params != h.params || len(salt) != argon2idSaltLength || len(key) != argon2idKeyLength
+}
+
+func decodeArgon2id(hash string) (params argon2idParams, salt, key []byte, err error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return params, nil, nil, ErrUnknownHash
+	}
+
+	var version int
+	_, err = fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil || version != argon2.Version {
+		return params, nil, nil, ErrUnknownHash
+	}
+
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Parallelism)
+	if err != nil || params.Time == 0 || params.Parallelism == 0 {
+		return params, nil, nil, ErrUnknownHash
+	}
+
+	salt, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrUnknownHash
+	}
+	key, err = base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrUnknownHash
+	}
+	return params, salt, key, nil
+}
@@ -0,0 +1,72 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// bundled holds a few hundred of the most common passwords, set
+// PASSWORD_BREACHED_DIR to the full list for real coverage.
+//
+//go:embed breached
+var bundled embed.FS
+
+// BreachedList looks passwords up in k-anonymity range files as served by
+// Have I Been Pwned: a file per 5 character SHA-1 prefix, named <PREFIX>.txt,
+// with a SUFFIX or SUFFIX:COUNT line per breached hash.
+type BreachedList struct {
+	fsys fs.FS
+}
+
+// NewBreachedList reads the range files from dir, or from the bundled list
+// when dir is empty.
+func NewBreachedList(dir string) (*BreachedList, error) {
+	if dir == "" {
+		fsys, err := fs.Sub(bundled, "breached")
+		if err != nil {
+			return nil, err
+		}
+		return &BreachedList{fsys}, nil
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		return nil, errors.New("breached password list must be a directory of range files")
+	}
+	return &BreachedList{os.DirFS(dir)}, nil
+}
+
+// Contains only reads the range file of the password's hash prefix.
+func (l *BreachedList) Contains(plain string) (bool, error) {
+	sum := sha1.Sum([]byte(plain))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	f, err := l.fsys.Open(prefix + ".txt")
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, ":"); i >= 0 {
+			line = line[:i]
+		}
+		if strings.EqualFold(line, suffix) {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
@@ -0,0 +1,99 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/osamaesmail/go-post-api/internal/config"
+)
+
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+var (
+	ErrMismatchedPassword = errors.New("password does not match hash")
+	ErrUnknownHash        = errors.New("unknown password hash format")
+)
+
+// PasswordHasher hashes new passwords with the configured algorithm and still
+// verifies hashes of every supported one, NeedsRehash tells which stored
+// hashes are due for an upgrade.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(password, hash string) error
+	NeedsRehash(hash string) bool
+}
+
+// algorithm is a single hashing scheme, Identify reports whether a stored
+// hash was produced by it.
+type algorithm interface {
+	PasswordHasher
+	Name() string
+	Identify(hash string) bool
+}
+
+type hasher struct {
+	current    algorithm
+	algorithms []algorithm
+}
+
+// NewHasher builds the hasher from the config. Bcrypt hashes keep verifying
+// when argon2id is configured and the other way around.
+func NewHasher(cfg *config.Config) (PasswordHasher, error) {
+	bcryptHasher, err := newBcryptHasher(cfg.PasswordBcryptCost)
+	if err != nil {
+		return nil, err
+	}
+	argon2idHasher, err := newArgon2idHasher(argon2idParams{
+		Memory:      uint32(cfg.PasswordArgon2Memory),
+		Time:        uint32(cfg.PasswordArgon2Time),
+		Parallelism: uint8(cfg.PasswordArgon2Parallelism),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	h := &hasher{algorithms: []algorithm{bcryptHasher, argon2idHasher}}
+	for _, a := range h.algorithms {
+		if a.Name() == strings.ToLower(cfg.PasswordHasher) {
+			h.current = a
+		}
+	}
+	if h.current == nil {
+		return nil, fmt.Errorf("unsupported password hasher %s", cfg.PasswordHasher)
+	}
+	return h, nil
+}
+
+func (h *hasher) Hash(password string) (string, error) {
+	return h.current.Hash(password)
+}
+
+func (h *hasher) Verify(password, hash string) error {
+	a := h.identify(hash)
+	if a == nil {
+		return ErrUnknownHash
+	}
+	return a.Verify(password, hash)
+}
+
+// NeedsRehash is true for hashes of another algorithm or with other
+// parameters than the configured ones.
+func (h *hasher) NeedsRehash(hash string) bool {
+	if !h.current.Identify(hash) {
+		return true
+	}
+	return h.current.NeedsRehash(hash)
+}
+
+func (h *hasher) identify(hash string) algorithm {
+	for _, a := range h.algorithms {
+		if a.Identify(hash) {
+			return a
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package password
+
+import (
+	"testing"
+
+	"github.com/osamaesmail/go-post-api/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newTestConfig(hasher string) *config.Config {
+	return &config.Config{
+		PasswordHasher:            hasher,
+		PasswordBcryptCost:        bcrypt.MinCost,
+		PasswordArgon2Memory:      64,
+		PasswordArgon2Time:        1,
+		PasswordArgon2Parallelism: 1,
+	}
+}
+
+func TestHasher(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmBcrypt, AlgorithmArgon2id} {
+		t.Run(algorithm, func(t *testing.T) {
+			h, err := NewHasher(newTestConfig(algorithm))
+			require.NoError(t, err)
+
+			hash, err := h.Hash("correct horse")
+			require.NoError(t, err)
+			assert.NoError(t, h.Verify("correct horse", hash))
+			assert.Equal(t, ErrMismatchedPassword, h.Verify("battery staple", hash))
+			assert.False(t, h.NeedsRehash(hash))
+
+			other, err := h.Hash("correct horse")
+			require.NoError(t, err)
+			assert.NotEqual(t, hash, other, "hashes are salted")
+		})
+	}
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := NewHasher(newTestConfig("md5"))
+		assert.Error(t, err)
+	})
+}
+
+func TestHasherMigration(t *testing.T) {
+	bcryptHasher, err := NewHasher(newTestConfig(AlgorithmBcrypt))
+	require.NoError(t, err)
+	argon2idHasher, err := NewHasher(newTestConfig(AlgorithmArgon2id))
+	require.NoError(t, err)
+
+	legacy, err := bcryptHasher.Hash("correct horse")
+	require.NoError(t, err)
+
+	assert.NoError(t, argon2idHasher.Verify("correct horse", legacy), "old algorithms keep verifying")
+	assert.True(t, argon2idHasher.NeedsRehash(legacy))
+
+	cfg := newTestConfig(AlgorithmArgon2id)
+	cfg.PasswordArgon2Time = 2
+	stronger, err := NewHasher(cfg)
+	require.NoError(t, err)
+
+	hash, err := argon2idHasher.Hash("correct horse")
+	require.NoError(t, err)
+	assert.NoError(t, stronger.Verify("correct horse", hash), "old parameters keep verifying")
+	assert.True(t, stronger.NeedsRehash(hash))
+
+	assert.Equal(t, ErrUnknownHash, argon2idHasher.Verify("correct horse", "plain"))
+	assert.Equal(t, ErrUnknownHash, argon2idHasher.Verify("correct horse", "$argon2id$v=19$m=64,t=1$c2FsdA$a2V5"))
+}
+
+// TestArgon2idVector checks the PHC encoding against a hash from the reference
+// implementation, echo -n password | argon2 somesalt -id -t 2 -m 16 -p 1 -l 32.
+func TestArgon2idVector(t *testing.T) {
+	h, err := newArgon2idHasher(argon2idParams{Memory: 1 << 16, Time: 2, Parallelism: 1})
+	require.NoError(t, err)
+
+	hash := "$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"
+	assert.NoError(t, h.Verify("password", hash))
+	assert.True(t, h.NeedsRehash(hash), "the salt is shorter than the one we generate")
+}
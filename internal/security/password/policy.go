@@ -0,0 +1,150 @@
+package password
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/osamaesmail/go-post-api/internal/config"
+	"github.com/osamaesmail/go-post-api/internal/constant"
+)
+
+const (
+	ClassLower  = "lower"
+	ClassUpper  = "upper"
+	ClassDigit  = "digit"
+	ClassSymbol = "symbol"
+)
+
+var classReasons = map[string]string{
+	ClassLower:  "must contain a lowercase letter",
+	ClassUpper:  "must contain an uppercase letter",
+	ClassDigit:  "must contain a digit",
+	ClassSymbol: "must contain a symbol",
+}
+
+// minUserInputLength keeps short names like "al" from ruling out passwords
+// that merely contain those letters.
+const minUserInputLength = 3
+
+// PolicyError lists every rule the password broke, keyed by the request
+// field it came from.
+type PolicyError struct {
+	Field   string
+	Reasons []string
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("%s: %s; %s", e.Field, constant.ErrPasswordPolicy, strings.Join(e.Reasons, ", "))
+}
+
+func (e *PolicyError) Unwrap() error {
+	return constant.ErrPasswordPolicy
+}
+
+func (e *PolicyError) Fields() map[string][]string {
+	return map[string][]string{e.Field: e.Reasons}
+}
+
+type Policy struct {
+	minLength       int
+	requiredClasses []string
+	breached        *BreachedList
+}
+
+// NewPolicy builds the policy from the config, PASSWORD_REQUIRE_CLASSES is a
+// comma separated list of lower, upper, digit and symbol.
+func NewPolicy(cfg *config.Config) (*Policy, error) {
+	policy := &Policy{minLength: cfg.PasswordMinLength}
+
+	for _, class := range strings.Split(cfg.PasswordRequireClasses, ",") {
+		class = strings.ToLower(strings.TrimSpace(class))
+		switch class {
+		case "":
+		case ClassLower, ClassUpper, ClassDigit, ClassSymbol:
+			policy.requiredClasses = append(policy.requiredClasses, class)
+		default:
+			return nil, fmt.Errorf("unsupported password character class %s", class)
+		}
+	}
+
+	if cfg.PasswordCheckBreached {
+		breached, err := NewBreachedList(cfg.PasswordBreachedDir)
+		if err != nil {
+			return nil, err
+		}
+		policy.breached = breached
+	}
+
+	return policy, nil
+}
+
+// Check returns a *PolicyError for field when plain breaks any rule. The user
+// inputs, like the name and email of the account, must not be part of it.
+func (p *Policy) Check(field, plain string, userInputs ...string) error {
+	var reasons []string
+
+	if len([]rune(plain)) < p.minLength {
+		reasons = append(reasons, fmt.Sprintf("must be at least %d characters long", p.minLength))
+	}
+
+	for _, class := range p.requiredClasses {
+		if !containsClass(plain, class) {
+			reasons = append(reasons, classReasons[class])
+		}
+	}
+
+	lower := strings.ToLower(plain)
+	for _, input := range expandUserInputs(userInputs) {
+		if strings.Contains(lower, input) {
+			reasons = append(reasons, "must not contain your name or email")
+			break
+		}
+	}
+
+	if p.breached != nil {
+		breached, err := p.breached.Contains(plain)
+		if err != nil {
+			return err
+		} else if breached {
+			reasons = append(reasons, "appeared in a data breach, choose another one")
+		}
+	}
+
+	if len(reasons) > 0 {
+		return &PolicyError{Field: field, Reasons: reasons}
+	}
+	return nil
+}
+
+func containsClass(plain, class string) bool {
+	for _, r := range plain {
+		switch {
+		case class == ClassLower && unicode.IsLower(r),
+			class == ClassUpper && unicode.IsUpper(r),
+			class == ClassDigit && unicode.IsDigit(r),
+			class == ClassSymbol && !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsSpace(r):
+			return true
+		}
+	}
+	return false
+}
+
+// expandUserInputs splits names into words and keeps both the whole address
+// and the local part of emails, lowercased.
+func expandUserInputs(userInputs []string) []string {
+	var expanded []string
+	for _, input := range userInputs {
+		input = strings.ToLower(strings.TrimSpace(input))
+		parts := strings.Fields(input)
+		if i := strings.Index(input, "@"); i >= 0 {
+			parts = []string{input, input[:i]}
+		}
+		for _, part := range parts {
+			if len([]rune(part)) >= minUserInputLength {
+				expanded = append(expanded, part)
+			}
+		}
+	}
+	return expanded
+}
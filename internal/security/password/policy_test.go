@@ -0,0 +1,76 @@
+package password
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/osamaesmail/go-post-api/internal/config"
+	"github.com/osamaesmail/go-post-api/internal/constant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy(t *testing.T) {
+	policy, err := NewPolicy(&config.Config{
+		PasswordMinLength:      10,
+		PasswordRequireClasses: "lower, upper,digit",
+		PasswordCheckBreached:  true,
+	})
+	require.NoError(t, err)
+
+	assert.NoError(t, policy.Check("password", "Tr0ub4dor&3x", "John Smith", "john@example.com"))
+
+	tests := []struct {
+		name    string
+		plain   string
+		reasons []string
+	}{
+		{"short", "Ab1", []string{"must be at least 10 characters long"}},
+		{"classes", "correcthorsebattery", []string{"must contain an uppercase letter", "must contain a digit"}},
+		{"name", "Smith12345678", []string{"must not contain your name or email"}},
+		{"email", "xJOHN@example.com1", []string{"must not contain your name or email"}},
+		{"breached", "Password123", []string{"appeared in a data breach, choose another one"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Check("new_password", tt.plain, "John Smith", "john@example.com")
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, constant.ErrPasswordPolicy))
+
+			var policyErr *PolicyError
+			require.True(t, errors.As(err, &policyErr))
+			assert.Equal(t, map[string][]string{"new_password": tt.reasons}, policyErr.Fields())
+		})
+	}
+
+	t.Run("unsupported class", func(t *testing.T) {
+		_, err := NewPolicy(&config.Config{PasswordRequireClasses: "emoji"})
+		assert.Error(t, err)
+	})
+}
+
+func TestBreachedList(t *testing.T) {
+	dir := t.TempDir()
+	// SHA-1 of "hunter2" is F3BBBD66A63D4BF1747940578EC3D0103530E21D
+	err := ioutil.WriteFile(filepath.Join(dir, "F3BBB.txt"), []byte("0000000000000000000000000000000000A:1\r\nD66A63D4BF1747940578EC3D0103530E21D:17043\r\n"), 0600)
+	require.NoError(t, err)
+
+	list, err := NewBreachedList(dir)
+	require.NoError(t, err)
+
+	breached, err := list.Contains("hunter2")
+	require.NoError(t, err)
+	assert.True(t, breached)
+
+	breached, err = list.Contains("hunter3")
+	require.NoError(t, err)
+	assert.False(t, breached, "no range file for the prefix")
+
+	bundledList, err := NewBreachedList("")
+	require.NoError(t, err)
+	breached, err = bundledList.Contains("qwerty")
+	require.NoError(t, err)
+	assert.True(t, breached)
+}
@@ -0,0 +1,42 @@
+package signature
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// MinKeyLength is the shortest key CheckKey accepts, as long as the
+// HMAC-SHA256 output.
+const MinKeyLength = 32
+
+// CheckKey refuses a key short enough to guess, an empty one would let anyone
+// sign links.
+func CheckKey(key string) error {
+	if len(key) < MinKeyLength {
+		return fmt.Errorf("signing key must be at least %d bytes", MinKeyLength)
+	}
+	return nil
+}
+
+// Sign returns the HMAC-SHA256 of message and the unix expiry under key, hex
+// encoded so it can go in a url as is.
+func Sign(key, message string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(message))
+	mac.Write([]byte{0})
+	mac.Write([]byte(strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature was made by Sign for message and expires,
+// and expires has not passed at now.
+func Verify(key, message string, expires int64, signature string, now time.Time) bool {
+	if key == "" || now.Unix() > expires {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(key, message, expires)), []byte(signature))
+}
@@ -0,0 +1,28 @@
+package signature
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify(t *testing.T) {
+	now := time.Now()
+	expires := now.Add(time.Hour).Unix()
+	signature := Sign("key", "export:1:2", expires)
+
+	assert.True(t, Verify("key", "export:1:2", expires, signature, now))
+	assert.False(t, Verify("key", "export:1:3", expires, signature, now), "other message")
+	assert.False(t, Verify("other key", "export:1:2", expires, signature, now), "other key")
+	assert.False(t, Verify("key", "export:1:2", expires+1, signature, now), "extended expiry")
+	assert.False(t, Verify("key", "export:1:2", expires, signature, now.Add(2*time.Hour)), "expired")
+	assert.False(t, Verify("key", "export:1:2", expires, "", now), "missing signature")
+	assert.False(t, Verify("", "export:1:2", expires, Sign("", "export:1:2", expires), now), "empty key")
+}
+
+func TestCheckKey(t *testing.T) {
+	assert.Error(t, CheckKey(""))
+	assert.Error(t, CheckKey("secret"))
+	assert.NoError(t, CheckKey("0123456789abcdef0123456789abcdef"))
+}
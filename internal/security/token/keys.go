@@ -0,0 +1,256 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/osamaesmail/go-post-api/internal/config"
+)
+
+// Key is a single signing or verification key, Private is nil for keys that
+// are only kept around to verify tokens signed before a rotation. A key with a
+// NotAfter stops verifying tokens then.
+type Key struct {
+	ID       string
+	Method   jwt.SigningMethod
+	Private  interface{}
+	Public   interface{}
+	NotAfter time.Time
+}
+
+// IsAsymmetric reports whether the key may be published.
+func (k *Key) IsAsymmetric() bool {
+	return k.Method != jwt.SigningMethodHS256
+}
+
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+}
+
+// NewKeySet builds the keys from the config. The configured signing key signs
+// every new token and JWT_PUBLIC_KEY_FILES lists previous public keys as a
+// comma separated "kid=path" list. After moving off HS256 the secret only
+// keeps verifying tokens without a kid until JWT_HS256_VERIFY_UNTIL, anyone
+// holding it could sign tokens otherwise.
+func NewKeySet(cfg *config.Config) (*KeySet, error) {
+	set := &KeySet{keys: map[string]*Key{}}
+
+	switch cfg.JwtSigningMethod {
+	case "", jwt.SigningMethodHS256.Alg():
+		if cfg.JwtSecretKey == "" {
+			return nil, errors.New("jwt secret key is required for HS256")
+		}
+		set.signing = newSecretKey(cfg.JwtSecretKey, time.Time{})
+		set.keys[""] = set.signing
+	case jwt.SigningMethodRS256.Alg(), SigningMethodEdDSA.Alg():
+		if cfg.JwtSecretKey != "" && !cfg.JwtHS256VerifyUntil.IsZero() {
+			set.keys[""] = newSecretKey(cfg.JwtSecretKey, cfg.JwtHS256VerifyUntil)
+		}
+
+		key, err := loadPrivateKey(cfg.JwtPrivateKeyFile, cfg.JwtKeyID)
+		if err != nil {
+			return nil, err
+		}
+		if key.Method.Alg() != cfg.JwtSigningMethod {
+			return nil, fmt.Errorf("jwt private key does not match signing method %s", cfg.JwtSigningMethod)
+		}
+		set.signing = key
+		set.keys[key.ID] = key
+	default:
+		return nil, fmt.Errorf("unsupported jwt signing method %s", cfg.JwtSigningMethod)
+	}
+
+	for _, entry := range strings.Split(cfg.JwtPublicKeyFiles, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		var kid, path string
+		if i := strings.Index(entry, "="); i >= 0 {
+			kid, path = entry[:i], entry[i+1:]
+		} else {
+			path = entry
+		}
+
+		key, err := loadPublicKey(path, kid)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := set.keys[key.ID]; !exists {
+			set.keys[key.ID] = key
+		}
+	}
+
+	return set, nil
+}
+
+func (s *KeySet) Sign(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(s.signing.Method, claims)
+	if s.signing.ID != "" {
+		token.Header["kid"] = s.signing.ID
+	}
+	return token.SignedString(s.signing.Private)
+}
+
+// Parse picks the verification key by the kid header, a token is only valid
+// when its alg is the one of that key.
+func (s *KeySet) Parse(tokenString string) (*jwt.Token, error) {
+	return jwt.Parse(tokenString, func(jwtToken *jwt.Token) (interface{}, error) {
+		kid, _ := jwtToken.Header["kid"].(string)
+		key, exists := s.keys[kid]
+		if !exists || jwtToken.Method.Alg() != key.Method.Alg() {
+			return nil, jwt.ErrInvalidKey
+		} else if !key.NotAfter.IsZero() && time.Now().After(key.NotAfter) {
+			return nil, jwt.ErrInvalidKey
+		}
+		return key.Public, nil
+	})
+}
+
+// PublicKeys returns the keys that can be published in a JWKS, sorted by kid.
+func (s *KeySet) PublicKeys() []*Key {
+	var keys []*Key
+	for _, key := range s.keys {
+		if key.IsAsymmetric() {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys
+}
+
+var (
+	keys     *KeySet
+	keysErr  error
+	keysOnce sync.Once
+)
+
+// LoadKeys loads the key set from the config once, call it on start up to fail
+// early on a bad key.
+func LoadKeys() (*KeySet, error) {
+	keysOnce.Do(func() {
+		keys, keysErr = NewKeySet(config.Cfg())
+	})
+	return keys, keysErr
+}
+
+func loadPrivateKey(path, kid string) (*Key, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var private crypto.PrivateKey
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		err = fmt.Errorf("unsupported pem block %s", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	switch private := private.(type) {
+	case *rsa.PrivateKey:
+		return newKey(jwt.SigningMethodRS256, private, &private.PublicKey, kid), nil
+	case ed25519.PrivateKey:
+		return newKey(SigningMethodEdDSA, private, private.Public(), kid), nil
+	default:
+		return nil, fmt.Errorf("%s: unsupported private key type %T", path, private)
+	}
+}
+
+func loadPublicKey(path, kid string) (*Key, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var public crypto.PublicKey
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		public, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		public, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		err = fmt.Errorf("unsupported pem block %s", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	switch public := public.(type) {
+	case *rsa.PublicKey:
+		return newKey(jwt.SigningMethodRS256, nil, public, kid), nil
+	case ed25519.PublicKey:
+		return newKey(SigningMethodEdDSA, nil, public, kid), nil
+	default:
+		return nil, fmt.Errorf("%s: unsupported public key type %T", path, public)
+	}
+}
+
+func newSecretKey(secret string, notAfter time.Time) *Key {
+	return &Key{Method: jwt.SigningMethodHS256, Private: []byte(secret), Public: []byte(secret), NotAfter: notAfter}
+}
+
+func newKey(method jwt.SigningMethod, private, public interface{}, kid string) *Key {
+	if kid == "" {
+		kid = Thumbprint(public)
+	}
+	return &Key{ID: kid, Method: method, Private: private, Public: public}
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no pem block found", path)
+	}
+	return block, nil
+}
+
+// Thumbprint is the RFC 7638 thumbprint of a public key, used as the kid when
+// none is configured.
+func Thumbprint(public interface{}) string {
+	var canonical string
+	switch public := public.(type) {
+	case *rsa.PublicKey:
+		canonical = fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`,
+			encodeBigInt(big.NewInt(int64(public.E))), encodeBigInt(public.N))
+	case ed25519.PublicKey:
+		canonical = fmt.Sprintf(`{"crv":"Ed25519","kty":"OKP","x":"%s"}`,
+			base64.RawURLEncoding.EncodeToString(public))
+	default:
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func encodeBigInt(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
@@ -0,0 +1,125 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/osamaesmail/go-post-api/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	path := filepath.Join(dir, name)
+	err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600)
+	require.NoError(t, err)
+	return path
+}
+
+func TestKeySet(t *testing.T) {
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaPrivate := writePEM(t, dir, "rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+	rsaPublicDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+	rsaPublic := writePEM(t, dir, "rsa.pub.pem", "PUBLIC KEY", rsaPublicDER)
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	edPrivateDER, err := x509.MarshalPKCS8PrivateKey(edKey)
+	require.NoError(t, err)
+	edPrivate := writePEM(t, dir, "ed.pem", "PRIVATE KEY", edPrivateDER)
+
+	claims := jwt.MapClaims{"id": 1}
+
+	t.Run("hs256", func(t *testing.T) {
+		set, err := NewKeySet(&config.Config{JwtSigningMethod: "HS256", JwtSecretKey: "secret"})
+		require.NoError(t, err)
+
+		signed, err := set.Sign(claims)
+		require.NoError(t, err)
+		parsed, err := set.Parse(signed)
+		require.NoError(t, err)
+		assert.True(t, parsed.Valid)
+		assert.Empty(t, set.PublicKeys())
+	})
+
+	t.Run("rs256", func(t *testing.T) {
+		set, err := NewKeySet(&config.Config{JwtSigningMethod: "RS256", JwtKeyID: "rsa-1", JwtPrivateKeyFile: rsaPrivate})
+		require.NoError(t, err)
+
+		signed, err := set.Sign(claims)
+		require.NoError(t, err)
+		parsed, err := set.Parse(signed)
+		require.NoError(t, err)
+		assert.Equal(t, "rsa-1", parsed.Header["kid"])
+		require.Len(t, set.PublicKeys(), 1)
+	})
+
+	t.Run("eddsa with rotated rsa key", func(t *testing.T) {
+		old, err := NewKeySet(&config.Config{JwtSigningMethod: "RS256", JwtKeyID: "rsa-1", JwtPrivateKeyFile: rsaPrivate})
+		require.NoError(t, err)
+		oldSigned, err := old.Sign(claims)
+		require.NoError(t, err)
+
+		set, err := NewKeySet(&config.Config{JwtSigningMethod: "EdDSA", JwtPrivateKeyFile: edPrivate, JwtPublicKeyFiles: "rsa-1=" + rsaPublic})
+		require.NoError(t, err)
+
+		signed, err := set.Sign(claims)
+		require.NoError(t, err)
+		parsed, err := set.Parse(signed)
+		require.NoError(t, err)
+		assert.Equal(t, Thumbprint(edKey.Public()), parsed.Header["kid"])
+
+		parsed, err = set.Parse(oldSigned)
+		require.NoError(t, err)
+		assert.True(t, parsed.Valid)
+		assert.Len(t, set.PublicKeys(), 2)
+	})
+
+	t.Run("hs256 secret after moving to rs256", func(t *testing.T) {
+		old, err := NewKeySet(&config.Config{JwtSigningMethod: "HS256", JwtSecretKey: "secret"})
+		require.NoError(t, err)
+		oldSigned, err := old.Sign(claims)
+		require.NoError(t, err)
+
+		set, err := NewKeySet(&config.Config{JwtSigningMethod: "RS256", JwtSecretKey: "secret", JwtPrivateKeyFile: rsaPrivate})
+		require.NoError(t, err)
+		_, err = set.Parse(oldSigned)
+		assert.Error(t, err, "without JWT_HS256_VERIFY_UNTIL")
+
+		set, err = NewKeySet(&config.Config{JwtSigningMethod: "RS256", JwtSecretKey: "secret", JwtHS256VerifyUntil: time.Now().Add(time.Hour), JwtPrivateKeyFile: rsaPrivate})
+		require.NoError(t, err)
+		_, err = set.Parse(oldSigned)
+		assert.NoError(t, err, "before JWT_HS256_VERIFY_UNTIL")
+
+		set, err = NewKeySet(&config.Config{JwtSigningMethod: "RS256", JwtSecretKey: "secret", JwtHS256VerifyUntil: time.Now().Add(-time.Hour), JwtPrivateKeyFile: rsaPrivate})
+		require.NoError(t, err)
+		_, err = set.Parse(oldSigned)
+		assert.Error(t, err, "after JWT_HS256_VERIFY_UNTIL")
+	})
+
+	t.Run("alg must match the key", func(t *testing.T) {
+		set, err := NewKeySet(&config.Config{JwtSigningMethod: "RS256", JwtSecretKey: "secret", JwtKeyID: "rsa-1", JwtPrivateKeyFile: rsaPrivate})
+		require.NoError(t, err)
+
+		// an HS256 token claiming the kid of the public rsa key
+		forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		forged.Header["kid"] = "rsa-1"
+		signed, err := forged.SignedString(x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey))
+		require.NoError(t, err)
+
+		_, err = set.Parse(signed)
+		assert.Error(t, err)
+	})
+}
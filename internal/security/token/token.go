@@ -1,6 +1,10 @@
 package token
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"time"
 
 	jwt "github.com/dgrijalva/jwt-go"
@@ -12,8 +16,70 @@ type Generator interface {
 }
 
 func GenerateToken(g Generator) (string, error) {
+	return GenerateTokenWithTTL(g, config.Cfg().JwtTTL)
+}
+
+// GenerateTokenWithTTL is GenerateToken for tokens that should not live as
+// long as JWT_TTL.
+func GenerateTokenWithTTL(g Generator, ttl time.Duration) (string, error) {
+	keys, err := LoadKeys()
+	if err != nil {
+		return "", err
+	}
+
+	tokenID, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
 	claims := g.GenerateClaims()
-	claims["exp"] = time.Now().Add(config.Cfg().JwtTTL).Unix()
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(config.Cfg().JwtSecretKey))
+	claims["jti"] = tokenID
+	setIssued(claims, time.Now(), ttl)
+	return keys.Sign(claims)
+}
+
+// setIssued sets when the token is issued and expires. iat only has whole
+// seconds, iat_ms tells a token issued right after a revocation in the same
+// second from one issued before it.
+func setIssued(claims jwt.MapClaims, now time.Time, ttl time.Duration) {
+	claims["iat"] = now.Unix()
+	claims["iat_ms"] = now.UnixNano() / int64(time.Millisecond)
+	claims["exp"] = now.Add(ttl).Unix()
+}
+
+// IssuedAt returns when the token was issued, to the millisecond when it
+// carries iat_ms. Tokens minted before the iat claim existed count as issued
+// at 0.
+func IssuedAt(claims jwt.MapClaims) time.Time {
+	if millis, valid := claims["iat_ms"].(float64); valid {
+		return time.Unix(0, int64(millis)*int64(time.Millisecond))
+	}
+	issuedAt, _ := claims["iat"].(float64)
+	return time.Unix(int64(issuedAt), 0)
+}
+
+func ParseToken(tokenString string) (*jwt.Token, error) {
+	keys, err := LoadKeys()
+	if err != nil {
+		return nil, err
+	}
+	return keys.Parse(tokenString)
+}
+
+// GenerateOpaqueToken returns a random url safe token that carries no claims,
+// it is only meaningful to whoever stored it.
+func GenerateOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashOpaqueToken returns the digest under which an opaque token is stored,
+// so a leaked store does not leak usable tokens.
+func HashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,46 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssuedAt(t *testing.T) {
+	revokedBefore := time.Now().Truncate(time.Second).Add(500 * time.Millisecond)
+
+	t.Run("issued after a revocation in the same second", func(t *testing.T) {
+		claims := jwt.MapClaims{}
+		setIssued(claims, revokedBefore.Add(time.Millisecond), time.Hour)
+		claims = roundTrip(t, claims)
+
+		assert.Equal(t, revokedBefore.Unix(), int64(claims["iat"].(float64)))
+		assert.False(t, IssuedAt(claims).Before(revokedBefore))
+	})
+
+	t.Run("issued before a revocation in the same second", func(t *testing.T) {
+		claims := jwt.MapClaims{}
+		setIssued(claims, revokedBefore.Add(-time.Millisecond), time.Hour)
+		claims = roundTrip(t, claims)
+
+		assert.True(t, IssuedAt(claims).Before(revokedBefore))
+	})
+
+	t.Run("without iat_ms", func(t *testing.T) {
+		claims := jwt.MapClaims{"iat": float64(revokedBefore.Unix())}
+		assert.True(t, IssuedAt(claims).Before(revokedBefore))
+		assert.True(t, IssuedAt(jwt.MapClaims{}).Before(revokedBefore))
+	})
+}
+
+// roundTrip signs and parses claims so they come back with the types a
+// verifier sees.
+func roundTrip(t *testing.T, claims jwt.MapClaims) jwt.MapClaims {
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+	assert.NoError(t, err)
+	parsed, err := jwt.Parse(signed, func(*jwt.Token) (interface{}, error) { return []byte("secret"), nil })
+	assert.NoError(t, err)
+	return parsed.Claims.(jwt.MapClaims)
+}
@@ -0,0 +1,34 @@
+package totp
+
+import (
+	"crypto/rand"
+	"strings"
+)
+
+const recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz023456789"
+
+// GenerateRecoveryCodes returns n single use codes formatted as xxxxx-xxxxx,
+// the alphabet leaves out characters that are easy to misread and has 32
+// characters so every one is equally likely.
+func GenerateRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, n)
+	for i := range codes {
+		b := make([]byte, 10)
+		_, err := rand.Read(b)
+		if err != nil {
+			return nil, err
+		}
+		for j := range b {
+			b[j] = recoveryCodeAlphabet[int(b[j])%len(recoveryCodeAlphabet)]
+		}
+		codes[i] = string(b[:5]) + "-" + string(b[5:])
+	}
+	return codes, nil
+}
+
+// NormalizeRecoveryCode undoes what users tend to do to a code when typing it.
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
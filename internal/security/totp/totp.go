@@ -0,0 +1,106 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/osamaesmail/go-post-api/internal/config"
+)
+
+// RFC 6238 defaults, the ones every authenticator app supports
+const (
+	Digits = 6
+	Period = 30
+	Skew   = 1
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret returns a random 160 bit secret, base32 encoded the way the
+// otpauth URI expects it.
+func GenerateSecret() (string, error) {
+	b := make([]byte, 20)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(b), nil
+}
+
+// URI is the otpauth URI authenticator apps enroll from, usually shown as a
+// QR code.
+func URI(issuer, accountName, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(Digits))
+	query.Set("period", fmt.Sprint(Period))
+
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + accountName,
+		RawQuery: query.Encode(),
+	}
+	return u.String()
+}
+
+// Code returns the code of the time step t falls in.
+func Code(secret string, t time.Time) (string, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, t.Unix()/Period), nil
+}
+
+// Validate checks code against the time step of t and the Skew steps around
+// it, and returns the matching step so the caller can refuse to accept it
+// twice.
+func Validate(secret, code string, t time.Time) (int64, bool) {
+	key, err := encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil || len(key) == 0 || len(code) != Digits {
+		return 0, false
+	}
+
+	step := t.Unix() / Period
+	for i := step - Skew; i <= step+Skew; i++ {
+		if subtle.ConstantTimeCompare([]byte(hotp(key, i)), []byte(code)) == 1 {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// RequiredFor reports whether accounts with role need a second factor for
+// their role to apply, see TOTP_REQUIRED_ROLES.
+func RequiredFor(role string) bool {
+	for _, required := range strings.Split(config.Cfg().TotpRequiredRoles, ",") {
+		if strings.TrimSpace(required) == role {
+			return true
+		}
+	}
+	return false
+}
+
+func hotp(key []byte, counter int64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", Digits, value%1000000)
+}
@@ -0,0 +1,74 @@
+package totp
+
+import (
+	"encoding/base32"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// the SHA1 vectors of RFC 6238 appendix B, truncated to six digits
+func TestCode(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, expected := range vectors {
+		code, err := Code(secret, time.Unix(unix, 0))
+		require.NoError(t, err)
+		assert.Equal(t, expected, code, unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+
+	now := time.Unix(1600000000, 0)
+	code, err := Code(secret, now)
+	require.NoError(t, err)
+
+	step, valid := Validate(secret, code, now)
+	assert.True(t, valid)
+	assert.Equal(t, now.Unix()/Period, step)
+
+	_, valid = Validate(secret, code, now.Add(Period*time.Second))
+	assert.True(t, valid, "previous step is accepted")
+
+	_, valid = Validate(secret, code, now.Add(2*Period*time.Second))
+	assert.False(t, valid, "older steps are not")
+
+	_, valid = Validate(secret, "12345", now)
+	assert.False(t, valid)
+}
+
+func TestURI(t *testing.T) {
+	u, err := url.Parse(URI("Go Post API", "a@b.c", "ABC"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/Go Post API:a@b.c", u.Path)
+	assert.Equal(t, "ABC", u.Query().Get("secret"))
+	assert.Equal(t, "Go Post API", u.Query().Get("issuer"))
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	require.NoError(t, err)
+	require.Len(t, codes, 10)
+
+	for _, code := range codes {
+		assert.Len(t, code, 11)
+		assert.Equal(t, strings.Replace(code, "-", "", 1), NormalizeRecoveryCode(" "+strings.ToUpper(code)))
+	}
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"github.com/osamaesmail/go-post-api/internal/app/repository"
+	"github.com/osamaesmail/go-post-api/internal/app/service"
+	"github.com/osamaesmail/go-post-api/internal/db/mysql"
+	"github.com/osamaesmail/go-post-api/internal/db/redis"
+	"github.com/osamaesmail/go-post-api/internal/imaging"
+	"github.com/osamaesmail/go-post-api/internal/mailer"
+	"github.com/osamaesmail/go-post-api/internal/security/oidc"
+	"github.com/osamaesmail/go-post-api/internal/security/password"
+	"github.com/osamaesmail/go-post-api/internal/storage"
+)
+
+// app holds the repositories and services built once by Start, the router and
+// the workers share them.
+type app struct {
+	tokenRepository       repository.TokenRepository
+	accessTokenRepository repository.AccessTokenRepository
+	sessionRepository     repository.SessionRepository
+	auditRepository       repository.AuditRepository
+
+	authService         service.AuthService
+	accountService      service.AccountService
+	accountPurgeService service.AccountPurgeService
+	postService         service.PostService
+	commentService      service.CommentService
+	accessTokenService  service.AccessTokenService
+	sessionService      service.SessionService
+	totpService         service.TOTPService
+	auditService        service.AuditService
+	tagService          service.TagService
+	searchService       service.SearchService
+	avatarService       service.AvatarService
+	exportService       service.ExportService
+}
+
+func newApp(mysqlClient mysql.Client, redisClient redis.Client, mailer mailer.Mailer, passwordHasher password.PasswordHasher, passwordPolicy *password.Policy, oauthProviders oidc.Providers, fileStorage storage.Storage, avatarProcessor *imaging.AvatarProcessor, searchIndex repository.SearchIndex) *app {
+	accountRepository := repository.NewAccountRepository(mysqlClient, redisClient)
+	handleRepository := repository.NewHandleRepository(mysqlClient)
+	postRepository := repository.NewPostRepository(mysqlClient, redisClient)
+	postSlugRepository := repository.NewPostSlugRepository(mysqlClient)
+	postRenderRepository := repository.NewPostRenderRepository(redisClient)
+	commentRepository := repository.NewCommentRepository(mysqlClient, redisClient)
+	tokenRepository := repository.NewTokenRepository(redisClient)
+	auditRepository := repository.NewAuditRepository(mysqlClient)
+	accessTokenRepository := repository.NewAccessTokenRepository(mysqlClient)
+	recoveryCodeRepository := repository.NewRecoveryCodeRepository(mysqlClient)
+	loginAttemptRepository := repository.NewLoginAttemptRepository(redisClient)
+	sessionRepository := repository.NewSessionRepository(mysqlClient, redisClient)
+	identityRepository := repository.NewIdentityRepository(mysqlClient)
+	exportJobRepository := repository.NewExportJobRepository(mysqlClient)
+	tagRepository := repository.NewTagRepository(mysqlClient, redisClient)
+	transactor := repository.NewTransactor(mysqlClient)
+
+	return &app{
+		tokenRepository:       tokenRepository,
+		accessTokenRepository: accessTokenRepository,
+		sessionRepository:     sessionRepository,
+		auditRepository:       auditRepository,
+
+		authService:    service.NewAuthService(accountRepository, tokenRepository, sessionRepository, recoveryCodeRepository, loginAttemptRepository, identityRepository, auditRepository, transactor, passwordHasher, oauthProviders),
+		accountService: service.NewAccountService(accountRepository, handleRepository, tokenRepository, auditRepository, transactor, passwordHasher, passwordPolicy, mailer),
+		accountPurgeService: service.NewAccountPurgeService(accountRepository, handleRepository, postRepository, commentRepository, sessionRepository, accessTokenRepository, recoveryCodeRepository, identityRepository,
+			exportJobRepository, auditRepository, transactor, fileStorage, searchIndex),
+		postService:        service.NewPostService(postRepository, postSlugRepository, postRenderRepository, accountRepository, auditRepository, transactor, searchIndex),
+		commentService:     service.NewCommentService(commentRepository, postRepository, accountRepository, auditRepository, transactor, searchIndex),
+		accessTokenService: service.NewAccessTokenService(accessTokenRepository, auditRepository, transactor),
+		sessionService:     service.NewSessionService(sessionRepository, tokenRepository, auditRepository, transactor),
+		totpService:        service.NewTOTPService(accountRepository, tokenRepository, recoveryCodeRepository, auditRepository, transactor, passwordHasher),
+		auditService:       service.NewAuditService(auditRepository),
+		tagService:         service.NewTagService(tagRepository, auditRepository, transactor),
+		searchService:      service.NewSearchService(searchIndex),
+		avatarService:      service.NewAvatarService(accountRepository, auditRepository, transactor, fileStorage, avatarProcessor),
+		exportService:      service.NewExportService(exportJobRepository, accountRepository, postRepository, commentRepository, auditRepository, transactor, fileStorage),
+	}
+}
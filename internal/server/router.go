@@ -9,11 +9,8 @@ import (
 	"github.com/go-chi/httprate"
 	_ "github.com/osamaesmail/go-post-api/docs"
 	"github.com/osamaesmail/go-post-api/internal/app/handler"
-	"github.com/osamaesmail/go-post-api/internal/app/repository"
-	"github.com/osamaesmail/go-post-api/internal/app/service"
+	"github.com/osamaesmail/go-post-api/internal/app/model"
 	"github.com/osamaesmail/go-post-api/internal/config"
-	"github.com/osamaesmail/go-post-api/internal/db/mysql"
-	"github.com/osamaesmail/go-post-api/internal/db/redis"
 	"github.com/osamaesmail/go-post-api/internal/security/middleware"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
@@ -26,7 +23,7 @@ import (
 // @securityDefinitions.apikey ApiKeyAuth
 // @in header
 // @name X-API-Key
-func NewRouter(mysqlClient mysql.Client, redisClient redis.Client) *chi.Mux {
+func newRouter(a *app) *chi.Mux {
 	router := chi.NewRouter()
 
 	router.Use(httprate.LimitByIP(
@@ -34,53 +31,99 @@ func NewRouter(mysqlClient mysql.Client, redisClient redis.Client) *chi.Mux {
 		config.Cfg().HttpRateLimitTime,
 	))
 	router.Use(cors.AllowAll().Handler)
+	router.Use(chimiddleware.RequestID)
+	router.Use(middleware.RequestMetadata)
 	router.Use(chimiddleware.Logger)
 	router.Use(chimiddleware.Recoverer)
 
-	accountRepository := repository.NewAccountRepository(mysqlClient, redisClient)
-	postRepository := repository.NewPostRepository(mysqlClient, redisClient)
-	commentRepository := repository.NewCommentRepository(mysqlClient, redisClient)
+	authHandler := handler.NewAuthHandler(a.authService)
+	accountHandler := handler.NewAccountHandler(a.accountService)
+	postHandler := handler.NewPostHandler(a.postService)
+	commentHandler := handler.NewCommentHandler(a.commentService)
+	accessTokenHandler := handler.NewAccessTokenHandler(a.accessTokenService)
+	sessionHandler := handler.NewSessionHandler(a.sessionService)
+	totpHandler := handler.NewTOTPHandler(a.totpService)
+	auditHandler := handler.NewAuditHandler(a.auditService)
+	tagHandler := handler.NewTagHandler(a.tagService)
+	searchHandler := handler.NewSearchHandler(a.searchService)
+	avatarHandler := handler.NewAvatarHandler(a.avatarService)
+	exportHandler := handler.NewExportHandler(a.exportService)
 
-	authService := service.NewAuthService(accountRepository)
-	accountService := service.NewAccountService(accountRepository)
-	postService := service.NewPostService(postRepository)
-	commentService := service.NewCommentService(commentRepository)
-
-	authHandler := handler.NewAuthHandler(authService)
-	accountHandler := handler.NewAccountHandler(accountService)
-	postHandler := handler.NewPostHandler(postService)
-	commentHandler := handler.NewCommentHandler(commentService)
+	jwtVerifier := middleware.JWTVerifier(a.tokenRepository, a.accessTokenRepository, a.sessionRepository, a.auditRepository)
+	optionalJWTVerifier := middleware.OptionalJWTVerifier(a.tokenRepository, a.accessTokenRepository, a.sessionRepository, a.auditRepository)
 
 	router.Options("/*", func(w http.ResponseWriter, r *http.Request) {})
+	router.Get("/.well-known/jwks.json", authHandler.JWKS())
 	api := router.Route("/v1", func(router chi.Router) {})
 
 	api.Route("/accounts", func(r chi.Router) {
 		r.Post("/auth", authHandler.Login())
+		r.Post("/auth/totp", authHandler.LoginTOTP())
+		r.Post("/auth/refresh", authHandler.Refresh())
+		r.With(jwtVerifier).Post("/auth/logout", authHandler.Logout())
+		r.Get("/oauth/{provider}/start", authHandler.OauthStart())
+		r.Get("/oauth/{provider}/callback", authHandler.OauthCallback())
+
+		r.Post("/password/forgot", accountHandler.ForgotPassword())
+		r.Post("/password/reset", accountHandler.ResetPassword())
+		r.Get("/verify", accountHandler.Verify())
 
 		r.Post("/", accountHandler.Create())
-		r.Get("/", accountHandler.List())
-		r.Get("/{account_id}", accountHandler.Get())
-		r.With(middleware.JWTVerifier).Put("/{account_id}", accountHandler.Update())
-		r.With(middleware.JWTVerifier).Put("/{account_id}/password", accountHandler.UpdatePassword())
-		r.With(middleware.JWTVerifier).Delete("/{account_id}", accountHandler.Delete())
+		r.With(optionalJWTVerifier).Get("/", accountHandler.List())
+		r.With(optionalJWTVerifier).Get("/@{handle}", accountHandler.GetByHandle())
+		r.With(optionalJWTVerifier).Get("/{account_id}", accountHandler.Get())
+		r.With(jwtVerifier, middleware.RequireScope(model.ScopeAccountsWrite)).Put("/{account_id}", accountHandler.Update())
+		r.With(jwtVerifier, middleware.RequireScope(model.ScopeAccountsWrite)).Put("/{account_id}/handle", accountHandler.UpdateHandle())
+		r.Get("/{account_id}/avatar", avatarHandler.Get())
+		r.With(jwtVerifier, middleware.RequireScope(model.ScopeAccountsWrite)).Put("/{account_id}/avatar", avatarHandler.Update())
+		r.With(jwtVerifier, middleware.DenyImpersonation, middleware.RequireScope(model.ScopeAccountsWrite)).Put("/{account_id}/password", accountHandler.UpdatePassword())
+		r.With(jwtVerifier, middleware.DenyImpersonation, middleware.RequireScope(model.ScopeAccountsWrite), middleware.RequireRole(model.RoleAdmin)).Put("/{account_id}/role", accountHandler.UpdateRole())
+		r.With(jwtVerifier, middleware.RequireScope(model.ScopeAccountsWrite)).Post("/{account_id}/verification", accountHandler.ResendVerification())
+		r.With(jwtVerifier, middleware.DenyImpersonation, middleware.RequireScope(model.ScopeAccountsWrite)).Delete("/{account_id}", accountHandler.Delete())
+		r.With(jwtVerifier, middleware.RequireRole(model.RoleAdmin)).Post("/{account_id}/impersonate", authHandler.Impersonate())
+
+		r.With(jwtVerifier, middleware.DenyImpersonation).Post("/{account_id}/totp", totpHandler.Enroll())
+		r.With(jwtVerifier, middleware.DenyImpersonation).Post("/{account_id}/totp/confirm", totpHandler.Confirm())
+		r.With(jwtVerifier, middleware.DenyImpersonation).Post("/{account_id}/totp/recovery-codes", totpHandler.RegenerateRecoveryCodes())
+		r.With(jwtVerifier, middleware.DenyImpersonation).Delete("/{account_id}/totp", totpHandler.Disable())
+
+		r.With(jwtVerifier, middleware.RequireScope(model.ScopeAccountsRead)).Get("/{account_id}/sessions", sessionHandler.List())
+		r.With(jwtVerifier, middleware.RequireScope(model.ScopeAccountsWrite)).Delete("/{account_id}/sessions/{session_id}", sessionHandler.Delete())
+
+		r.With(jwtVerifier, middleware.DenyImpersonation).Post("/{account_id}/tokens", accessTokenHandler.Create())
+		r.With(jwtVerifier, middleware.RequireScope(model.ScopeAccountsRead)).Get("/{account_id}/tokens", accessTokenHandler.List())
+		r.With(jwtVerifier, middleware.DenyImpersonation, middleware.RequireScope(model.ScopeAccountsWrite)).Delete("/{account_id}/tokens/{token_id}", accessTokenHandler.Delete())
+
+		r.With(jwtVerifier, middleware.DenyImpersonation, middleware.RequireScope(model.ScopeAccountsRead)).Post("/{account_id}/export", exportHandler.Create())
+		r.With(optionalJWTVerifier).Get("/{account_id}/export/{job_id}", exportHandler.Get())
 	})
 
 	api.Route("/posts", func(r chi.Router) {
-		r.With(middleware.JWTVerifier).Post("/", postHandler.Create())
-		r.Get("/", postHandler.List())
-		r.Get("/{post_id}", postHandler.Get())
-		r.With(middleware.JWTVerifier).Put("/{post_id}", postHandler.Update())
-		r.With(middleware.JWTVerifier).Delete("/{post_id}", postHandler.Delete())
+		r.With(jwtVerifier, middleware.RequireScope(model.ScopePostsWrite)).Post("/", postHandler.Create())
+		r.With(optionalJWTVerifier).Get("/", postHandler.List())
+		r.With(optionalJWTVerifier).Get("/by-slug/{slug}", postHandler.GetBySlug())
+		r.With(optionalJWTVerifier).Get("/{post_id}", postHandler.Get())
+		r.With(jwtVerifier, middleware.RequireScope(model.ScopePostsWrite)).Put("/{post_id}", postHandler.Update())
+		r.With(jwtVerifier, middleware.RequireScope(model.ScopePostsWrite)).Delete("/{post_id}", postHandler.Delete())
+	})
+
+	api.Route("/tags", func(r chi.Router) {
+		r.Get("/", tagHandler.List())
+		r.With(jwtVerifier, middleware.RequireScope(model.ScopePostsWrite), middleware.RequireRole(model.RoleAdmin)).Put("/{tag}", tagHandler.Update())
 	})
 
 	api.Route("/comments", func(r chi.Router) {
-		r.With(middleware.JWTVerifier).Post("/", commentHandler.Create())
-		r.Get("/", commentHandler.List())
-		r.Get("/{comment_id}", commentHandler.Get())
-		r.With(middleware.JWTVerifier).Put("/{comment_id}", commentHandler.Update())
-		r.With(middleware.JWTVerifier).Delete("/{comment_id}", commentHandler.Delete())
+		r.With(jwtVerifier, middleware.RequireScope(model.ScopeCommentsWrite)).Post("/", commentHandler.Create())
+		r.With(optionalJWTVerifier).Get("/", commentHandler.List())
+		r.With(optionalJWTVerifier).Get("/{comment_id}", commentHandler.Get())
+		r.With(jwtVerifier, middleware.RequireScope(model.ScopeCommentsWrite)).Put("/{comment_id}", commentHandler.Update())
+		r.With(jwtVerifier, middleware.RequireScope(model.ScopeCommentsWrite)).Delete("/{comment_id}", commentHandler.Delete())
 	})
 
+	api.Get("/search", searchHandler.Search())
+
+	api.With(jwtVerifier, middleware.RequireRole(model.RoleAdmin)).Get("/audit", auditHandler.List())
+
 	api.Get("/swagger/*", httpSwagger.Handler(
 		httpSwagger.URL("doc.json"),
 	))
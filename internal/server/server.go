@@ -6,15 +6,30 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 
+	"github.com/osamaesmail/go-post-api/internal/app/model"
+	"github.com/osamaesmail/go-post-api/internal/app/repository"
 	"github.com/osamaesmail/go-post-api/internal/config"
 	"github.com/osamaesmail/go-post-api/internal/db/mysql"
 	"github.com/osamaesmail/go-post-api/internal/db/redis"
+	"github.com/osamaesmail/go-post-api/internal/imaging"
 	"github.com/osamaesmail/go-post-api/internal/logger"
+	"github.com/osamaesmail/go-post-api/internal/mailer"
+	"github.com/osamaesmail/go-post-api/internal/security/oidc"
+	"github.com/osamaesmail/go-post-api/internal/security/password"
+	"github.com/osamaesmail/go-post-api/internal/security/signature"
+	"github.com/osamaesmail/go-post-api/internal/security/token"
+	"github.com/osamaesmail/go-post-api/internal/storage"
 )
 
 func Start() error {
+	_, err := token.LoadKeys()
+	if err != nil {
+		return err
+	}
+
 	mysqlClient, err := mysql.NewClient()
 	if err != nil {
 		return err
@@ -27,9 +42,78 @@ func Start() error {
 	}
 	defer redisClient.Close()
 
+	mail, err := mailer.NewMailer()
+	if err != nil {
+		return err
+	}
+
+	passwordHasher, err := password.NewHasher(config.Cfg())
+	if err != nil {
+		return err
+	}
+
+	passwordPolicy, err := password.NewPolicy(config.Cfg())
+	if err != nil {
+		return err
+	}
+
+	oauthProviders, err := oidc.NewProviders(config.Cfg())
+	if err != nil {
+		return err
+	}
+
+	fileStorage, err := storage.NewStorage(config.Cfg())
+	if err != nil {
+		return err
+	}
+
+	avatarProcessor, err := imaging.NewAvatarProcessor(config.Cfg())
+	if err != nil {
+		return err
+	}
+
+	err = signature.CheckKey(config.Cfg().ExportSigningKey)
+	if err != nil {
+		return fmt.Errorf("EXPORT_SIGNING_KEY: %w", err)
+	}
+
+	switch config.Cfg().AccountPurgePolicy {
+	case model.AccountPurgeAnonymize, model.AccountPurgeDelete:
+	default:
+		return fmt.Errorf("unknown account purge policy %s", config.Cfg().AccountPurgePolicy)
+	}
+
+	searchIndex, err := repository.NewSearchIndex(mysqlClient)
+	if err != nil {
+		return err
+	}
+
+	a := newApp(mysqlClient, redisClient, mail, passwordHasher, passwordPolicy, oauthProviders, fileStorage, avatarProcessor, searchIndex)
+
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	var workers sync.WaitGroup
+	workers.Add(4)
+	go func() {
+		defer workers.Done()
+		flushSessionLastSeen(workerCtx, a.sessionRepository, config.Cfg().SessionFlushInterval)
+	}()
+	go func() {
+		defer workers.Done()
+		purgeDeletedAccounts(workerCtx, a.accountPurgeService, config.Cfg().AccountPurgeInterval)
+	}()
+	go func() {
+		defer workers.Done()
+		runExports(workerCtx, a.exportService, config.Cfg().ExportPollInterval)
+	}()
+	go func() {
+		defer workers.Done()
+		publishScheduledPosts(workerCtx, a.postService, config.Cfg().PostPublishInterval)
+	}()
+
 	httpServer := &http.Server{
 		Addr:    fmt.Sprintf(":%d", config.Cfg().AppPort),
-		Handler: NewRouter(mysqlClient, redisClient),
+		Handler: newRouter(a),
 	}
 
 	idleConnsClosed := make(chan struct{})
@@ -56,6 +140,9 @@ func Start() error {
 
 	<-idleConnsClosed
 
+	stopWorkers()
+	workers.Wait()
+
 	logger.Log().Info().Msg("stopped server gracefully")
 	return nil
 }
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/osamaesmail/go-post-api/internal/app/repository"
+	"github.com/osamaesmail/go-post-api/internal/app/service"
+	"github.com/osamaesmail/go-post-api/internal/logger"
+)
+
+// flushSessionLastSeen writes the session last seen times every interval, and
+// once more when ctx is done so a shutdown does not lose them.
+func flushSessionLastSeen(ctx context.Context, sessionRepository repository.SessionRepository, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	flush := func() {
+		n, err := sessionRepository.FlushLastSeen(context.Background())
+		if err != nil {
+			logger.Log().Err(err).Msg("failed to flush session last seen")
+			return
+		}
+		logger.Log().Debug().Int("sessions", n).Msg("flushed session last seen")
+	}
+
+	for {
+		select {
+		case <-ticker.C:
+			flush()
+		case <-ctx.Done():
+			flush()
+			return
+		}
+	}
+}
+
+// purgeDeletedAccounts purges the accounts past their deletion grace period
+// every interval.
+func purgeDeletedAccounts(ctx context.Context, accountPurgeService service.AccountPurgeService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			n, err := accountPurgeService.Purge(ctx)
+			if err != nil {
+				logger.Log().Err(err).Int("accounts", n).Msg("failed to purge deleted accounts")
+				continue
+			}
+			logger.Log().Debug().Int("accounts", n).Msg("purged deleted accounts")
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// runExports builds the pending exports and removes the expired archives every
+// interval.
+func runExports(ctx context.Context, exportService service.ExportService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			n, err := exportService.RunPending(ctx)
+			if err != nil {
+				logger.Log().Err(err).Int("exports", n).Msg("failed to run pending exports")
+				continue
+			}
+			logger.Log().Debug().Int("exports", n).Msg("ran pending exports")
+
+			n, err = exportService.Expire(ctx)
+			if err != nil {
+				logger.Log().Err(err).Int("exports", n).Msg("failed to expire exports")
+				continue
+			}
+			logger.Log().Debug().Int("exports", n).Msg("expired exports")
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// publishScheduledPosts publishes the scheduled posts that are due every
+// interval.
+func publishScheduledPosts(ctx context.Context, postService service.PostService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			n, err := postService.PublishScheduled(ctx)
+			if err != nil {
+				logger.Log().Err(err).Int("posts", n).Msg("failed to publish scheduled posts")
+				continue
+			}
+			logger.Log().Debug().Int("posts", n).Msg("published scheduled posts")
+		case <-ctx.Done():
+			return
+		}
+	}
+}
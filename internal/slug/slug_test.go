@@ -0,0 +1,30 @@
+package slug
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMake(t *testing.T) {
+	for title, slug := range map[string]string{
+		"Hello, World!":             "hello-world",
+		"  Go 1.16 -- what's new? ": "go-1-16-what-s-new",
+		"Crème brûlée à la carte":   "creme-brulee-a-la-carte",
+		"Straße in Łódź":            "strasse-in-lodz",
+		"Привет, мир":               "privet-mir",
+		"Καλημέρα":                  "kalimera",
+		"Объект":                    "obekt",
+		"日本語":                       Fallback,
+		"Go 日本語 post":               "go-post",
+		"":                          Fallback,
+	} {
+		assert.Equal(t, slug, Make(title), title)
+	}
+
+	long := Make(strings.Repeat("word ", 40))
+	assert.LessOrEqual(t, len(long), MaxLength)
+	assert.False(t, strings.HasSuffix(long, "-"), long)
+	assert.True(t, strings.HasSuffix(long, "word"), long)
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// localStorage keeps the files in a directory, good for a single instance or
+// a volume shared between instances.
+type localStorage struct {
+	dir string
+}
+
+func NewLocalStorage(dir string) Storage {
+	return &localStorage{dir}
+}
+
+// path maps the key into the directory, keys cannot climb out of it.
+func (s *localStorage) path(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(path.Clean("/"+key)))
+}
+
+// Put writes next to the file and renames it over, so Open never sees half of
+// it.
+func (s *localStorage) Put(ctx context.Context, key string, r io.Reader) error {
+	dst := s.path(key)
+	err := os.MkdirAll(filepath.Dir(dst), 0755)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	_, err = io.Copy(file, r)
+	if err != nil {
+		return err
+	}
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), dst)
+}
+
+func (s *localStorage) Open(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	file, err := os.Open(s.path(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return file, nil
+}
+
+func (s *localStorage) DeleteAll(ctx context.Context, prefix string) error {
+	if path.Clean("/"+prefix) == "/" {
+		// never the whole storage
+		return nil
+	}
+	return os.RemoveAll(s.path(prefix))
+}
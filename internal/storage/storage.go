@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/osamaesmail/go-post-api/internal/config"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+// Storage keeps the files the API serves, keys are slash separated paths like
+// avatars/1/128.
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Open returns ErrNotFound when nothing is stored under key.
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	// DeleteAll removes everything stored under the prefix.
+	DeleteAll(ctx context.Context, prefix string) error
+}
+
+// NewStorage picks the implementation named by STORAGE_DRIVER.
+func NewStorage(cfg *config.Config) (Storage, error) {
+	switch cfg.StorageDriver {
+	case "", "local":
+		return NewLocalStorage(cfg.StorageDir), nil
+	default:
+		return nil, fmt.Errorf("unsupported storage driver %s", cfg.StorageDriver)
+	}
+}
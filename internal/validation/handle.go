@@ -0,0 +1,48 @@
+package validation
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/go-playground/validator"
+)
+
+var handlePattern = regexp.MustCompile(`^[a-zA-Z0-9_]{3,30}$`)
+
+// reservedHandles would read like a route or like someone speaking for the
+// site in urls and mentions.
+var reservedHandles = map[string]bool{
+	"about":         true,
+	"admin":         true,
+	"administrator": true,
+	"api":           true,
+	"auth":          true,
+	"comments":      true,
+	"export":        true,
+	"help":          true,
+	"login":         true,
+	"logout":        true,
+	"me":            true,
+	"moderator":     true,
+	"null":          true,
+	"oauth":         true,
+	"posts":         true,
+	"root":          true,
+	"security":      true,
+	"settings":      true,
+	"staff":         true,
+	"support":       true,
+	"system":        true,
+	"undefined":     true,
+	"verify":        true,
+}
+
+// IsHandle reports whether s is a valid handle, handles compare case
+// insensitively so a reserved word is refused in any case.
+func IsHandle(s string) bool {
+	return handlePattern.MatchString(s) && !reservedHandles[strings.ToLower(s)]
+}
+
+func isHandle(fl validator.FieldLevel) bool {
+	return IsHandle(fl.Field().String())
+}
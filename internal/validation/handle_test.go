@@ -0,0 +1,28 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/osamaesmail/go-post-api/internal/constant"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsHandle(t *testing.T) {
+	for _, handle := range []string{"someone", "Some_One", "abc", "a23456789012345678901234567890"} {
+		assert.True(t, IsHandle(handle), handle)
+	}
+
+	for _, handle := range []string{"", "ab", "a234567890123456789012345678901", "some-one", "some one", "someoné", "admin", "Admin", "ME"} {
+		assert.False(t, IsHandle(handle), handle)
+	}
+}
+
+func TestStructHandle(t *testing.T) {
+	type request struct {
+		Handle string `validate:"omitempty,handle"`
+	}
+
+	assert.NoError(t, Struct(request{}))
+	assert.NoError(t, Struct(request{Handle: "someone"}))
+	assert.ErrorIs(t, Struct(request{Handle: "support"}), constant.ErrFieldValidation)
+}
@@ -0,0 +1,26 @@
+package validation
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/go-playground/validator"
+)
+
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9+#.-]{0,29}$`)
+
+// NormalizeTag lower cases the tag and joins its words with hyphens, so "Go
+// Modules" and "go-modules" are the same tag.
+func NormalizeTag(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), "-")
+}
+
+// IsTag reports whether s is a valid tag once normalized, up to 30 letters,
+// digits and the +#.- of names like c++, c# or node.js.
+func IsTag(s string) bool {
+	return tagPattern.MatchString(NormalizeTag(s))
+}
+
+func isTag(fl validator.FieldLevel) bool {
+	return IsTag(fl.Field().String())
+}
@@ -0,0 +1,35 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/osamaesmail/go-post-api/internal/constant"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	assert.Equal(t, "go", NormalizeTag("Go"))
+	assert.Equal(t, "go-modules", NormalizeTag("  Go \t Modules "))
+	assert.Equal(t, "node.js", NormalizeTag("Node.js"))
+}
+
+func TestIsTag(t *testing.T) {
+	for _, tag := range []string{"go", "C++", "c#", "node.js", "Go Modules", "a23456789012345678901234567890"} {
+		assert.True(t, IsTag(tag), tag)
+	}
+
+	for _, tag := range []string{"", "  ", "-go", "go,mysql", "gö", "a234567890123456789012345678901"} {
+		assert.False(t, IsTag(tag), tag)
+	}
+}
+
+func TestStructTag(t *testing.T) {
+	type request struct {
+		Tags []string `validate:"omitempty,max=2,dive,tag"`
+	}
+
+	assert.NoError(t, Struct(request{}))
+	assert.NoError(t, Struct(request{Tags: []string{"go", "MySQL"}}))
+	assert.ErrorIs(t, Struct(request{Tags: []string{"go", "my,sql"}}), constant.ErrFieldValidation)
+	assert.ErrorIs(t, Struct(request{Tags: []string{"a", "b", "c"}}), constant.ErrFieldValidation)
+}
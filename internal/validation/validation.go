@@ -5,8 +5,28 @@ import (
 	"github.com/osamaesmail/go-post-api/internal/constant"
 )
 
+var validate = newValidator()
+
+// newValidator registers the tags of this package next to the built in ones.
+func newValidator() *validator.Validate {
+	v := validator.New()
+	err := v.RegisterValidation("handle", isHandle)
+	if err != nil {
+		panic(err)
+	}
+	err = v.RegisterValidation("tag", isTag)
+	if err != nil {
+		panic(err)
+	}
+	err = v.RegisterValidation("weburl", isWebURL)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func Struct(s interface{}) error {
-	err := validator.New().Struct(s)
+	err := validate.Struct(s)
 	if err != nil {
 		errs := err.(validator.ValidationErrors)
 		for _, e := range errs {
@@ -0,0 +1,23 @@
+package validation
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/go-playground/validator"
+)
+
+// IsWebURL reports whether s is an absolute http or https url. The built in
+// url tag takes any scheme, javascript: and data: included, which is no good
+// for a link shown on a profile.
+func IsWebURL(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return strings.EqualFold(u.Scheme, "http") || strings.EqualFold(u.Scheme, "https")
+}
+
+func isWebURL(fl validator.FieldLevel) bool {
+	return IsWebURL(fl.Field().String())
+}
@@ -0,0 +1,18 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsWebURL(t *testing.T) {
+	for _, s := range []string{"https://example.com", "http://example.com/a?b=c", "HTTPS://example.com"} {
+		assert.True(t, IsWebURL(s), s)
+	}
+
+	for _, s := range []string{"", "example.com", "javascript:alert(1)", "data:text/html,<script>alert(1)</script>",
+		"ftp://example.com", "https://", "//example.com"} {
+		assert.False(t, IsWebURL(s), s)
+	}
+}
@@ -2,6 +2,7 @@ package web
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/osamaesmail/go-post-api/internal/app/model"
@@ -13,8 +14,20 @@ func MarshalPayload(w http.ResponseWriter, code int, payload interface{}) {
 	json.NewEncoder(w).Encode(payload)
 }
 
+// fieldsError is implemented by errors that carry reasons per request field.
+type fieldsError interface {
+	Fields() map[string][]string
+}
+
 func MarshalError(w http.ResponseWriter, code int, err error) {
+	res := model.ErrorResponse{Message: err.Error()}
+
+	var fieldsErr fieldsError
+	if errors.As(err, &fieldsErr) {
+		res.Fields = fieldsErr.Fields()
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
-	json.NewEncoder(w).Encode(model.ErrorResponse{Message: err.Error()})
+	json.NewEncoder(w).Encode(res)
 }
@@ -3,9 +3,11 @@ package web
 import (
 	"io"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/osamaesmail/go-post-api/internal/config"
@@ -36,6 +38,11 @@ func GetUrlQueryString(r *http.Request, key string) string {
 	return r.URL.Query().Get(key)
 }
 
+// GetUrlQueryStrings returns every value of a repeated query parameter.
+func GetUrlQueryStrings(r *http.Request, key string) []string {
+	return r.URL.Query()[key]
+}
+
 func GetUrlQueryInt(r *http.Request, key string) (int, error) {
 	i, err := strconv.Atoi(key)
 	if err != nil {
@@ -52,6 +59,25 @@ func GetUrlQueryInt64(r *http.Request, key string) (int64, error) {
 	return i, nil
 }
 
+// GetUrlQueryTime parses an RFC 3339 time.
+func GetUrlQueryTime(r *http.Request, key string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, r.URL.Query().Get(key))
+	if err != nil {
+		return time.Time{}, constant.ErrUrlQueryParameter
+	}
+	return t, nil
+}
+
+// GetClientIP returns the address of the peer, forwarding headers are not
+// trusted since anyone can set them.
+func GetClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
 func GetPagination(r *http.Request) (limit, offset int, err error) {
 	limitQuery := r.URL.Query().Get("limit")
 	offsetQuery := r.URL.Query().Get("offset")
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS `session`;
\ No newline at end of file
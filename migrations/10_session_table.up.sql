@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS `session` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `family_id` VARCHAR(64) NOT NULL UNIQUE,
+    `user_agent` VARCHAR(512) NOT NULL,
+    `ip` VARCHAR(45) NOT NULL,
+    `last_seen_at` DATETIME,
+    `revoked_at` DATETIME,
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP(),
+    `account_id` BIGINT NOT NULL REFERENCES account(id)
+);
\ No newline at end of file
@@ -0,0 +1,6 @@
+ALTER TABLE `audit_event`
+    DROP INDEX `audit_event_actor_id`,
+    DROP INDEX `audit_event_target`,
+    DROP COLUMN `ip`,
+    DROP COLUMN `request_id`,
+    DROP COLUMN `diff`;
\ No newline at end of file
@@ -0,0 +1,6 @@
+ALTER TABLE `audit_event`
+    ADD COLUMN `ip` VARCHAR(45),
+    ADD COLUMN `request_id` VARCHAR(64),
+    ADD COLUMN `diff` JSON,
+    ADD INDEX `audit_event_actor_id` (`actor_id`),
+    ADD INDEX `audit_event_target` (`target_type`, `target_id`);
\ No newline at end of file
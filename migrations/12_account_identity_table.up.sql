@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS `account_identity` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `provider` VARCHAR(64) NOT NULL,
+    `subject` VARCHAR(255) NOT NULL,
+    `email` VARCHAR(255) NOT NULL,
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP(),
+    `account_id` BIGINT NOT NULL REFERENCES account(id),
+    UNIQUE INDEX `account_identity_provider_subject` (`provider`, `subject`)
+);
\ No newline at end of file
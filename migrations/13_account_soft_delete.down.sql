@@ -0,0 +1,4 @@
+ALTER TABLE `account`
+    DROP INDEX `account_deleted_at`,
+    DROP COLUMN `deleted_at`,
+    DROP COLUMN `purged_at`;
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE `account`
+    ADD COLUMN `deleted_at` DATETIME,
+    ADD COLUMN `purged_at` DATETIME,
+    ADD INDEX `account_deleted_at` (`deleted_at`);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS `export_job`;
\ No newline at end of file
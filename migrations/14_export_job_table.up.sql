@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS `export_job` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `status` VARCHAR(16) NOT NULL,
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP(),
+    `finished_at` DATETIME,
+    `account_id` BIGINT NOT NULL REFERENCES account(id),
+    INDEX `export_job_status` (`status`)
+);
\ No newline at end of file
@@ -0,0 +1,6 @@
+ALTER TABLE `account`
+    DROP INDEX `account_handle`,
+    DROP COLUMN `handle`,
+    DROP COLUMN `bio`,
+    DROP COLUMN `website`,
+    DROP COLUMN `avatar_url`;
\ No newline at end of file
@@ -0,0 +1,6 @@
+ALTER TABLE `account`
+    ADD COLUMN `handle` VARCHAR(30),
+    ADD COLUMN `bio` VARCHAR(500) NOT NULL DEFAULT '',
+    ADD COLUMN `website` VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN `avatar_url` VARCHAR(512) NOT NULL DEFAULT '',
+    ADD UNIQUE INDEX `account_handle` (`handle`);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS `account_handle_history`;
\ No newline at end of file
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS `account_handle_history` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `handle` VARCHAR(30) NOT NULL UNIQUE,
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP(),
+    `account_id` BIGINT NOT NULL REFERENCES account(id)
+);
\ No newline at end of file
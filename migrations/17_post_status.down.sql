@@ -0,0 +1,5 @@
+ALTER TABLE `post`
+    DROP INDEX `post_account_status`,
+    DROP INDEX `post_status_publish_at`,
+    DROP COLUMN `publish_at`,
+    DROP COLUMN `status`;
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE `post`
+    ADD COLUMN `status` VARCHAR(16) NOT NULL DEFAULT 'published',
+    ADD COLUMN `publish_at` DATETIME,
+    ADD INDEX `post_status_publish_at` (`status`, `publish_at`),
+    ADD INDEX `post_account_status` (`account_id`, `status`);
\ No newline at end of file
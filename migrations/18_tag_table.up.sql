@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS `tag` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `name` VARCHAR(30) NOT NULL UNIQUE,
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP()
+);
\ No newline at end of file
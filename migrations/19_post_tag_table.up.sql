@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS `post_tag` (
+    `post_id` BIGINT NOT NULL REFERENCES post(id),
+    `tag_id` BIGINT NOT NULL REFERENCES tag(id),
+    PRIMARY KEY (`post_id`, `tag_id`),
+    INDEX `post_tag_tag_id` (`tag_id`)
+);
\ No newline at end of file
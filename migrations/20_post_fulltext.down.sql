@@ -0,0 +1,3 @@
+ALTER TABLE `post`
+    DROP INDEX `post_title_body_fulltext`,
+    DROP INDEX `post_title_fulltext`;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE `post`
+    ADD FULLTEXT INDEX `post_title_fulltext` (`title`),
+    ADD FULLTEXT INDEX `post_title_body_fulltext` (`title`, `body`);
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE `comment`
+    DROP INDEX `comment_body_fulltext`;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE `comment`
+    ADD FULLTEXT INDEX `comment_body_fulltext` (`body`);
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE `post`
+    DROP INDEX `post_slug`,
+    DROP COLUMN `slug`;
\ No newline at end of file
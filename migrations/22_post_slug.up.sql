@@ -0,0 +1,3 @@
+ALTER TABLE `post`
+    ADD COLUMN `slug` VARCHAR(100),
+    ADD UNIQUE INDEX `post_slug` (`slug`);
\ No newline at end of file
@@ -0,0 +1 @@
+UPDATE `post` SET `slug` = NULL WHERE `slug` = CONCAT('post-', `id`);
\ No newline at end of file
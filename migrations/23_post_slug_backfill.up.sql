@@ -0,0 +1 @@
+UPDATE `post` SET `slug` = CONCAT('post-', `id`) WHERE `slug` IS NULL;
\ No newline at end of file
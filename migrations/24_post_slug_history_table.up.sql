@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS `post_slug_history` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `slug` VARCHAR(100) NOT NULL UNIQUE,
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP(),
+    `post_id` BIGINT NOT NULL REFERENCES post(id)
+);
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE `post`
+    DROP COLUMN `body_format`;
\ No newline at end of file
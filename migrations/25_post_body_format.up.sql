@@ -0,0 +1,2 @@
+ALTER TABLE `post`
+    ADD COLUMN `body_format` VARCHAR(16) NOT NULL DEFAULT 'plain';
\ No newline at end of file
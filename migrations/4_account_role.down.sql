@@ -0,0 +1 @@
+ALTER TABLE `account` DROP COLUMN `role`;
\ No newline at end of file
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS `audit_event` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `actor_id` BIGINT,
+    `action` VARCHAR(64) NOT NULL,
+    `target_type` VARCHAR(64) NOT NULL,
+    `target_id` BIGINT NOT NULL,
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP()
+);
\ No newline at end of file
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS `access_token` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `name` VARCHAR(255) NOT NULL,
+    `token_hash` CHAR(64) NOT NULL UNIQUE,
+    `scopes` VARCHAR(512) NOT NULL,
+    `expires_at` DATETIME,
+    `revoked_at` DATETIME,
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP(),
+    `account_id` BIGINT NOT NULL REFERENCES account(id)
+);
\ No newline at end of file
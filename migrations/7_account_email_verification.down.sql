@@ -0,0 +1,3 @@
+ALTER TABLE `account`
+    DROP COLUMN `email_verified_at`,
+    DROP COLUMN `pending_email`;
\ No newline at end of file
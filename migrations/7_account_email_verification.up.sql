@@ -0,0 +1,5 @@
+ALTER TABLE `account`
+    ADD COLUMN `email_verified_at` DATETIME,
+    ADD COLUMN `pending_email` VARCHAR (255);
+
+UPDATE `account` SET `email_verified_at` = `created_at` WHERE `email_verified_at` IS NULL;
\ No newline at end of file
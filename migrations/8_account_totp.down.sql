@@ -0,0 +1,3 @@
+ALTER TABLE `account`
+    DROP COLUMN `totp_secret`,
+    DROP COLUMN `totp_enabled_at`;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE `account`
+    ADD COLUMN `totp_secret` VARCHAR(64),
+    ADD COLUMN `totp_enabled_at` DATETIME;
\ No newline at end of file
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS `recovery_code` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `code_hash` CHAR(64) NOT NULL,
+    `used_at` DATETIME,
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP(),
+    `account_id` BIGINT NOT NULL REFERENCES account(id),
+    UNIQUE KEY `recovery_code_account_id_code_hash` (`account_id`, `code_hash`)
+);
\ No newline at end of file